@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+	"video-processor/internal/batch"
+	"video-processor/internal/monitor"
+	"video-processor/pkg/denoise"
+)
+
+// executarBatch implementa o comando "batch": processa todos os vídeos de um diretório que casam
+// com o padrão, espelhando a árvore no diretório de saída, com vários arquivos ao mesmo tempo
+// dentro de um orçamento global de CPUs. Saídas já existentes são puladas, então um lote
+// interrompido pode ser retomado rodando o mesmo comando.
+func executarBatch(args []string) int {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	dirEntrada := fs.String("in", "", "diretório de entrada (obrigatório)")
+	dirSaida := fs.String("out", "", "diretório de saída, com a mesma árvore da entrada (obrigatório)")
+	padrao := fs.String("glob", "*.mp4", "padrão do nome dos arquivos processados (sintaxe de filepath.Match)")
+	extensao := fs.String("ext", "", "extensão das saídas, como .y4m; vazio mantém a da entrada")
+	caminhoConfig := fs.String("config", "", "configuração JSON usada como modelo (filtros, quadros, codec); input e output.path são ignorados")
+	arquivos := fs.Int("jobs", 2, "quantos arquivos são processados ao mesmo tempo")
+	cpus := fs.Int("cpus", runtime.NumCPU(), "orçamento de CPUs: cada quadro em filtragem ocupa uma, somando todos os arquivos; os blocos e linhas dos quadros dividem um único pool com um worker por núcleo")
+	caminhoResumo := fs.String("summary", "", "se definido, grava também a tabela de resumo neste arquivo")
+	enderecoMetricas := fs.String("metrics-addr", "", "se definido, expõe /metrics (Prometheus) neste endereço durante o lote")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "uso: video-processor batch -in dir -out dir [-glob '*.mp4'] [-config job.json] [-jobs n] [-cpus n]")
+		fs.PrintDefaults()
+	}
+	flagsLog := registrarFlagsLog(fs)
+	fs.Parse(args)
+	if err := flagsLog.configurar(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if *dirEntrada == "" || *dirSaida == "" {
+		fs.Usage()
+		return 2
+	}
+
+	modelo, err := modeloBatch(*caminhoConfig)
+	if err != nil {
+		slog.Error("configuração inválida", "err", err)
+		return 2
+	}
+	tarefas, err := batch.Plan(*dirEntrada, *dirSaida, *padrao, *extensao)
+	if err != nil {
+		slog.Error("erro ao listar a entrada", "dir", *dirEntrada, "err", err)
+		return 1
+	}
+	if len(tarefas) == 0 {
+		slog.Warn("nenhum arquivo encontrado", "dir", *dirEntrada, "glob", *padrao)
+		return 0
+	}
+	if err := configuracaoBatch(modelo, tarefas[0], tarefas[0].Output).Validate(); err != nil {
+		slog.Error("configuração inválida", "err", err)
+		return 2
+	}
+
+	opcoes := opcoesTrabalho{cpu: denoise.NewCPUBudget(*cpus)}
+	if *enderecoMetricas != "" {
+		registro := monitor.NewRegistry()
+		opcoes.observador = monitor.NewPipelineMetrics(registro)
+		servirMetricas(*enderecoMetricas, registro)
+	}
+
+	ctx, parar := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer parar()
+	slog.Info("lote iniciado", "files", len(tarefas), "jobs", *arquivos, "cpus", *cpus)
+	inicio := time.Now()
+	resultados := batch.Run(ctx, tarefas, *arquivos, func(ctx context.Context, tarefa batch.Task, saida string) error {
+		return processarTrabalho(ctx, configuracaoBatch(modelo, tarefa, saida), opcoes)
+	})
+	duracao := time.Since(inicio)
+
+	if err := batch.WriteSummary(os.Stdout, resultados, duracao); err != nil {
+		slog.Error("erro ao escrever o resumo", "err", err)
+	}
+	if *caminhoResumo != "" {
+		err := gravarArquivo(*caminhoResumo, func(w io.Writer) error { return batch.WriteSummary(w, resultados, duracao) })
+		if err != nil {
+			slog.Error("erro ao gravar o resumo", "path", *caminhoResumo, "err", err)
+		}
+	}
+	if batch.Failed(resultados) {
+		return 1
+	}
+	return 0
+}
+
+// modeloBatch lê o modelo de configuração do lote ou, sem arquivo, usa os filtros padrão da CLI
+// sobre todos os quadros.
+func modeloBatch(caminho string) (denoise.JobConfig, error) {
+	if caminho == "" {
+		params := denoise.DefaultParams()
+		stages, err := params.Stages()
+		if err != nil {
+			return denoise.JobConfig{}, err
+		}
+		config := denoise.DefaultJobConfig()
+		config.AutoStrength = params.AutoStrength
+		config.Filters, err = denoise.FilterConfigs(stages)
+		return config, err
+	}
+
+	arquivo, err := os.Open(caminho)
+	if err != nil {
+		return denoise.JobConfig{}, err
+	}
+	defer arquivo.Close()
+	config, err := denoise.DecodeJobConfig(arquivo)
+	if err != nil {
+		return denoise.JobConfig{}, fmt.Errorf("%s: %w", caminho, err)
+	}
+	return config, nil
+}
+
+// configuracaoBatch aplica o modelo a um arquivo, gravando o vídeo em saida. As estatísticas, se
+// pedidas no modelo, ficam ao lado da saída final (<saída>.stats.json / .stats.csv); decisões e
+// visualização não são geradas em lote.
+func configuracaoBatch(modelo denoise.JobConfig, tarefa batch.Task, saida string) denoise.JobConfig {
+	config := modelo
+	config.Input = tarefa.Input
+	config.Output.Path = saida
+	config.Output.Original = ""
+
+	base := strings.TrimSuffix(tarefa.Output, filepath.Ext(tarefa.Output))
+	relatorios := denoise.ReportsConfig{Visualize: denoise.VisualNone.String(), DiffGain: modelo.Reports.DiffGain}
+	if modelo.Reports.StatsJSON != "" {
+		relatorios.StatsJSON = base + ".stats.json"
+	}
+	if modelo.Reports.StatsCSV != "" {
+		relatorios.StatsCSV = base + ".stats.csv"
+	}
+	config.Reports = relatorios
+	return config
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"video-processor/internal/metrics"
+)
+
+// executarCompare implementa o comando "compare": calcula PSNR, SSIM e MS-SSIM entre dois vídeos,
+// imprime o resumo e, opcionalmente, grava as métricas por frame em CSV e/ou JSON.
+func executarCompare(args []string) int {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	caminhoCSV := fs.String("csv", "", "grava as métricas por frame neste arquivo CSV")
+	caminhoJSON := fs.String("json", "", "grava o relatório completo neste arquivo JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "uso: video-processor compare [-csv arquivo] [-json arquivo] <referência> <processado>")
+		fs.PrintDefaults()
+	}
+	flagsLog := registrarFlagsLog(fs)
+	fs.Parse(args)
+	if err := flagsLog.configurar(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	referencia := carregarVideo(fs.Arg(0))
+	processado := carregarVideo(fs.Arg(1))
+	if referencia == nil || processado == nil {
+		slog.Error("não foi possível ler os dois vídeos")
+		return 1
+	}
+
+	relatorio, err := metrics.Compare(referencia, processado)
+	if err != nil {
+		slog.Error("erro ao comparar vídeos", "err", err)
+		return 1
+	}
+	fmt.Println(relatorio.Summary)
+
+	if *caminhoCSV != "" {
+		if err := gravarArquivo(*caminhoCSV, relatorio.WriteCSV); err != nil {
+			slog.Error("erro ao gravar CSV", "path", *caminhoCSV, "err", err)
+			return 1
+		}
+		slog.Info("métricas por frame gravadas", "path", *caminhoCSV)
+	}
+	if *caminhoJSON != "" {
+		if err := gravarArquivo(*caminhoJSON, relatorio.WriteJSON); err != nil {
+			slog.Error("erro ao gravar JSON", "path", *caminhoJSON, "err", err)
+			return 1
+		}
+		slog.Info("relatório gravado", "path", *caminhoJSON)
+	}
+
+	return 0
+}
+
+// gravarArquivo cria o arquivo no caminho informado e delega a escrita do conteúdo.
+func gravarArquivo(caminho string, escrever func(w io.Writer) error) error {
+	arquivo, err := os.Create(caminho)
+	if err != nil {
+		return err
+	}
+	if err := escrever(arquivo); err != nil {
+		arquivo.Close()
+		return err
+	}
+	return arquivo.Close()
+}
@@ -0,0 +1,184 @@
+// Package batch processa os vídeos de uma árvore de diretórios, espelhando a árvore no destino,
+// com vários arquivos ao mesmo tempo e um resumo ao final.
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Task é um arquivo a processar.
+type Task struct {
+	Rel    string // Caminho relativo ao diretório de entrada.
+	Input  string
+	Output string
+}
+
+// partialPrefix marca as saídas em andamento; elas só recebem o nome final quando o arquivo
+// termina, para que uma execução interrompida não pareça concluída.
+const partialPrefix = ".parcial-"
+
+// Partial retorna o caminho temporário da saída, com a mesma extensão.
+func (t Task) Partial() string {
+	return filepath.Join(filepath.Dir(t.Output), partialPrefix+filepath.Base(t.Output))
+}
+
+// Plan percorre inDir e lista os arquivos cujo nome casa com pattern (sintaxe de filepath.Match),
+// com a saída no mesmo caminho relativo dentro de outDir. Se ext não for vazio, substitui a extensão
+// da saída. Saídas parciais de execuções anteriores são ignoradas.
+func Plan(inDir, outDir, pattern, ext string) ([]Task, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("padrão inválido %q: %w", pattern, err)
+	}
+
+	var tasks []Task
+	err := filepath.WalkDir(inDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), partialPrefix) {
+			return nil
+		}
+		if ok, _ := filepath.Match(pattern, d.Name()); !ok {
+			return nil
+		}
+		rel, err := filepath.Rel(inDir, path)
+		if err != nil {
+			return err
+		}
+		output := filepath.Join(outDir, rel)
+		if ext != "" {
+			output = strings.TrimSuffix(output, filepath.Ext(output)) + ext
+		}
+		tasks = append(tasks, Task{Rel: rel, Input: path, Output: output})
+		return nil
+	})
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Rel < tasks[j].Rel })
+	return tasks, err
+}
+
+// Status é o resultado de um arquivo.
+type Status string
+
+const (
+	StatusOK      Status = "ok"
+	StatusFailed  Status = "falhou"
+	StatusSkipped Status = "pulado"
+)
+
+// Result é o resultado de um arquivo.
+type Result struct {
+	Task
+	Status  Status
+	Err     error
+	Elapsed time.Duration
+}
+
+// Runner processa task.Input e grava a saída em output, um caminho temporário com a mesma extensão
+// que é renomeado para task.Output quando o Runner termina sem erro.
+type Runner func(ctx context.Context, task Task, output string) error
+
+// Run processa as tarefas com até concurrency arquivos ao mesmo tempo. Arquivos cuja saída já
+// existe são pulados. Os resultados voltam na ordem das tarefas; quando ctx é cancelado, as
+// tarefas que não começaram ficam como falha com o erro do contexto.
+func Run(ctx context.Context, tasks []Task, concurrency int, run Runner) []Result {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]Result, len(tasks))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = runTask(ctx, tasks[i], run)
+			}
+		}()
+	}
+	for i := range tasks {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// runTask processa um arquivo numa saída parcial e a renomeia ao terminar.
+func runTask(ctx context.Context, task Task, run Runner) Result {
+	result := Result{Task: task}
+	if _, err := os.Stat(task.Output); err == nil {
+		result.Status = StatusSkipped
+		return result
+	}
+	if err := ctx.Err(); err != nil {
+		result.Status, result.Err = StatusFailed, err
+		return result
+	}
+
+	start := time.Now()
+	partial := task.Partial()
+	err := os.MkdirAll(filepath.Dir(task.Output), 0o755)
+	if err == nil {
+		err = run(ctx, task, partial)
+	}
+	if err == nil {
+		err = os.Rename(partial, task.Output)
+	}
+	result.Elapsed = time.Since(start)
+	if err != nil {
+		os.Remove(partial)
+		result.Status, result.Err = StatusFailed, err
+		return result
+	}
+	result.Status = StatusOK
+	return result
+}
+
+// Failed indica se algum arquivo falhou.
+func Failed(results []Result) bool {
+	for _, r := range results {
+		if r.Status == StatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteSummary escreve uma tabela com o resultado e o tempo de cada arquivo, seguida dos totais.
+func WriteSummary(w io.Writer, results []Result, elapsed time.Duration) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ARQUIVO\tSTATUS\tTEMPO\tERRO")
+	counts := make(map[Status]int)
+	for _, r := range results {
+		counts[r.Status]++
+		took, message := "-", ""
+		if r.Status != StatusSkipped {
+			took = r.Elapsed.Round(time.Millisecond).String()
+		}
+		if r.Err != nil {
+			message = r.Err.Error()
+			if errors.Is(r.Err, context.Canceled) {
+				message = "cancelado"
+			}
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", r.Rel, r.Status, took, message)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d arquivos: %d ok, %d falharam, %d pulados em %s\n", len(results),
+		counts[StatusOK], counts[StatusFailed], counts[StatusSkipped], elapsed.Round(time.Millisecond))
+	return err
+}
@@ -0,0 +1,151 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Helper function to create files (with their directories) under root
+func createFiles(t *testing.T, root string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Helper function for a runner that copies the input to the output
+func copyRunner(ctx context.Context, task Task, output string) error {
+	data, err := os.ReadFile(task.Input)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(output, data, 0o644)
+}
+
+func TestPlan_MirrorsTreeAndFilters(t *testing.T) {
+	in, out := t.TempDir(), t.TempDir()
+	createFiles(t, in, "a.mp4", "day1/b.mp4", "day1/notes.txt", "day2/deep/c.mp4", "day2/.parcial-d.mp4")
+
+	tasks, err := Plan(in, out, "*.mp4", ".y4m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rels, outputs []string
+	for _, task := range tasks {
+		rels = append(rels, task.Rel)
+		outputs = append(outputs, task.Output)
+	}
+	expectedRels := []string{"a.mp4", filepath.Join("day1", "b.mp4"), filepath.Join("day2", "deep", "c.mp4")}
+	if strings.Join(rels, ",") != strings.Join(expectedRels, ",") {
+		t.Errorf("rels = %v, expected %v", rels, expectedRels)
+	}
+	if expected := filepath.Join(out, "day2", "deep", "c.y4m"); outputs[2] != expected {
+		t.Errorf("output = %q, expected %q", outputs[2], expected)
+	}
+
+	if _, err := Plan(in, out, "[", ""); err == nil {
+		t.Error("expected error for an invalid pattern")
+	}
+}
+
+func TestRun_SkipsCompletedAndReportsFailures(t *testing.T) {
+	in, out := t.TempDir(), t.TempDir()
+	createFiles(t, in, "a.mp4", "b.mp4", "sub/c.mp4")
+	createFiles(t, out, "a.mp4")
+	tasks, err := Plan(in, out, "*.mp4", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := Run(context.Background(), tasks, 2, func(ctx context.Context, task Task, output string) error {
+		if filepath.Base(task.Input) == "b.mp4" {
+			os.WriteFile(output, []byte("partial"), 0o644)
+			return errors.New("decoder failed")
+		}
+		return copyRunner(ctx, task, output)
+	})
+
+	statuses := map[string]Status{}
+	for _, r := range results {
+		statuses[r.Rel] = r.Status
+	}
+	if statuses["a.mp4"] != StatusSkipped || statuses["b.mp4"] != StatusFailed || statuses[filepath.Join("sub", "c.mp4")] != StatusOK {
+		t.Errorf("statuses = %v", statuses)
+	}
+	if data, err := os.ReadFile(filepath.Join(out, "sub", "c.mp4")); err != nil || string(data) != "sub/c.mp4" {
+		t.Errorf("mirrored output = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "b.mp4")); err == nil {
+		t.Error("failed file left an output that would be skipped next time")
+	}
+	if _, err := os.Stat(filepath.Join(out, partialPrefix+"b.mp4")); err == nil {
+		t.Error("failed file left its partial output")
+	}
+	if !Failed(results) {
+		t.Error("Failed() = false with a failed file")
+	}
+
+	var summary strings.Builder
+	if err := WriteSummary(&summary, results, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"decoder failed", "3 arquivos: 1 ok, 1 falharam, 1 pulados"} {
+		if !strings.Contains(summary.String(), expected) {
+			t.Errorf("summary missing %q:\n%s", expected, summary.String())
+		}
+	}
+}
+
+func TestRun_LimitsConcurrency(t *testing.T) {
+	in, out := t.TempDir(), t.TempDir()
+	createFiles(t, in, "1.mp4", "2.mp4", "3.mp4", "4.mp4", "5.mp4")
+	tasks, err := Plan(in, out, "*.mp4", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	active, peak := 0, 0
+	Run(context.Background(), tasks, 2, func(ctx context.Context, task Task, output string) error {
+		mu.Lock()
+		active++
+		peak = max(peak, active)
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return copyRunner(ctx, task, output)
+	})
+	if peak != 2 {
+		t.Errorf("peak concurrency = %d, expected 2", peak)
+	}
+}
+
+func TestRun_CancelledContext(t *testing.T) {
+	in, out := t.TempDir(), t.TempDir()
+	createFiles(t, in, "a.mp4")
+	tasks, err := Plan(in, out, "*.mp4", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := Run(ctx, tasks, 1, copyRunner)
+	if results[0].Status != StatusFailed || !errors.Is(results[0].Err, context.Canceled) {
+		t.Errorf("result = %+v, expected a cancelled failure", results[0])
+	}
+}
@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+)
+
+// BilateralParams define os parâmetros do filtro bilateral.
+type BilateralParams struct {
+	Radius       int     `json:"radius"`        // Raio da janela quadrada em torno do pixel central.
+	SigmaSpatial float64 `json:"sigma_spatial"` // Desvio padrão do peso espacial (em pixels).
+	SigmaRange   float64 `json:"sigma_range"`   // Desvio padrão do peso de intensidade (em níveis de cinza).
+}
+
+// DefaultBilateralParams retorna parâmetros que suavizam ruído leve sem atravessar bordas de contraste médio.
+func DefaultBilateralParams() BilateralParams {
+	return BilateralParams{
+		Radius:       2,
+		SigmaSpatial: 2.0,
+		SigmaRange:   20.0,
+	}
+}
+
+// Validate verifica se os parâmetros estão dentro de intervalos válidos.
+func (p BilateralParams) Validate() error {
+	if p.Radius < 1 {
+		return fmt.Errorf("raio do filtro bilateral deve ser >= 1, recebido %d", p.Radius)
+	}
+	if p.SigmaSpatial <= 0 {
+		return fmt.Errorf("sigma espacial do filtro bilateral deve ser > 0, recebido %f", p.SigmaSpatial)
+	}
+	if p.SigmaRange <= 0 {
+		return fmt.Errorf("sigma de intensidade do filtro bilateral deve ser > 0, recebido %f", p.SigmaRange)
+	}
+	return nil
+}
+
+// BilateralFilter é um filtro bilateral com as tabelas de pesos já calculadas.
+// Cada vizinho é ponderado pela distância espacial e pela diferença de intensidade em relação ao centro,
+// de modo que vizinhos do outro lado de uma borda quase não contribuem.
+type BilateralFilter struct {
+	params         BilateralParams
+	spatialWeights [][]float64  // Pesos espaciais indexados por [dy+Radius][dx+Radius].
+	rangeWeights   [256]float64 // Pesos de intensidade indexados pela diferença absoluta.
+}
+
+// NewBilateralFilter cria um filtro bilateral, pré-calculando as tabelas de pesos.
+func NewBilateralFilter(params BilateralParams) (*BilateralFilter, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	f := &BilateralFilter{params: params}
+
+	size := 2*params.Radius + 1
+	spatialDenominator := 2 * params.SigmaSpatial * params.SigmaSpatial
+	f.spatialWeights = make([][]float64, size)
+	for dy := -params.Radius; dy <= params.Radius; dy++ {
+		f.spatialWeights[dy+params.Radius] = make([]float64, size)
+		for dx := -params.Radius; dx <= params.Radius; dx++ {
+			distance := float64(dx*dx + dy*dy)
+			f.spatialWeights[dy+params.Radius][dx+params.Radius] = math.Exp(-distance / spatialDenominator)
+		}
+	}
+
+	rangeDenominator := 2 * params.SigmaRange * params.SigmaRange
+	for diff := range f.rangeWeights {
+		f.rangeWeights[diff] = math.Exp(-float64(diff*diff) / rangeDenominator)
+	}
+
+	return f, nil
+}
+
+// Params retorna os parâmetros usados na criação do filtro.
+func (f *BilateralFilter) Params() BilateralParams {
+	return f.params
+}
+
+// filterRegion calcula o valor filtrado do pixel (centerY, centerX) de uma região.
+// A janela é recortada nas bordas da região.
+func (f *BilateralFilter) filterRegion(region Frame, centerY, centerX int) uint8 {
+	radius := f.params.Radius
+	center := int(region[centerY][centerX])
+
+	var weightedSum, weightTotal float64
+	for dy := -radius; dy <= radius; dy++ {
+		y := centerY + dy
+		if y < 0 || y >= len(region) {
+			continue
+		}
+		row := region[y]
+		spatialRow := f.spatialWeights[dy+radius]
+		for dx := -radius; dx <= radius; dx++ {
+			x := centerX + dx
+			if x < 0 || x >= len(row) {
+				continue
+			}
+			diff := int(row[x]) - center
+			if diff < 0 {
+				diff = -diff
+			}
+			weight := spatialRow[dx+radius] * f.rangeWeights[diff]
+			weightedSum += weight * float64(row[x])
+			weightTotal += weight
+		}
+	}
+
+	// O peso do próprio centro é sempre 1, então weightTotal nunca é zero.
+	return roundPixel(weightedSum / weightTotal)
+}
+
+// ApplyFrame aplica o filtro bilateral a todos os pixels do quadro e retorna um novo quadro.
+// O quadro é dividido em blocos processados em paralelo.
+func (f *BilateralFilter) ApplyFrame(frame Frame) Frame {
+	return f.applyTiled(frame, defaultTileSize)
+}
+
+// applyTiled divide o quadro em blocos de size x size pixels processados no pool compartilhado.
+// Cada bloco lê o halo de Radius pixels em volta direto do quadro de entrada e grava só os próprios
+// pixels, então o resultado não depende do tamanho do bloco.
+func (f *BilateralFilter) applyTiled(frame Frame, size int) Frame {
+	result := acquireFrameLike(frame)
+	if len(frame) == 0 {
+		return result
+	}
+	forEachTile(splitTiles(len(frame), len(frame[0]), size), func(t tile) {
+		for y := t.Y0; y < t.Y1; y++ {
+			for x := t.X0; x < t.X1; x++ {
+				result[y][x] = f.filterRegion(frame, y, x)
+			}
+		}
+	})
+	return result
+}
+
+// ApplyBilateralFilter aplica o filtro bilateral ao pixel central do PixelsRadius.
+// É a alternativa ao ApplyAdaptiveFilter: vizinhos com intensidade muito diferente do centro
+// recebem peso quase nulo, evitando que as bordas "vazem" mesmo com suavização forte.
+func (p PixelsRadius) ApplyBilateralFilter(f *BilateralFilter) {
+	if len(p.Pixels) == 0 {
+		return
+	}
+	p.Pixels[p.CenterY][p.CenterX] = f.filterRegion(p.Pixels, p.CenterY, p.CenterX)
+}
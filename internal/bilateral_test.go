@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBilateralParams_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  BilateralParams
+		wantErr bool
+	}{
+		{"defaults", DefaultBilateralParams(), false},
+		{"zero radius", BilateralParams{Radius: 0, SigmaSpatial: 1, SigmaRange: 1}, true},
+		{"zero spatial sigma", BilateralParams{Radius: 1, SigmaSpatial: 0, SigmaRange: 1}, true},
+		{"negative range sigma", BilateralParams{Radius: 1, SigmaSpatial: 1, SigmaRange: -5}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, err := NewBilateralFilter(tt.params); (err != nil) != tt.wantErr {
+				t.Errorf("NewBilateralFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBilateralFilter_UniformFrame(t *testing.T) {
+	filter, err := NewBilateralFilter(DefaultBilateralParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := filter.ApplyFrame(createTestFrame(6, 6, 137))
+	for y := range result {
+		for x := range result[y] {
+			if result[y][x] != 137 {
+				t.Fatalf("uniform frame changed at (%d, %d): %d", y, x, result[y][x])
+			}
+		}
+	}
+}
+
+func TestBilateralFilter_PreservesEdges(t *testing.T) {
+	filter, err := NewBilateralFilter(BilateralParams{Radius: 2, SigmaSpatial: 3, SigmaRange: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := filter.ApplyFrame(createEdgeFrame())
+	for x := 0; x < 5; x++ {
+		if result[1][x] > 5 {
+			t.Errorf("dark side of the edge bled: row 1, col %d = %d", x, result[1][x])
+		}
+		if result[2][x] < 250 {
+			t.Errorf("bright side of the edge bled: row 2, col %d = %d", x, result[2][x])
+		}
+	}
+}
+
+func TestBilateralFilter_SmoothsNoise(t *testing.T) {
+	filter, err := NewBilateralFilter(BilateralParams{Radius: 1, SigmaSpatial: 2, SigmaRange: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pixels := PixelsRadius{CenterX: 2, CenterY: 2, Pixels: createNoisyFrame()}
+	pixels.ApplyBilateralFilter(filter)
+
+	filtered := pixels.Pixels[2][2]
+	if math.Abs(float64(filtered)-100) >= math.Abs(255-100) {
+		t.Errorf("noisy pixel was not smoothed: %d", filtered)
+	}
+}
+
+func TestNewSpatialFilter(t *testing.T) {
+	frame := createNoisyFrame()
+
+	for _, mode := range []SpatialMode{SpatialAdaptive, SpatialBilateral} {
+		t.Run(mode.String(), func(t *testing.T) {
+			params := DefaultSpatialParams()
+			params.Mode = mode
+			filter, err := NewSpatialFilter(params)
+			if err != nil {
+				t.Fatalf("NewSpatialFilter() error = %v", err)
+			}
+			result := filter(frame)
+			if len(result) != len(frame) || len(result[0]) != len(frame[0]) {
+				t.Fatalf("filter returned %dx%d frame", len(result[0]), len(result))
+			}
+		})
+	}
+
+	params := DefaultSpatialParams()
+	params.Mode = SpatialBilateral
+	params.Bilateral.SigmaRange = 0
+	if _, err := NewSpatialFilter(params); err == nil {
+		t.Error("NewSpatialFilter should reject invalid bilateral params")
+	}
+
+	if _, err := ParseSpatialMode("gaussian"); err == nil {
+		t.Error("ParseSpatialMode should reject unknown modes")
+	}
+}
+
+func BenchmarkApplyBilateralFilter(b *testing.B) {
+	filter, err := NewBilateralFilter(BilateralParams{Radius: 1, SigmaSpatial: 2, SigmaRange: 20})
+	if err != nil {
+		b.Fatal(err)
+	}
+	frame := createPatternFrame(10, 10)
+	pixels := PixelsRadius{
+		CenterX: 5,
+		CenterY: 5,
+		Pixels:  frame,
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		// Same setup as BenchmarkApplyAdaptiveFilter so the numbers are comparable
+		testPixels := PixelsRadius{
+			CenterX: pixels.CenterX,
+			CenterY: pixels.CenterY,
+			Pixels:  make(Frame, len(pixels.Pixels)),
+		}
+		for j := range pixels.Pixels {
+			testPixels.Pixels[j] = make([]uint8, len(pixels.Pixels[j]))
+			copy(testPixels.Pixels[j], pixels.Pixels[j])
+		}
+		testPixels.ApplyBilateralFilter(filter)
+	}
+}
+
+func BenchmarkBilateralFilterFrame(b *testing.B) {
+	filter, err := NewBilateralFilter(DefaultBilateralParams())
+	if err != nil {
+		b.Fatal(err)
+	}
+	frame := createPatternFrame(100, 100)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		filter.ApplyFrame(frame)
+	}
+}
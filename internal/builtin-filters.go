@@ -0,0 +1,246 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+)
+
+// Nomes dos filtros registrados por este pacote.
+const (
+	FilterNameAdaptive       = "adaptive"
+	FilterNameBilateral      = "bilateral"
+	FilterNameNLMeans        = "nlmeans"
+	FilterNameTimeTravaler   = "timetravaler"
+	FilterNameSpatioTemporal = "spatiotemporal"
+	FilterNameGamma          = "gamma"
+)
+
+func init() {
+	RegisterFilter(FilterNameAdaptive, func(decode func(any) error) (Filter, error) {
+		f := &AdaptiveFilter{Radius: 1, Params: DefaultAdaptiveParams()}
+		if err := decode(f); err != nil {
+			return nil, err
+		}
+		return f, f.Validate()
+	})
+	RegisterFilter(FilterNameBilateral, func(decode func(any) error) (Filter, error) {
+		params := DefaultBilateralParams()
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		return NewBilateralFilter(params)
+	})
+	RegisterFilter(FilterNameNLMeans, func(decode func(any) error) (Filter, error) {
+		f := &NLMeansFilter{Params: DefaultNLMeansParams()}
+		if err := decode(&f.Params); err != nil {
+			return nil, err
+		}
+		return f, f.Params.Validate()
+	})
+	RegisterFilter(FilterNameTimeTravaler, func(decode func(any) error) (Filter, error) {
+		f := &TimeTravalerFilter{Window: 7, Params: DefaultTemporalParams()}
+		if err := decode(f); err != nil {
+			return nil, err
+		}
+		return f, f.Validate()
+	})
+	RegisterFilter(FilterNameSpatioTemporal, func(decode func(any) error) (Filter, error) {
+		f := &SpatioTemporalFilter{Params: DefaultSpatioTemporalParams()}
+		if err := decode(&f.Params); err != nil {
+			return nil, err
+		}
+		return f, f.Params.Validate()
+	})
+	RegisterFilter(FilterNameGamma, func(decode func(any) error) (Filter, error) {
+		f := &GammaFilter{Gamma: 1}
+		if err := decode(f); err != nil {
+			return nil, err
+		}
+		return f, f.Validate()
+	})
+}
+
+// AdaptiveFilter é o filtro adaptativo (ApplyAdaptiveFilterFrameWithParams) como Filter.
+type AdaptiveFilter struct {
+	Radius int            `json:"radius"`
+	Params AdaptiveParams `json:"params"`
+}
+
+// Validate verifica o raio da vizinhança e os parâmetros.
+func (f *AdaptiveFilter) Validate() error {
+	if f.Radius < 1 {
+		return fmt.Errorf("raio do filtro adaptativo deve ser >= 1, recebido %d", f.Radius)
+	}
+	return f.Params.Validate()
+}
+
+func (f *AdaptiveFilter) Name() string     { return FilterNameAdaptive }
+func (f *AdaptiveFilter) Kind() FilterKind { return FilterSpatial }
+func (f *AdaptiveFilter) Config() any      { return *f }
+func (f *AdaptiveFilter) pooledOutput()    {}
+
+// ApplyFrame aplica o filtro adaptativo ao quadro.
+func (f *AdaptiveFilter) ApplyFrame(frame Frame) Frame {
+	return ApplyAdaptiveFilterFrameWithParams(frame, f.Radius, f.Params)
+}
+
+// ScaledForNoise retorna uma cópia com os parâmetros escalados pelo ruído.
+func (f *AdaptiveFilter) ScaledForNoise(sigma float64) Filter {
+	return &AdaptiveFilter{Radius: f.Radius, Params: f.Params.ScaledForNoise(sigma)}
+}
+
+func (f *AdaptiveFilter) applyFrameWithStats(frame Frame) (Frame, AdaptiveCounts) {
+	return ApplyAdaptiveFilterFrameWithStats(frame, f.Radius, f.Params)
+}
+
+func (f *BilateralFilter) Name() string     { return FilterNameBilateral }
+func (f *BilateralFilter) Kind() FilterKind { return FilterSpatial }
+func (f *BilateralFilter) Config() any      { return f.params }
+func (f *BilateralFilter) pooledOutput()    {}
+
+// NLMeansFilter é o non-local means espacial como Filter.
+type NLMeansFilter struct {
+	Params NLMeansParams
+}
+
+func (f *NLMeansFilter) Name() string     { return FilterNameNLMeans }
+func (f *NLMeansFilter) Kind() FilterKind { return FilterSpatial }
+func (f *NLMeansFilter) Config() any      { return f.Params }
+func (f *NLMeansFilter) pooledOutput()    {}
+
+// ApplyFrame aplica o non-local means ao quadro.
+func (f *NLMeansFilter) ApplyFrame(frame Frame) Frame {
+	return ApplyNLMeans(frame, f.Params)
+}
+
+// TimeTravalerFilter é o TimeTravaler como Filter. É recursivo: o histórico de cada quadro
+// são os Window quadros anteriores já filtrados.
+type TimeTravalerFilter struct {
+	Window int `json:"window"` // Número de quadros anteriores usados como histórico.
+	// Direction é a direção da janela. Só WindowBackward é suportada: o histórico são quadros já
+	// filtrados, que não existem depois do quadro atual. Vazio equivale a WindowBackward.
+	Direction string         `json:"direction,omitempty"`
+	Params    TemporalParams `json:"params"`
+}
+
+// Direções da janela temporal.
+const (
+	WindowBackward      = "backward"      // Só quadros anteriores.
+	WindowBidirectional = "bidirectional" // Quadros anteriores e posteriores.
+)
+
+// Validate verifica a janela, a direção e os parâmetros.
+func (f *TimeTravalerFilter) Validate() error {
+	if f.Window < 1 {
+		return fmt.Errorf("janela do TimeTravaler deve ser >= 1, recebido %d", f.Window)
+	}
+	switch f.Direction {
+	case "", WindowBackward:
+	case WindowBidirectional:
+		return fmt.Errorf("janela do TimeTravaler só pode ser %q, pois ele filtra a partir dos quadros anteriores já filtrados; "+
+			"para uma janela bidirecional use o filtro %s", WindowBackward, FilterNameSpatioTemporal)
+	default:
+		return fmt.Errorf("direção da janela do TimeTravaler deve ser %q, recebido %q", WindowBackward, f.Direction)
+	}
+	return f.Params.Validate()
+}
+
+func (f *TimeTravalerFilter) Name() string     { return FilterNameTimeTravaler }
+func (f *TimeTravalerFilter) Kind() FilterKind { return FilterTemporal }
+func (f *TimeTravalerFilter) Config() any      { return *f }
+func (f *TimeTravalerFilter) pooledOutput()    {}
+
+// ApplyTemporal aplica o TimeTravaler ao quadro frameID.
+func (f *TimeTravalerFilter) ApplyTemporal(input, processed VideoFrames, frameID int) Frame {
+	frame, _ := f.apply(processed, frameID, nil)
+	return frame
+}
+
+// ScaledForNoise retorna uma cópia com os parâmetros escalados pelo ruído.
+func (f *TimeTravalerFilter) ScaledForNoise(sigma float64) Filter {
+	return &TimeTravalerFilter{Window: f.Window, Direction: f.Direction, Params: f.Params.ScaledForNoise(sigma)}
+}
+
+// history retorna quantos quadros filtrados anteriores o TimeTravaler lê. São pelo menos 3, porque
+// o filtro de linha também deixa passar os quadros 0..2: com menos histórico, um quadro posterior
+// seria tratado como um dos primeiros.
+func (f *TimeTravalerFilter) history() int {
+	return max(f.Window, 3)
+}
+
+func (f *TimeTravalerFilter) applyTemporalWithDecisions(input, processed VideoFrames, frameID int) (Frame, DecisionMap) {
+	frame := processed[frameID]
+	decisions := NewDecisionMap(len(frame), frameWidth(frame))
+	return f.apply(processed, frameID, decisions)
+}
+
+// apply roda o TimeTravaler gravando o quadro filtrado num quadro novo do pool, com a janela montada
+// a partir de processed, que não é modificado.
+func (f *TimeTravalerFilter) apply(processed VideoFrames, frameID int, decisions DecisionMap) (Frame, DecisionMap) {
+	filtered := acquireFrameLike(processed[frameID])
+	timeTravalerInto(filtered, processed, frameID, f.Window, f.Params, decisions)
+	return filtered, decisions
+}
+
+func (f *TimeTravalerFilter) sequence() temporalSequence {
+	return &timeTravalerSequence{filter: f}
+}
+
+// timeTravalerSequence aplica o TimeTravaler a quadros consecutivos mantendo a janela deslizante
+// entre eles, em vez de remontá-la a cada quadro.
+type timeTravalerSequence struct {
+	filter *TimeTravalerFilter
+	window *temporalWindow // Montada na primeira chamada.
+}
+
+// apply filtra o quadro frameID num quadro novo do pool e registra as decisões; processed não é
+// modificado.
+func (s *timeTravalerSequence) apply(input, processed VideoFrames, frameID int) (Frame, DecisionMap) {
+	frame := processed[frameID]
+	if s.window == nil {
+		s.window = newTemporalWindow(len(frame), frameWidth(frame), s.filter.Window, s.filter.Params.SimilarityThreshold)
+		s.window.seed(processed[max(frameID-s.filter.Window, 0):frameID])
+	}
+	filtered := acquireFrameLike(frame)
+	decisions := NewDecisionMap(len(frame), frameWidth(frame))
+	timeTravalerStep(filtered, frame, frameID, s.window, s.filter.Params, decisions)
+	return filtered, decisions
+}
+
+// SpatioTemporalFilter é o denoiser espaço-temporal conjunto como Filter. Não é recursivo:
+// busca patches nos quadros de entrada.
+type SpatioTemporalFilter struct {
+	Params SpatioTemporalParams
+}
+
+func (f *SpatioTemporalFilter) Name() string     { return FilterNameSpatioTemporal }
+func (f *SpatioTemporalFilter) Kind() FilterKind { return FilterTemporal }
+func (f *SpatioTemporalFilter) Config() any      { return f.Params }
+func (f *SpatioTemporalFilter) pooledOutput()    {}
+
+// ApplyTemporal aplica o denoiser conjunto ao quadro frameID.
+func (f *SpatioTemporalFilter) ApplyTemporal(input, processed VideoFrames, frameID int) Frame {
+	return ApplySpatioTemporalNLMeans(input, frameID, f.Params)
+}
+
+// GammaFilter aplica uma correção de gama: valores acima de 1 clareiam os tons médios.
+type GammaFilter struct {
+	Gamma float64 `json:"gamma"`
+}
+
+// Validate verifica se o gama é positivo.
+func (f *GammaFilter) Validate() error {
+	if !(f.Gamma > 0) {
+		return fmt.Errorf("gama deve ser > 0, recebido %f", f.Gamma)
+	}
+	return nil
+}
+
+func (f *GammaFilter) Name() string     { return FilterNameGamma }
+func (f *GammaFilter) Kind() FilterKind { return FilterPoint }
+func (f *GammaFilter) Config() any      { return *f }
+
+// ApplyPixel aplica a correção de gama a um valor.
+func (f *GammaFilter) ApplyPixel(value uint8) uint8 {
+	return uint8(math.Round(255 * math.Pow(float64(value)/255, 1/f.Gamma)))
+}
@@ -0,0 +1,175 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// checkpointVersion muda sempre que o formato do checkpoint muda, para que arquivos antigos
+// sejam recusados em vez de lidos errado.
+const checkpointVersion = 1
+
+// ErrCheckpointMismatch indica um checkpoint gravado com outros parâmetros ou por outra versão.
+var ErrCheckpointMismatch = errors.New("checkpoint gravado com outros parâmetros")
+
+// checkpointFile é o conteúdo gravado em disco.
+type checkpointFile struct {
+	Version int
+	Hash    string // Identifica os parâmetros do trabalho; veja JobConfig.Hash.
+	State   StreamState
+}
+
+// SaveCheckpoint grava o estado de RunStream em path, junto com o hash dos parâmetros do trabalho.
+// O arquivo é gravado num temporário e renomeado, então o checkpoint anterior nunca fica pela metade.
+func SaveCheckpoint(path, hash string, state StreamState) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	err = gob.NewEncoder(writer).Encode(checkpointFile{Version: checkpointVersion, Hash: hash, State: state})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return fmt.Errorf("gravando o checkpoint %s: %w", path, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadCheckpoint lê um checkpoint gravado por SaveCheckpoint. Retorna ErrCheckpointMismatch se
+// ele foi gravado com outro hash ou por outra versão do formato.
+func LoadCheckpoint(path, hash string) (StreamState, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return StreamState{}, err
+	}
+	defer file.Close()
+
+	var checkpoint checkpointFile
+	if err := gob.NewDecoder(bufio.NewReader(file)).Decode(&checkpoint); err != nil {
+		return StreamState{}, fmt.Errorf("lendo o checkpoint %s: %w", path, err)
+	}
+	if checkpoint.Version != checkpointVersion || checkpoint.Hash != hash {
+		return StreamState{}, fmt.Errorf("%s: %w", path, ErrCheckpointMismatch)
+	}
+	return checkpoint.State, nil
+}
+
+// FrameSpool grava os quadros de saída num arquivo Y4M monocromático à medida que ficam prontos,
+// para que não se percam se o processo morrer. Com um checkpoint, o arquivo pode ser reaberto para
+// continuar depois do último quadro confirmado.
+type FrameSpool struct {
+	file   *os.File
+	writer *Y4MWriter
+	frames int
+}
+
+// CreateFrameSpool cria (ou trunca) o arquivo e grava o cabeçalho.
+func CreateFrameSpool(path string, width, height int, fps float64) (*FrameSpool, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := NewY4MWriter(file, width, height, fps)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &FrameSpool{file: file, writer: writer}, nil
+}
+
+// ResumeFrameSpool reabre um spool que tem pelo menos frames quadros completos e descarta o que vier
+// depois deles, quadros gravados após o último checkpoint e talvez pela metade. As próximas gravações
+// continuam a partir do quadro frames.
+func ResumeFrameSpool(path string, frames int) (*FrameSpool, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	spool, err := resumeFrameSpool(file, frames)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return spool, nil
+}
+
+// resumeFrameSpool lê o cabeçalho de file, trunca o arquivo depois de frames quadros e posiciona
+// a escrita no fim.
+func resumeFrameSpool(file *os.File, frames int) (*FrameSpool, error) {
+	line, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("cabeçalho Y4M incompleto: %w", err)
+	}
+	reader, err := NewY4MReader(strings.NewReader(line))
+	if err != nil {
+		return nil, err
+	}
+	header := reader.Header()
+	if header.Colorspace != "mono" {
+		return nil, fmt.Errorf("spool deve ser Y4M mono, encontrado %q", header.Colorspace)
+	}
+
+	size := int64(len(line)) + int64(frames)*int64(len("FRAME\n")+header.Width*header.Height)
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < size {
+		return nil, fmt.Errorf("spool tem menos que os %d quadros do checkpoint", frames)
+	}
+	if err := file.Truncate(size); err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return &FrameSpool{file: file, writer: &Y4MWriter{writer: bufio.NewWriter(file), header: header}, frames: frames}, nil
+}
+
+// WriteFrame grava um quadro depois dos já gravados.
+func (s *FrameSpool) WriteFrame(frame Frame) error {
+	if err := s.writer.WriteFrame(frame); err != nil {
+		return err
+	}
+	s.frames++
+	return nil
+}
+
+// Frames retorna quantos quadros o spool tem, contando os de antes de ser reaberto.
+func (s *FrameSpool) Frames() int {
+	return s.frames
+}
+
+// Sync descarrega os quadros gravados e os força para o disco; deve ser chamado antes de gravar
+// um checkpoint que os conte.
+func (s *FrameSpool) Sync() error {
+	if err := s.writer.Flush(); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close descarrega os quadros pendentes e fecha o arquivo.
+func (s *FrameSpool) Close() error {
+	err := s.writer.Flush()
+	if errClose := s.file.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
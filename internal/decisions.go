@@ -0,0 +1,220 @@
+package internal
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// TemporalDecision identifica qual ramo do TimeTravaler decidiu o valor de um pixel.
+type TemporalDecision uint8
+
+const (
+	// DecisionSkipped marca pixels de quadros sem histórico suficiente, que não foram filtrados.
+	DecisionSkipped TemporalDecision = iota
+	// DecisionPassthrough marca pixels mantidos por não se encaixarem em nenhum caso.
+	DecisionPassthrough
+	// DecisionEdge marca pixels de borda, sempre mantidos.
+	DecisionEdge
+	// DecisionBlur marca pixels corrigidos como blur.
+	DecisionBlur
+	// DecisionNoise marca pixels corrigidos como ruído.
+	DecisionNoise
+	// DecisionAdaptive marca pixels suavizados pelo filtro temporal adaptativo.
+	DecisionAdaptive
+
+	numDecisions = int(DecisionAdaptive) + 1
+)
+
+// String retorna o nome da decisão.
+func (d TemporalDecision) String() string {
+	switch d {
+	case DecisionSkipped:
+		return "skipped"
+	case DecisionPassthrough:
+		return "passthrough"
+	case DecisionEdge:
+		return "edge"
+	case DecisionBlur:
+		return "blur"
+	case DecisionNoise:
+		return "noise"
+	case DecisionAdaptive:
+		return "adaptive"
+	default:
+		return fmt.Sprintf("TemporalDecision(%d)", int(d))
+	}
+}
+
+// decisionColors é a paleta do mapa de decisões: preto para quadros pulados, cinza para
+// passthrough, branco para bordas e cores saturadas para cada correção.
+var decisionColors = color.Palette{
+	DecisionSkipped:     color.RGBA{0, 0, 0, 255},
+	DecisionPassthrough: color.RGBA{96, 96, 96, 255},
+	DecisionEdge:        color.RGBA{255, 255, 255, 255},
+	DecisionBlur:        color.RGBA{0, 120, 255, 255},
+	DecisionNoise:       color.RGBA{255, 60, 0, 255},
+	DecisionAdaptive:    color.RGBA{0, 200, 80, 255},
+}
+
+// recordDecision registra a decisão de um pixel, se houver onde registrar.
+func recordDecision(decisions []TemporalDecision, x int, decision TemporalDecision) {
+	if decisions != nil {
+		decisions[x] = decision
+	}
+}
+
+// DecisionMap guarda a decisão do TimeTravaler para cada pixel de um quadro.
+type DecisionMap [][]TemporalDecision
+
+// NewDecisionMap cria um mapa com todos os pixels em DecisionSkipped.
+func NewDecisionMap(height, width int) DecisionMap {
+	decisions := make(DecisionMap, height)
+	for y := range decisions {
+		decisions[y] = make([]TemporalDecision, width)
+	}
+	return decisions
+}
+
+// DecisionCounts conta quantos pixels caíram em cada decisão, indexado por TemporalDecision.
+type DecisionCounts [numDecisions]int
+
+// Counts conta os pixels do mapa por decisão.
+func (m DecisionMap) Counts() DecisionCounts {
+	var counts DecisionCounts
+	for _, row := range m {
+		for _, decision := range row {
+			counts[decision]++
+		}
+	}
+	return counts
+}
+
+// Image converte o mapa numa imagem com a paleta de decisões.
+func (m DecisionMap) Image() *image.Paletted {
+	width := 0
+	if len(m) > 0 {
+		width = len(m[0])
+	}
+	img := image.NewPaletted(image.Rect(0, 0, width, len(m)), decisionColors)
+	for y, row := range m {
+		for x, decision := range row {
+			img.SetColorIndex(x, y, uint8(decision))
+		}
+	}
+	return img
+}
+
+// DecisionSink recebe o mapa de decisões de cada quadro processado pelo estágio temporal.
+type DecisionSink interface {
+	WriteDecisions(frameID int, decisions DecisionMap) error
+}
+
+// PNGDecisionSink grava cada mapa como decisions-NNNNN.png num diretório e as contagens
+// por quadro em decisions.csv, no mesmo diretório.
+type PNGDecisionSink struct {
+	dir    string
+	file   *os.File
+	counts *csv.Writer
+}
+
+// NewPNGDecisionSink cria o diretório, se necessário, e o CSV de contagens.
+func NewPNGDecisionSink(dir string) (*PNGDecisionSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return createPNGDecisionSink(dir, nil)
+}
+
+// ResumePNGDecisionSink reabre o diretório de uma execução interrompida que continua a partir do
+// quadro next: o CSV de contagens é regravado só com as linhas dos quadros anteriores, e as dos
+// demais, talvez gravadas depois do último checkpoint, são descartadas para não se repetirem. Sem
+// CSV no diretório, começa um novo, como NewPNGDecisionSink.
+func ResumePNGDecisionSink(dir string, next int) (*PNGDecisionSink, error) {
+	file, err := os.Open(filepath.Join(dir, "decisions.csv"))
+	if errors.Is(err, os.ErrNotExist) {
+		return NewPNGDecisionSink(dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	records, err := csv.NewReader(file).ReadAll()
+	file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("decisions.csv: %w", err)
+	}
+
+	var kept [][]string
+	for i, record := range records {
+		if i == 0 {
+			continue // Cabeçalho, regravado por createPNGDecisionSink.
+		}
+		frame, err := strconv.Atoi(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("decisions.csv, linha %d: quadro inválido %q", i+1, record[0])
+		}
+		if frame < next {
+			kept = append(kept, record)
+		}
+	}
+	return createPNGDecisionSink(dir, kept)
+}
+
+// createPNGDecisionSink cria o CSV de contagens com o cabeçalho seguido das linhas rows.
+func createPNGDecisionSink(dir string, rows [][]string) (*PNGDecisionSink, error) {
+	file, err := os.Create(filepath.Join(dir, "decisions.csv"))
+	if err != nil {
+		return nil, err
+	}
+
+	counts := csv.NewWriter(file)
+	header := []string{"frame"}
+	for d := 0; d < numDecisions; d++ {
+		header = append(header, TemporalDecision(d).String())
+	}
+	if err := counts.WriteAll(append([][]string{header}, rows...)); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &PNGDecisionSink{dir: dir, file: file, counts: counts}, nil
+}
+
+// WriteDecisions grava o PNG do quadro e acrescenta uma linha de contagens ao CSV.
+func (s *PNGDecisionSink) WriteDecisions(frameID int, decisions DecisionMap) error {
+	path := filepath.Join(s.dir, fmt.Sprintf("decisions-%05d.png", frameID))
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, decisions.Image()); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	counts := decisions.Counts()
+	record := []string{strconv.Itoa(frameID)}
+	for _, count := range counts {
+		record = append(record, strconv.Itoa(count))
+	}
+	return s.counts.Write(record)
+}
+
+// Close descarrega e fecha o CSV de contagens.
+func (s *PNGDecisionSink) Close() error {
+	s.counts.Flush()
+	if err := s.counts.Error(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
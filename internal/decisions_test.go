@@ -0,0 +1,171 @@
+package internal
+
+import (
+	"encoding/csv"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// Helper function to create a static video whose frame at index noisy has an isolated spike
+func createVideoWithSpike(frames, noisy int) VideoFrames {
+	video := make(VideoFrames, frames)
+	for i := range video {
+		video[i] = createTestFrame(8, 8, 100)
+	}
+	video[noisy][4][4] = 140
+	return video
+}
+
+func TestTimeTravalerWithDecisions_MatchesWithParams(t *testing.T) {
+	videoA := make(VideoFrames, 8)
+	for i := range videoA {
+		videoA[i] = addGaussianNoise(createRampWithEdgeFrame(10, 12), 4, int64(i))
+	}
+	videoB := copyClip(videoA)
+
+	TimeTravalerWithParams(videoA, 7, 5, DefaultTemporalParams())
+	decisions := TimeTravalerWithDecisions(videoB, 7, 5, DefaultTemporalParams())
+
+	for y := range videoA[7] {
+		for x := range videoA[7][y] {
+			if videoA[7][y][x] != videoB[7][y][x] {
+				t.Fatalf("outputs differ at (%d, %d): %d vs %d", y, x, videoA[7][y][x], videoB[7][y][x])
+			}
+		}
+	}
+
+	counts := decisions.Counts()
+	if counts[DecisionSkipped] != 0 {
+		t.Errorf("%d pixels skipped in a processed frame", counts[DecisionSkipped])
+	}
+	if counts[DecisionEdge] == 0 {
+		t.Error("expected edge decisions on the ramp edge and the frame border")
+	}
+}
+
+func TestTimeTravalerWithDecisions_RecordsBranches(t *testing.T) {
+	video := createVideoWithSpike(8, 7)
+	decisions := TimeTravalerWithDecisions(video, 7, 5, DefaultTemporalParams())
+
+	// O gradiente é nulo no centro de um pico isolado, que destoa do histórico estável;
+	// seus vizinhos veem o gradiente do pico e são mantidos como borda.
+	if got := decisions[4][4]; got != DecisionNoise {
+		t.Errorf("spike decision = %s, expected noise", got)
+	}
+	if got := decisions[4][5]; got != DecisionEdge {
+		t.Errorf("spike neighbour decision = %s, expected edge", got)
+	}
+	if got := decisions[1][6]; got != DecisionAdaptive {
+		t.Errorf("static pixel decision = %s, expected adaptive", got)
+	}
+
+	skipped := TimeTravalerWithDecisions(video, 2, 5, DefaultTemporalParams())
+	if counts := skipped.Counts(); counts[DecisionSkipped] != 64 {
+		t.Errorf("frame without history: %d skipped pixels, expected 64", counts[DecisionSkipped])
+	}
+}
+
+func TestPNGDecisionSink(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewPNGDecisionSink(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decisions := NewDecisionMap(3, 4)
+	decisions[1][2] = DecisionNoise
+	if err := sink.WriteDecisions(7, decisions); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(filepath.Join(dir, "decisions-00007.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 4 || img.Bounds().Dy() != 3 {
+		t.Errorf("image bounds = %v", img.Bounds())
+	}
+	if r, g, b, _ := img.At(2, 1).RGBA(); r>>8 != 255 || g>>8 != 60 || b != 0 {
+		t.Errorf("noise pixel color = (%d, %d, %d)", r>>8, g>>8, b>>8)
+	}
+
+	csvFile, err := os.Open(filepath.Join(dir, "decisions.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer csvFile.Close()
+	records, err := csv.NewReader(csvFile).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1][0] != "7" || records[1][1+int(DecisionNoise)] != "1" {
+		t.Errorf("csv = %v", records)
+	}
+}
+
+func TestResumePNGDecisionSink_KeepsRowsBeforeNext(t *testing.T) {
+	dir := t.TempDir()
+	decisions := NewDecisionMap(2, 2)
+
+	// writeFrames writes the decisions of frames [first, last) and closes the sink.
+	writeFrames := func(sink *PNGDecisionSink, first, last int) {
+		t.Helper()
+		for frame := first; frame < last; frame++ {
+			if err := sink.WriteDecisions(frame, decisions); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := sink.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sink, err := NewPNGDecisionSink(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The interrupted run got past the checkpoint at frame 3.
+	writeFrames(sink, 0, 5)
+
+	sink, err = ResumePNGDecisionSink(dir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFrames(sink, 3, 6)
+
+	csvFile, err := os.Open(filepath.Join(dir, "decisions.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer csvFile.Close()
+	records, err := csv.NewReader(csvFile).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 7 || records[0][0] != "frame" {
+		t.Fatalf("csv = %v, expected the header and frames 0 to 5", records)
+	}
+	for i, record := range records[1:] {
+		if record[0] != strconv.Itoa(i) {
+			t.Errorf("row %d is frame %s, expected %d", i+1, record[0], i)
+		}
+	}
+
+	// Without a CSV, resuming starts a new one.
+	empty := t.TempDir()
+	if sink, err = ResumePNGDecisionSink(empty, 3); err != nil {
+		t.Fatal(err)
+	}
+	writeFrames(sink, 3, 4)
+}
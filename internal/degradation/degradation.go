@@ -0,0 +1,207 @@
+// Package degradation gera versões degradadas de uma sequência de quadros limpa (ruído gaussiano,
+// sal e pimenta, flares, quedas escuras com blur e flicker), de forma reprodutível a partir de uma semente.
+// Serve para medir o quanto os filtros recuperam um ground truth conhecido.
+package degradation
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Config define quais degradações aplicar e com que intensidade. Valores zero desligam cada degradação.
+type Config struct {
+	Seed int64 // Semente do gerador; a mesma semente e os mesmos quadros geram a mesma saída.
+
+	GaussianSigma     float64 // Desvio padrão do ruído gaussiano aditivo.
+	SaltPepperDensity float64 // Fração de pixels substituídos por 0 ou 255.
+
+	FlareProbability float64 // Probabilidade de um quadro receber um flare.
+	FlareRadius      int     // Raio (desvio padrão, em pixels) da mancha de luz.
+	FlareIntensity   float64 // Acréscimo de brilho no centro do flare.
+
+	DropoutProbability float64 // Probabilidade de um quadro receber uma queda escura com blur.
+	DropoutSize        int     // Lado da região quadrada afetada.
+	DropoutDarkness    float64 // Fração do brilho mantida na região (0 = preto, 1 = sem escurecer).
+
+	FlickerAmplitude float64 // Variação relativa máxima do brilho global de cada quadro (0,1 = ±10%).
+}
+
+// Validate verifica se os parâmetros estão dentro de intervalos válidos.
+func (c Config) Validate() error {
+	if c.GaussianSigma < 0 {
+		return fmt.Errorf("sigma do ruído gaussiano deve ser >= 0, recebido %f", c.GaussianSigma)
+	}
+	if c.SaltPepperDensity < 0 || c.SaltPepperDensity > 1 {
+		return fmt.Errorf("densidade de sal e pimenta deve estar em [0, 1], recebido %f", c.SaltPepperDensity)
+	}
+	if c.FlareProbability < 0 || c.FlareProbability > 1 {
+		return fmt.Errorf("probabilidade de flare deve estar em [0, 1], recebido %f", c.FlareProbability)
+	}
+	if c.DropoutProbability < 0 || c.DropoutProbability > 1 {
+		return fmt.Errorf("probabilidade de queda deve estar em [0, 1], recebido %f", c.DropoutProbability)
+	}
+	if c.DropoutDarkness < 0 || c.DropoutDarkness > 1 {
+		return fmt.Errorf("fração de brilho da queda deve estar em [0, 1], recebido %f", c.DropoutDarkness)
+	}
+	if c.FlickerAmplitude < 0 || c.FlickerAmplitude >= 1 {
+		return fmt.Errorf("amplitude do flicker deve estar em [0, 1), recebido %f", c.FlickerAmplitude)
+	}
+	return nil
+}
+
+// Apply retorna uma cópia degradada dos quadros; a entrada não é modificada.
+// As degradações são aplicadas na ordem flicker, flare, queda escura, ruído gaussiano e sal e pimenta,
+// para que o ruído do sensor fique por cima dos artefatos de iluminação, como numa câmera real.
+func Apply(frames [][][]uint8, config Config) ([][][]uint8, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(config.Seed))
+	result := make([][][]uint8, len(frames))
+	for i, frame := range frames {
+		degraded := copyFrame(frame)
+		if len(degraded) == 0 || len(degraded[0]) == 0 {
+			result[i] = degraded
+			continue
+		}
+
+		if config.FlickerAmplitude > 0 {
+			AddFlicker(degraded, 1+config.FlickerAmplitude*(2*rng.Float64()-1))
+		}
+		if config.FlareProbability > 0 && rng.Float64() < config.FlareProbability {
+			AddFlare(degraded, rng.Intn(len(degraded)), rng.Intn(len(degraded[0])), config.FlareRadius, config.FlareIntensity)
+		}
+		if config.DropoutProbability > 0 && rng.Float64() < config.DropoutProbability {
+			size := max(1, config.DropoutSize)
+			y := rng.Intn(max(1, len(degraded)-size+1))
+			x := rng.Intn(max(1, len(degraded[0])-size+1))
+			AddDropout(degraded, y, x, size, config.DropoutDarkness)
+		}
+		if config.GaussianSigma > 0 {
+			AddGaussianNoise(degraded, config.GaussianSigma, rng)
+		}
+		if config.SaltPepperDensity > 0 {
+			AddSaltAndPepper(degraded, config.SaltPepperDensity, rng)
+		}
+
+		result[i] = degraded
+	}
+
+	return result, nil
+}
+
+// AddGaussianNoise soma ruído gaussiano de desvio padrão sigma a cada pixel, no próprio quadro.
+func AddGaussianNoise(frame [][]uint8, sigma float64, rng *rand.Rand) {
+	for _, row := range frame {
+		for x, value := range row {
+			row[x] = clamp(float64(value) + rng.NormFloat64()*sigma)
+		}
+	}
+}
+
+// AddSaltAndPepper substitui uma fração density dos pixels por 0 ou 255, com igual probabilidade.
+func AddSaltAndPepper(frame [][]uint8, density float64, rng *rand.Rand) {
+	for _, row := range frame {
+		for x := range row {
+			if rng.Float64() >= density {
+				continue
+			}
+			if rng.Intn(2) == 0 {
+				row[x] = 0
+			} else {
+				row[x] = 255
+			}
+		}
+	}
+}
+
+// AddFlare soma uma mancha de luz gaussiana centrada em (centerY, centerX), com acréscimo máximo intensity.
+func AddFlare(frame [][]uint8, centerY, centerX, radius int, intensity float64) {
+	if radius <= 0 || intensity <= 0 {
+		return
+	}
+	sigma := float64(radius)
+	reach := 3 * radius
+	for y := max(0, centerY-reach); y < min(len(frame), centerY+reach+1); y++ {
+		row := frame[y]
+		for x := max(0, centerX-reach); x < min(len(row), centerX+reach+1); x++ {
+			dy, dx := float64(y-centerY), float64(x-centerX)
+			boost := intensity * math.Exp(-(dx*dx+dy*dy)/(2*sigma*sigma))
+			row[x] = clamp(float64(row[x]) + boost)
+		}
+	}
+}
+
+// AddDropout escurece e borra a região quadrada de lado size a partir de (top, left),
+// imitando um quadro em que parte da imagem perdeu exposição e foco. A região é recortada
+// aos limites do quadro; se ficar vazia, o quadro não muda.
+func AddDropout(frame [][]uint8, top, left, size int, darkness float64) {
+	bottom := min(len(frame), top+size)
+	top = max(top, 0)
+	if bottom <= top {
+		return
+	}
+	right := min(len(frame[0]), left+size)
+	left = max(left, 0)
+	if right <= left {
+		return
+	}
+
+	// Blur de caixa 3x3 calculado sobre a região original, antes de escurecer.
+	blurred := make([][]float64, bottom-top)
+	for y := top; y < bottom; y++ {
+		blurred[y-top] = make([]float64, right-left)
+		for x := left; x < right; x++ {
+			var sum, count float64
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					ny, nx := y+dy, x+dx
+					if ny < 0 || ny >= len(frame) || nx < 0 || nx >= len(frame[ny]) {
+						continue
+					}
+					sum += float64(frame[ny][nx])
+					count++
+				}
+			}
+			blurred[y-top][x-left] = sum / count
+		}
+	}
+
+	for y := top; y < bottom; y++ {
+		for x := left; x < right; x++ {
+			frame[y][x] = clamp(blurred[y-top][x-left] * darkness)
+		}
+	}
+}
+
+// AddFlicker multiplica o brilho de todo o quadro por gain.
+func AddFlicker(frame [][]uint8, gain float64) {
+	for _, row := range frame {
+		for x, value := range row {
+			row[x] = clamp(float64(value) * gain)
+		}
+	}
+}
+
+// copyFrame retorna uma cópia profunda do quadro.
+func copyFrame(frame [][]uint8) [][]uint8 {
+	copied := make([][]uint8, len(frame))
+	for y, row := range frame {
+		copied[y] = make([]uint8, len(row))
+		copy(copied[y], row)
+	}
+	return copied
+}
+
+// clamp arredonda e limita um valor ao intervalo de pixel válido [0, 255].
+func clamp(value float64) uint8 {
+	if value <= 0 {
+		return 0
+	}
+	if value >= 255 {
+		return 255
+	}
+	return uint8(value + 0.5)
+}
@@ -0,0 +1,181 @@
+package degradation
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Helper function to create a clean sequence of constant frames
+func createCleanFrames(count, height, width int, value uint8) [][][]uint8 {
+	frames := make([][][]uint8, count)
+	for i := range frames {
+		frames[i] = make([][]uint8, height)
+		for y := range frames[i] {
+			frames[i][y] = make([]uint8, width)
+			for x := range frames[i][y] {
+				frames[i][y][x] = value
+			}
+		}
+	}
+	return frames
+}
+
+func TestApply_ZeroConfigIsIdentity(t *testing.T) {
+	clean := createCleanFrames(3, 8, 8, 120)
+	degraded, err := Apply(clean, Config{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(degraded, clean) {
+		t.Error("zero config should leave frames unchanged")
+	}
+}
+
+func TestApply_DeterministicAndDoesNotModifyInput(t *testing.T) {
+	clean := createCleanFrames(5, 16, 16, 100)
+	config := Config{
+		Seed:               42,
+		GaussianSigma:      5,
+		SaltPepperDensity:  0.02,
+		FlareProbability:   0.5,
+		FlareRadius:        3,
+		FlareIntensity:     120,
+		DropoutProbability: 0.5,
+		DropoutSize:        6,
+		DropoutDarkness:    0.3,
+		FlickerAmplitude:   0.1,
+	}
+
+	first, err := Apply(clean, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := Apply(clean, config)
+	if !reflect.DeepEqual(first, second) {
+		t.Error("same seed should produce the same output")
+	}
+
+	config.Seed = 43
+	third, _ := Apply(clean, config)
+	if reflect.DeepEqual(first, third) {
+		t.Error("different seeds should produce different output")
+	}
+
+	if !reflect.DeepEqual(clean, createCleanFrames(5, 16, 16, 100)) {
+		t.Error("Apply should not modify the input frames")
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	invalid := []Config{
+		{GaussianSigma: -1},
+		{SaltPepperDensity: 1.5},
+		{FlareProbability: -0.1},
+		{DropoutProbability: 2},
+		{DropoutDarkness: 1.5},
+		{FlickerAmplitude: 1},
+	}
+	for _, config := range invalid {
+		if _, err := Apply(createCleanFrames(1, 2, 2, 0), config); err == nil {
+			t.Errorf("Apply(%+v) should fail validation", config)
+		}
+	}
+}
+
+func TestAddGaussianNoise(t *testing.T) {
+	frame := createCleanFrames(1, 100, 100, 128)[0]
+	AddGaussianNoise(frame, 10, rand.New(rand.NewSource(1)))
+
+	var sum, sumSq float64
+	for _, row := range frame {
+		for _, v := range row {
+			sum += float64(v)
+			sumSq += float64(v) * float64(v)
+		}
+	}
+	n := 100.0 * 100.0
+	mean := sum / n
+	std := math.Sqrt(sumSq/n - mean*mean)
+	if math.Abs(mean-128) > 1 || math.Abs(std-10) > 1 {
+		t.Errorf("noise mean/std = %.2f/%.2f, expected about 128/10", mean, std)
+	}
+}
+
+func TestAddSaltAndPepper(t *testing.T) {
+	frame := createCleanFrames(1, 100, 100, 128)[0]
+	AddSaltAndPepper(frame, 0.1, rand.New(rand.NewSource(1)))
+
+	salt, pepper := 0, 0
+	for _, row := range frame {
+		for _, v := range row {
+			switch v {
+			case 255:
+				salt++
+			case 0:
+				pepper++
+			}
+		}
+	}
+	density := float64(salt+pepper) / 10000
+	if math.Abs(density-0.1) > 0.02 || salt == 0 || pepper == 0 {
+		t.Errorf("salt=%d pepper=%d density=%.3f, expected about 0.1 with both kinds", salt, pepper, density)
+	}
+}
+
+func TestAddFlareAndDropout(t *testing.T) {
+	frame := createCleanFrames(1, 40, 40, 100)[0]
+	AddFlare(frame, 20, 20, 4, 120)
+	if frame[20][20] != 220 {
+		t.Errorf("flare center = %d, expected 220", frame[20][20])
+	}
+	if frame[0][0] != 100 {
+		t.Errorf("pixel far from the flare changed: %d", frame[0][0])
+	}
+
+	frame = createCleanFrames(1, 40, 40, 100)[0]
+	AddDropout(frame, 5, 5, 10, 0.4)
+	if frame[10][10] != 40 {
+		t.Errorf("dropout pixel = %d, expected 40", frame[10][10])
+	}
+	if frame[4][4] != 100 || frame[15][15] != 100 {
+		t.Error("pixels outside the dropout region changed")
+	}
+}
+
+func TestAddDropout_ClipsToFrame(t *testing.T) {
+	tests := []struct {
+		name      string
+		top, left int
+		changed   [2]int // A pixel inside both the rectangle and the frame.
+	}{
+		{"negative corner", -5, -5, [2]int{0, 0}},
+		{"past the bottom right", 15, 15, [2]int{19, 19}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame := createCleanFrames(1, 20, 20, 100)[0]
+			AddDropout(frame, tt.top, tt.left, 10, 0.4)
+			if got := frame[tt.changed[0]][tt.changed[1]]; got != 40 {
+				t.Errorf("dropout pixel = %d, expected 40", got)
+			}
+		})
+	}
+
+	frame := createCleanFrames(1, 20, 20, 100)[0]
+	for _, corner := range [][2]int{{-30, 0}, {0, -30}, {25, 0}, {0, 25}} {
+		AddDropout(frame, corner[0], corner[1], 10, 0.4)
+	}
+	if frame[0][0] != 100 || frame[19][19] != 100 {
+		t.Error("a dropout outside the frame changed it")
+	}
+}
+
+func TestAddFlicker(t *testing.T) {
+	frame := createCleanFrames(1, 4, 4, 100)[0]
+	AddFlicker(frame, 1.1)
+	if frame[0][0] != 110 {
+		t.Errorf("flicker pixel = %d, expected 110", frame[0][0])
+	}
+}
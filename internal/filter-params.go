@@ -0,0 +1,224 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ReferenceNoiseSigma é o desvio padrão de ruído para o qual os limiares e alfas padrão foram ajustados.
+// Os parâmetros escalados por ScaledForNoise são idênticos aos padrão quando sigma é igual a este valor.
+const ReferenceNoiseSigma = 5.0
+
+// Limites do fator de escala aplicado aos parâmetros, para que estimativas extremas
+// (vídeo quase limpo ou quadros pretos) não desliguem nem saturem os filtros.
+const (
+	minNoiseScale = 0.5
+	maxNoiseScale = 3.0
+)
+
+// AdaptiveParams reúne os limiares e alfas usados por ApplyAdaptiveFilter.
+type AdaptiveParams struct {
+	EdgeThreshold  float64 `json:"edge_threshold"`  // Magnitude do gradiente (Sobel) acima da qual o pixel é borda.
+	NoiseThreshold float64 `json:"noise_threshold"` // Diferença máxima para um vizinho ser considerado semelhante ao centro.
+	NoiseRatio     float64 `json:"noise_ratio"`     // Razão de vizinhos semelhantes abaixo da qual o pixel é ruído.
+	LowVariance    float64 `json:"low_variance"`    // Variância abaixo da qual a região é considerada suave.
+	MidVariance    float64 `json:"mid_variance"`    // Variância abaixo da qual a região é considerada de textura média.
+	EdgeAlpha      float64 `json:"edge_alpha"`      // Peso da mediana dos vizinhos em pixels de borda.
+	SmoothAlpha    float64 `json:"smooth_alpha"`    // Peso da média dos vizinhos em regiões suaves.
+	MidAlpha       float64 `json:"mid_alpha"`       // Peso da mediana dos vizinhos em regiões de textura média.
+	TextureAlpha   float64 `json:"texture_alpha"`   // Peso da mediana dos vizinhos em regiões texturizadas.
+}
+
+// DefaultAdaptiveParams retorna os valores historicamente fixos em ApplyAdaptiveFilter.
+func DefaultAdaptiveParams() AdaptiveParams {
+	return AdaptiveParams{
+		EdgeThreshold:  DefaultEdgeThreshold,
+		NoiseThreshold: 15.0,
+		NoiseRatio:     0.3,
+		LowVariance:    50,
+		MidVariance:    200,
+		EdgeAlpha:      0.1,
+		SmoothAlpha:    0.7,
+		MidAlpha:       0.3,
+		TextureAlpha:   0.05,
+	}
+}
+
+// Validate verifica se os limiares não são negativos e se a razão e os alfas estão em [0, 1].
+func (p AdaptiveParams) Validate() error {
+	if err := checkNonNegative(map[string]float64{
+		"edge_threshold":  p.EdgeThreshold,
+		"noise_threshold": p.NoiseThreshold,
+		"low_variance":    p.LowVariance,
+		"mid_variance":    p.MidVariance,
+	}); err != nil {
+		return fmt.Errorf("filtro adaptativo: %w", err)
+	}
+	if err := checkUnitInterval(map[string]float64{
+		"noise_ratio":   p.NoiseRatio,
+		"edge_alpha":    p.EdgeAlpha,
+		"smooth_alpha":  p.SmoothAlpha,
+		"mid_alpha":     p.MidAlpha,
+		"texture_alpha": p.TextureAlpha,
+	}); err != nil {
+		return fmt.Errorf("filtro adaptativo: %w", err)
+	}
+	return nil
+}
+
+// ScaledForNoise retorna uma cópia dos parâmetros ajustada para um ruído de desvio padrão sigma.
+// Limiares de intensidade crescem linearmente com o ruído, limiares de variância com o quadrado,
+// e os alfas são reforçados (ou atenuados) para suavizar mais quanto mais ruidoso for o vídeo.
+func (p AdaptiveParams) ScaledForNoise(sigma float64) AdaptiveParams {
+	k := noiseScale(sigma)
+	return AdaptiveParams{
+		EdgeThreshold:  p.EdgeThreshold * k,
+		NoiseThreshold: p.NoiseThreshold * k,
+		NoiseRatio:     p.NoiseRatio,
+		LowVariance:    p.LowVariance * k * k,
+		MidVariance:    p.MidVariance * k * k,
+		EdgeAlpha:      scaleAlpha(p.EdgeAlpha, k),
+		SmoothAlpha:    scaleAlpha(p.SmoothAlpha, k),
+		MidAlpha:       scaleAlpha(p.MidAlpha, k),
+		TextureAlpha:   scaleAlpha(p.TextureAlpha, k),
+	}
+}
+
+// TemporalParams reúne os limiares e alfas usados pelo TimeTravaler.
+type TemporalParams struct {
+	EdgeThreshold       float64 `json:"edge_threshold"`       // Magnitude do gradiente (Sobel) acima da qual o pixel é mantido.
+	BlurDiff            float64 `json:"blur_diff"`            // Queda mínima em relação à mediana para caracterizar blur.
+	BlurMaxValue        float64 `json:"blur_max_value"`       // Valor máximo do pixel atual para caracterizar blur.
+	BlurAlpha           float64 `json:"blur_alpha"`           // Peso da correção de blur.
+	FlareDiff           float64 `json:"flare_diff"`           // Subida mínima em relação à mediana para caracterizar flare.
+	FlareMinValue       float64 `json:"flare_min_value"`      // Valor mínimo do pixel atual para caracterizar flare.
+	SimilarityThreshold float64 `json:"similarity_threshold"` // Diferença máxima para dois valores anteriores serem considerados estáveis.
+	StabilityRatio      float64 `json:"stability_ratio"`      // Razão de pares estáveis acima da qual o histórico é considerado estável.
+	NoiseDiff           float64 `json:"noise_diff"`           // Desvio mínimo em relação à mediana para caracterizar ruído.
+	NoiseAlpha          float64 `json:"noise_alpha"`          // Peso da correção de ruído.
+	LowVariance         float64 `json:"low_variance"`         // Variância abaixo da qual o filtro temporal adaptativo é aplicado.
+	MovementVariance    float64 `json:"movement_variance"`    // Variância acima da qual há movimento.
+	StrongVariance      float64 `json:"strong_variance"`      // Variância abaixo da qual o filtro temporal usa o alfa forte.
+	MediumVariance      float64 `json:"medium_variance"`      // Variância abaixo da qual o filtro temporal usa o alfa médio.
+	StrongAlpha         float64 `json:"strong_alpha"`         // Peso da mediana para históricos muito estáveis.
+	MediumAlpha         float64 `json:"medium_alpha"`         // Peso da mediana para históricos estáveis.
+	WeakAlpha           float64 `json:"weak_alpha"`           // Peso da mediana para os demais históricos.
+}
+
+// DefaultTemporalParams retorna os valores historicamente fixos no TimeTravaler.
+func DefaultTemporalParams() TemporalParams {
+	return TemporalParams{
+		EdgeThreshold:       DefaultEdgeThreshold,
+		BlurDiff:            40,
+		BlurMaxValue:        60,
+		BlurAlpha:           0.8,
+		FlareDiff:           50,
+		FlareMinValue:       180,
+		SimilarityThreshold: 5,
+		StabilityRatio:      0.6,
+		NoiseDiff:           12,
+		NoiseAlpha:          0.7,
+		LowVariance:         20,
+		MovementVariance:    30,
+		StrongVariance:      10,
+		MediumVariance:      25,
+		StrongAlpha:         0.6,
+		MediumAlpha:         0.4,
+		WeakAlpha:           0.2,
+	}
+}
+
+// Validate verifica se os limiares não são negativos e se as razões e os alfas estão em [0, 1].
+func (p TemporalParams) Validate() error {
+	if err := checkNonNegative(map[string]float64{
+		"edge_threshold":       p.EdgeThreshold,
+		"blur_diff":            p.BlurDiff,
+		"blur_max_value":       p.BlurMaxValue,
+		"flare_diff":           p.FlareDiff,
+		"flare_min_value":      p.FlareMinValue,
+		"similarity_threshold": p.SimilarityThreshold,
+		"noise_diff":           p.NoiseDiff,
+		"low_variance":         p.LowVariance,
+		"movement_variance":    p.MovementVariance,
+		"strong_variance":      p.StrongVariance,
+		"medium_variance":      p.MediumVariance,
+	}); err != nil {
+		return fmt.Errorf("TimeTravaler: %w", err)
+	}
+	if err := checkUnitInterval(map[string]float64{
+		"blur_alpha":      p.BlurAlpha,
+		"stability_ratio": p.StabilityRatio,
+		"noise_alpha":     p.NoiseAlpha,
+		"strong_alpha":    p.StrongAlpha,
+		"medium_alpha":    p.MediumAlpha,
+		"weak_alpha":      p.WeakAlpha,
+	}); err != nil {
+		return fmt.Errorf("TimeTravaler: %w", err)
+	}
+	return nil
+}
+
+// ScaledForNoise retorna uma cópia dos parâmetros ajustada para um ruído de desvio padrão sigma,
+// com as mesmas regras de AdaptiveParams.ScaledForNoise. Os limiares de blur e flare descrevem
+// artefatos de iluminação, não ruído, e por isso não são alterados.
+func (p TemporalParams) ScaledForNoise(sigma float64) TemporalParams {
+	k := noiseScale(sigma)
+	scaled := p
+	scaled.EdgeThreshold = p.EdgeThreshold * k
+	scaled.SimilarityThreshold = p.SimilarityThreshold * k
+	scaled.NoiseDiff = p.NoiseDiff * k
+	scaled.LowVariance = p.LowVariance * k * k
+	scaled.MovementVariance = p.MovementVariance * k * k
+	scaled.StrongVariance = p.StrongVariance * k * k
+	scaled.MediumVariance = p.MediumVariance * k * k
+	scaled.NoiseAlpha = scaleAlpha(p.NoiseAlpha, k)
+	scaled.StrongAlpha = scaleAlpha(p.StrongAlpha, k)
+	scaled.MediumAlpha = scaleAlpha(p.MediumAlpha, k)
+	scaled.WeakAlpha = scaleAlpha(p.WeakAlpha, k)
+	return scaled
+}
+
+// checkNonNegative retorna um erro para o primeiro parâmetro, em ordem alfabética, negativo ou NaN.
+func checkNonNegative(params map[string]float64) error {
+	for _, name := range sortedKeys(params) {
+		if v := params[name]; !(v >= 0) {
+			return fmt.Errorf("%s deve ser >= 0, recebido %f", name, v)
+		}
+	}
+	return nil
+}
+
+// checkUnitInterval retorna um erro para o primeiro parâmetro, em ordem alfabética, fora de [0, 1].
+func checkUnitInterval(params map[string]float64) error {
+	for _, name := range sortedKeys(params) {
+		if v := params[name]; !(v >= 0 && v <= 1) {
+			return fmt.Errorf("%s deve estar em [0, 1], recebido %f", name, v)
+		}
+	}
+	return nil
+}
+
+// sortedKeys retorna as chaves do mapa em ordem alfabética.
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// noiseScale converte um sigma estimado no fator de escala relativo ao ReferenceNoiseSigma.
+func noiseScale(sigma float64) float64 {
+	if sigma <= 0 || math.IsNaN(sigma) {
+		return minNoiseScale
+	}
+	return math.Max(minNoiseScale, math.Min(maxNoiseScale, sigma/ReferenceNoiseSigma))
+}
+
+// scaleAlpha ajusta um peso de mistura: 1-(1-alpha)^k. Com k=1 o alfa não muda,
+// com k>1 se aproxima de 1 (mais suavização) e com k<1 se aproxima de 0.
+func scaleAlpha(alpha, k float64) float64 {
+	return 1 - math.Pow(1-alpha, k)
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScaledForNoise_ReferenceSigmaIsIdentity(t *testing.T) {
+	adaptive := DefaultAdaptiveParams()
+	scaledAdaptive := adaptive.ScaledForNoise(ReferenceNoiseSigma)
+	if !nearlyEqualAdaptive(adaptive, scaledAdaptive) {
+		t.Errorf("AdaptiveParams.ScaledForNoise(reference) = %+v, expected %+v", scaledAdaptive, adaptive)
+	}
+
+	temporal := DefaultTemporalParams()
+	scaledTemporal := temporal.ScaledForNoise(ReferenceNoiseSigma)
+	if !nearlyEqualTemporal(temporal, scaledTemporal) {
+		t.Errorf("TemporalParams.ScaledForNoise(reference) = %+v, expected %+v", scaledTemporal, temporal)
+	}
+}
+
+func TestScaledForNoise_StrongerForNoisierInput(t *testing.T) {
+	base := DefaultAdaptiveParams()
+	noisy := base.ScaledForNoise(2 * ReferenceNoiseSigma)
+	clean := base.ScaledForNoise(ReferenceNoiseSigma / 2)
+
+	if noisy.NoiseThreshold != 2*base.NoiseThreshold {
+		t.Errorf("NoiseThreshold = %f, expected %f", noisy.NoiseThreshold, 2*base.NoiseThreshold)
+	}
+	if noisy.LowVariance != 4*base.LowVariance {
+		t.Errorf("LowVariance = %f, expected %f", noisy.LowVariance, 4*base.LowVariance)
+	}
+	if !(clean.SmoothAlpha < base.SmoothAlpha && base.SmoothAlpha < noisy.SmoothAlpha) {
+		t.Errorf("SmoothAlpha should grow with noise: clean=%f base=%f noisy=%f",
+			clean.SmoothAlpha, base.SmoothAlpha, noisy.SmoothAlpha)
+	}
+
+	temporal := DefaultTemporalParams().ScaledForNoise(2 * ReferenceNoiseSigma)
+	if temporal.BlurDiff != DefaultTemporalParams().BlurDiff {
+		t.Error("blur thresholds should not depend on noise")
+	}
+	if temporal.NoiseDiff != 2*DefaultTemporalParams().NoiseDiff {
+		t.Errorf("NoiseDiff = %f, expected %f", temporal.NoiseDiff, 2*DefaultTemporalParams().NoiseDiff)
+	}
+}
+
+func TestNoiseScale_Clamped(t *testing.T) {
+	tests := []struct {
+		sigma    float64
+		expected float64
+	}{
+		{0, minNoiseScale},
+		{math.NaN(), minNoiseScale},
+		{0.1, minNoiseScale},
+		{ReferenceNoiseSigma, 1},
+		{1000, maxNoiseScale},
+	}
+
+	for _, tt := range tests {
+		if got := noiseScale(tt.sigma); got != tt.expected {
+			t.Errorf("noiseScale(%f) = %f, expected %f", tt.sigma, got, tt.expected)
+		}
+	}
+}
+
+func TestTimeTravalerWithParams_DefaultsMatchTimeTravaler(t *testing.T) {
+	videoA := make(VideoFrames, 8)
+	videoB := make(VideoFrames, 8)
+	for i := range videoA {
+		videoA[i] = addGaussianNoise(createTestFrame(6, 6, 100), 4, int64(i))
+		videoB[i] = make(Frame, len(videoA[i]))
+		for y := range videoA[i] {
+			videoB[i][y] = append([]uint8(nil), videoA[i][y]...)
+		}
+	}
+
+	TimeTravaler(videoA, 7, 5)
+	TimeTravalerWithParams(videoB, 7, 5, DefaultTemporalParams())
+
+	for y := range videoA[7] {
+		for x := range videoA[7][y] {
+			if videoA[7][y][x] != videoB[7][y][x] {
+				t.Fatalf("outputs differ at (%d, %d): %d vs %d", y, x, videoA[7][y][x], videoB[7][y][x])
+			}
+		}
+	}
+}
+
+// Helper function comparing adaptive params with a float tolerance
+func nearlyEqualAdaptive(a, b AdaptiveParams) bool {
+	return nearlyEqual(a.EdgeThreshold, b.EdgeThreshold) && nearlyEqual(a.NoiseThreshold, b.NoiseThreshold) &&
+		nearlyEqual(a.NoiseRatio, b.NoiseRatio) && nearlyEqual(a.LowVariance, b.LowVariance) &&
+		nearlyEqual(a.MidVariance, b.MidVariance) && nearlyEqual(a.EdgeAlpha, b.EdgeAlpha) &&
+		nearlyEqual(a.SmoothAlpha, b.SmoothAlpha) && nearlyEqual(a.MidAlpha, b.MidAlpha) &&
+		nearlyEqual(a.TextureAlpha, b.TextureAlpha)
+}
+
+// Helper function comparing temporal params with a float tolerance
+func nearlyEqualTemporal(a, b TemporalParams) bool {
+	return nearlyEqual(a.EdgeThreshold, b.EdgeThreshold) && nearlyEqual(a.SimilarityThreshold, b.SimilarityThreshold) &&
+		nearlyEqual(a.NoiseDiff, b.NoiseDiff) && nearlyEqual(a.NoiseAlpha, b.NoiseAlpha) &&
+		nearlyEqual(a.LowVariance, b.LowVariance) && nearlyEqual(a.MovementVariance, b.MovementVariance) &&
+		nearlyEqual(a.StrongVariance, b.StrongVariance) && nearlyEqual(a.MediumVariance, b.MediumVariance) &&
+		nearlyEqual(a.StrongAlpha, b.StrongAlpha) && nearlyEqual(a.MediumAlpha, b.MediumAlpha) &&
+		nearlyEqual(a.WeakAlpha, b.WeakAlpha) && a.BlurDiff == b.BlurDiff && a.FlareDiff == b.FlareDiff
+}
+
+// Helper function for float comparison
+func nearlyEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// FilterFactory cria um filtro configurado. decode preenche o valor apontado com a configuração
+// do estágio; a factory deve inicializá-lo com os padrões antes, para que campos ausentes
+// mantenham o valor padrão. decode nunca é nil.
+type FilterFactory func(decode func(config any) error) (Filter, error)
+
+var filterRegistry = struct {
+	sync.RWMutex
+	factories map[string]FilterFactory
+}{factories: make(map[string]FilterFactory)}
+
+// RegisterFilter registra uma factory sob o nome informado, normalmente num init.
+// Entra em pânico se o nome já estiver registrado ou se a factory for nil.
+func RegisterFilter(name string, factory FilterFactory) {
+	filterRegistry.Lock()
+	defer filterRegistry.Unlock()
+
+	if factory == nil {
+		panic("internal: RegisterFilter com factory nil para " + name)
+	}
+	if _, dup := filterRegistry.factories[name]; dup {
+		panic("internal: RegisterFilter chamado duas vezes para " + name)
+	}
+	filterRegistry.factories[name] = factory
+}
+
+// NewFilter cria o filtro registrado sob name. Com decode nil, o filtro usa os parâmetros padrão.
+func NewFilter(name string, decode func(config any) error) (Filter, error) {
+	filterRegistry.RLock()
+	factory, ok := filterRegistry.factories[name]
+	filterRegistry.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("filtro desconhecido: %q (disponíveis: %v)", name, FilterNames())
+	}
+	if decode == nil {
+		decode = func(any) error { return nil }
+	}
+
+	filter, err := factory(decode)
+	if err != nil {
+		return nil, fmt.Errorf("filtro %s: %w", name, err)
+	}
+	return filter, nil
+}
+
+// FilterNames retorna os nomes registrados, em ordem alfabética.
+func FilterNames() []string {
+	filterRegistry.RLock()
+	defer filterRegistry.RUnlock()
+
+	names := make([]string, 0, len(filterRegistry.factories))
+	for name := range filterRegistry.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
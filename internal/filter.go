@@ -0,0 +1,133 @@
+package internal
+
+import "fmt"
+
+// FilterKind classifica os filtros pela vizinhança que eles leem.
+type FilterKind int
+
+const (
+	// FilterSpatial filtra cada quadro a partir da vizinhança de cada pixel no mesmo quadro.
+	FilterSpatial FilterKind = iota
+	// FilterTemporal filtra cada quadro a partir dos quadros vizinhos no tempo.
+	FilterTemporal
+	// FilterPoint transforma cada pixel isoladamente, só a partir do seu próprio valor.
+	FilterPoint
+)
+
+// String retorna o nome do tipo de filtro.
+func (k FilterKind) String() string {
+	switch k {
+	case FilterSpatial:
+		return "spatial"
+	case FilterTemporal:
+		return "temporal"
+	case FilterPoint:
+		return "point"
+	default:
+		return fmt.Sprintf("FilterKind(%d)", int(k))
+	}
+}
+
+// Filter é o que todo filtro do pipeline implementa. Além destes métodos, cada filtro implementa
+// SpatialFilter, TemporalFilter ou PointFilter, conforme o Kind.
+type Filter interface {
+	// Name é o nome com que o filtro foi registrado.
+	Name() string
+	Kind() FilterKind
+	// Config retorna os parâmetros do filtro, no mesmo formato aceito pela sua FilterFactory.
+	Config() any
+}
+
+// SpatialFilter filtra um quadro inteiro e retorna um novo quadro, sem modificar a entrada.
+type SpatialFilter interface {
+	Filter
+	ApplyFrame(frame Frame) Frame
+}
+
+// TemporalFilter filtra um quadro usando os quadros vizinhos.
+type TemporalFilter interface {
+	Filter
+	// ApplyTemporal retorna o quadro frameID filtrado. input são os quadros que entraram no estágio
+	// e processed os mesmos quadros com 0..frameID-1 já filtrados por este estágio, de modo que
+	// filtros recursivos podem usar o histórico filtrado. Nenhum dos dois pode ser modificado.
+	ApplyTemporal(input, processed VideoFrames, frameID int) Frame
+}
+
+// PointFilter transforma cada pixel isoladamente.
+type PointFilter interface {
+	Filter
+	ApplyPixel(value uint8) uint8
+}
+
+// NoiseScalable é implementado por filtros cuja força pode ser ajustada pelo ruído estimado do vídeo.
+type NoiseScalable interface {
+	ScaledForNoise(sigma float64) Filter
+}
+
+// adaptiveCounter é implementado por filtros que classificam pixels como o filtro adaptativo,
+// para que as classificações entrem nas estatísticas.
+type adaptiveCounter interface {
+	applyFrameWithStats(frame Frame) (Frame, AdaptiveCounts)
+}
+
+// decisionRecorder é implementado por filtros temporais que registram a decisão de cada pixel.
+type decisionRecorder interface {
+	applyTemporalWithDecisions(input, processed VideoFrames, frameID int) (Frame, DecisionMap)
+}
+
+// historyFilter é implementado por filtros temporais causais que, além do quadro atual, só leem os
+// últimos history() quadros já filtrados pelo próprio estágio, passados como os primeiros de
+// processed. Só esses filtros podem rodar quadro a quadro em Pipeline.RunStream.
+type historyFilter interface {
+	history() int
+}
+
+// sequentialFilter é implementado por filtros temporais que guardam estado entre quadros consecutivos,
+// como a janela deslizante do TimeTravaler. O pipeline, que filtra os quadros de um estágio temporal
+// em ordem, cria uma sequência por execução e a usa no lugar de ApplyTemporal.
+type sequentialFilter interface {
+	sequence() temporalSequence
+}
+
+// temporalSequence filtra, em ordem, os quadros de uma execução de um estágio temporal. apply recebe
+// os argumentos de ApplyTemporal e retorna também as decisões, se o filtro as registra. Na primeira
+// chamada o estado é montado a partir do histórico em processed; nas seguintes, o quadro anterior
+// precisa ser o retornado pela chamada anterior.
+type temporalSequence interface {
+	apply(input, processed VideoFrames, frameID int) (Frame, DecisionMap)
+}
+
+// pooledFilter é implementado pelos filtros embutidos cuja saída é sempre um quadro novo do pool,
+// sem linhas compartilhadas com nenhum outro quadro. Além dos filtros pontuais, o pipeline só
+// devolve ao pool os quadros produzidos por esses filtros.
+type pooledFilter interface {
+	pooledOutput()
+}
+
+// pointLUT é a tabela com o resultado de um PointFilter para cada um dos 256 valores.
+type pointLUT [256]uint8
+
+// newPointLUT calcula a tabela do filtro.
+func newPointLUT(f PointFilter) *pointLUT {
+	var lut pointLUT
+	for v := range lut {
+		lut[v] = f.ApplyPixel(uint8(v))
+	}
+	return &lut
+}
+
+// apply retorna um novo quadro do pool com a tabela aplicada a cada pixel.
+func (lut *pointLUT) apply(frame Frame) Frame {
+	result := acquireFrameLike(frame)
+	for y, row := range frame {
+		for x, v := range row {
+			result[y][x] = lut[v]
+		}
+	}
+	return result
+}
+
+// ApplyPointFilter aplica um PointFilter a todos os pixels e retorna um novo quadro.
+func ApplyPointFilter(frame Frame, f PointFilter) Frame {
+	return newPointLUT(f).apply(frame)
+}
@@ -0,0 +1,253 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// invertFilter is a point filter registered only by the tests
+type invertFilter struct{}
+
+func (invertFilter) Name() string                 { return "test-invert" }
+func (invertFilter) Kind() FilterKind             { return FilterPoint }
+func (invertFilter) Config() any                  { return nil }
+func (invertFilter) ApplyPixel(value uint8) uint8 { return 255 - value }
+
+// previousFrameFilter is a temporal filter that replaces each frame by the previous input frame
+type previousFrameFilter struct{}
+
+func (previousFrameFilter) Name() string     { return "test-previous" }
+func (previousFrameFilter) Kind() FilterKind { return FilterTemporal }
+func (previousFrameFilter) Config() any      { return nil }
+func (previousFrameFilter) ApplyTemporal(input, processed VideoFrames, frameID int) Frame {
+	if frameID == 0 {
+		return input[0]
+	}
+	return input[frameID-1]
+}
+
+// Helper function to decode a JSON object into a filter config
+func jsonDecoder(config string) func(any) error {
+	return func(v any) error {
+		return json.Unmarshal([]byte(config), v)
+	}
+}
+
+func TestFilterRegistry_BuiltinFilters(t *testing.T) {
+	names := FilterNames()
+	for _, expected := range []string{FilterNameAdaptive, FilterNameBilateral, FilterNameGamma,
+		FilterNameNLMeans, FilterNameSpatioTemporal, FilterNameTimeTravaler} {
+		found := false
+		for _, name := range names {
+			found = found || name == expected
+		}
+		if !found {
+			t.Errorf("filter %q not registered (got %v)", expected, names)
+		}
+
+		filter, err := NewFilter(expected, nil)
+		if err != nil {
+			t.Errorf("NewFilter(%q) with defaults: %v", expected, err)
+			continue
+		}
+		if filter.Name() != expected {
+			t.Errorf("NewFilter(%q).Name() = %q", expected, filter.Name())
+		}
+		if err := (Stage{Filter: filter, Passes: 1}).validate(); err != nil {
+			t.Errorf("%s: %v", expected, err)
+		}
+	}
+}
+
+func TestFilterRegistry_Config(t *testing.T) {
+	filter, err := NewFilter(FilterNameBilateral, jsonDecoder(`{"radius": 3}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := filter.Config().(BilateralParams)
+	if params.Radius != 3 || params.SigmaRange != DefaultBilateralParams().SigmaRange {
+		t.Errorf("config = %+v, expected radius 3 and default sigmas", params)
+	}
+
+	if _, err := NewFilter(FilterNameTimeTravaler, jsonDecoder(`{"window": 0}`)); err == nil {
+		t.Error("expected validation error for window 0")
+	}
+	if _, err := NewFilter(FilterNameGamma, jsonDecoder(`{"gamma": "x"}`)); err == nil {
+		t.Error("expected decode error")
+	}
+	if _, err := NewFilter("does-not-exist", nil); err == nil || !strings.Contains(err.Error(), FilterNameAdaptive) {
+		t.Errorf("unknown filter error should list the available filters, got %v", err)
+	}
+}
+
+func TestRegisterFilter_PanicsOnDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for duplicate registration")
+		}
+	}()
+	RegisterFilter(FilterNameGamma, func(func(any) error) (Filter, error) { return &GammaFilter{Gamma: 1}, nil })
+}
+
+func TestGammaFilter(t *testing.T) {
+	identity := &GammaFilter{Gamma: 1}
+	brighter := &GammaFilter{Gamma: 2}
+	for _, v := range []uint8{0, 64, 128, 255} {
+		if got := identity.ApplyPixel(v); got != v {
+			t.Errorf("gamma 1: %d -> %d", v, got)
+		}
+	}
+	if brighter.ApplyPixel(64) <= 64 || brighter.ApplyPixel(255) != 255 || brighter.ApplyPixel(0) != 0 {
+		t.Error("gamma 2 should brighten mid tones and keep the extremes")
+	}
+}
+
+func TestPipelineFromStages_CustomFilters(t *testing.T) {
+	input := VideoFrames{createTestFrame(2, 2, 10), createTestFrame(2, 2, 20), createTestFrame(2, 2, 30)}
+
+	pipeline, err := NewPipelineFromStages([]Stage{
+		{Filter: invertFilter{}, Passes: 1},
+		{Filter: previousFrameFilter{}, Passes: 1},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen []string
+	pipeline.OnFrame = func(stage string, frameID int) {
+		if frameID == 0 {
+			seen = append(seen, stage)
+		}
+	}
+
+	result, err := pipeline.Run(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []uint8{245, 245, 235}
+	for i, v := range expected {
+		if result.Frames[i][1][1] != v {
+			t.Errorf("frame %d = %d, expected %d", i, result.Frames[i][1][1], v)
+		}
+	}
+	if input[0][0][0] != 10 {
+		t.Error("input modified")
+	}
+	if strings.Join(seen, ",") != "test-invert,test-previous" {
+		t.Errorf("stages notified = %v", seen)
+	}
+	if _, ok := result.Stats[0].StageTime["test-previous"]; !ok {
+		t.Errorf("stage time not recorded: %v", result.Stats[0].StageTime)
+	}
+}
+
+// brokenFilter claims to be spatial but does not implement ApplyFrame
+type brokenFilter struct{}
+
+func (brokenFilter) Name() string     { return "test-broken" }
+func (brokenFilter) Kind() FilterKind { return FilterSpatial }
+func (brokenFilter) Config() any      { return nil }
+
+func TestNewPipelineFromStages_Validation(t *testing.T) {
+	invalid := [][]Stage{
+		{{Filter: nil, Passes: 1}},
+		{{Filter: invertFilter{}, Passes: 0}},
+		{{Filter: brokenFilter{}, Passes: 1}},
+	}
+	for i, stages := range invalid {
+		if _, err := NewPipelineFromStages(stages, false); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestPipelineParams_StagesMatchFilters(t *testing.T) {
+	params := DefaultPipelineParams()
+	params.AutoStrength = false
+	input := createMovingSquareClip(9, 16, 20)
+
+	fromParams, err := NewPipeline(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adaptive, _ := NewFilter(FilterNameAdaptive, nil)
+	temporal, _ := NewFilter(FilterNameTimeTravaler, nil)
+	fromStages, err := NewPipelineFromStages([]Stage{
+		{Filter: adaptive, Passes: params.SpatialPasses},
+		{Filter: temporal, Passes: 1},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := fromParams.Run(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := fromStages.Run(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range a.Frames {
+		for y := range a.Frames[i] {
+			if !bytes.Equal(a.Frames[i][y], b.Frames[i][y]) {
+				t.Fatalf("frame %d row %d differs between params and registry stages", i, y)
+			}
+		}
+	}
+}
+
+// concurrencyFilter is a spatial filter that records how many frames it processes at the same time
+type concurrencyFilter struct {
+	mu           sync.Mutex
+	active, peak int
+}
+
+func (*concurrencyFilter) Name() string     { return "test-concurrency" }
+func (*concurrencyFilter) Kind() FilterKind { return FilterSpatial }
+func (*concurrencyFilter) Config() any      { return nil }
+func (f *concurrencyFilter) ApplyFrame(frame Frame) Frame {
+	f.mu.Lock()
+	f.active++
+	f.peak = max(f.peak, f.active)
+	f.mu.Unlock()
+	time.Sleep(2 * time.Millisecond)
+	f.mu.Lock()
+	f.active--
+	f.mu.Unlock()
+	return frame
+}
+
+func TestPipeline_CPUBudgetIsShared(t *testing.T) {
+	filter := &concurrencyFilter{}
+	budget := NewCPUBudget(2)
+	video := make(VideoFrames, 8)
+	for i := range video {
+		video[i] = createTestFrame(4, 4, 100)
+	}
+
+	var wg sync.WaitGroup
+	for range 3 {
+		pipeline, err := NewPipelineFromStages([]Stage{{Filter: filter, Passes: 1}}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pipeline.CPU = budget
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := pipeline.Run(video); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if filter.peak > 2 {
+		t.Errorf("peak concurrency = %d across pipelines, expected at most the budget of 2", filter.peak)
+	}
+}
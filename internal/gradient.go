@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+)
+
+// GradientOperator identifica o operador usado para estimar o gradiente de um pixel.
+type GradientOperator int
+
+const (
+	// GradientCentralDifference usa apenas os quatro vizinhos diretos: (direita-esquerda)/2 e (baixo-cima)/2.
+	GradientCentralDifference GradientOperator = iota
+	// GradientSobel usa o kernel 3x3 de Sobel, com pesos 1-2-1.
+	GradientSobel
+	// GradientScharr usa o kernel 3x3 de Scharr, com pesos 3-10-3 e melhor isotropia.
+	GradientScharr
+)
+
+// DefaultEdgeThreshold é o limiar de magnitude (Sobel) a partir do qual um pixel é considerado borda.
+// É o mesmo valor usado pelos estágios espacial e temporal, para que ambos concordem sobre o que é borda.
+const DefaultEdgeThreshold = 25.0
+
+// DefaultEdgeOperator é o operador padrão usado pelos estágios espacial e temporal.
+const DefaultEdgeOperator = GradientSobel
+
+// String retorna o nome do operador.
+func (op GradientOperator) String() string {
+	switch op {
+	case GradientCentralDifference:
+		return "central-difference"
+	case GradientSobel:
+		return "sobel"
+	case GradientScharr:
+		return "scharr"
+	default:
+		return fmt.Sprintf("GradientOperator(%d)", int(op))
+	}
+}
+
+// ParseGradientOperator converte um nome ("central-difference", "sobel", "scharr") no operador correspondente.
+func ParseGradientOperator(name string) (GradientOperator, error) {
+	switch name {
+	case "central-difference", "central":
+		return GradientCentralDifference, nil
+	case "sobel":
+		return GradientSobel, nil
+	case "scharr":
+		return GradientScharr, nil
+	default:
+		return 0, fmt.Errorf("operador de gradiente desconhecido: %q", name)
+	}
+}
+
+// Gradient calcula as componentes (gx, gy) do gradiente no pixel (y, x) do quadro.
+// O pixel precisa ter os oito vizinhos dentro do quadro; quem chama deve tratar as bordas.
+func Gradient(frame Frame, y, x int, op GradientOperator) (gx, gy float64) {
+	switch op {
+	case GradientCentralDifference:
+		gx = (float64(frame[y][x+1]) - float64(frame[y][x-1])) / 2.0
+		gy = (float64(frame[y+1][x]) - float64(frame[y-1][x])) / 2.0
+		return gx, gy
+	case GradientScharr:
+		return kernelGradient(frame, y, x, 3, 10)
+	default:
+		return kernelGradient(frame, y, x, 1, 2)
+	}
+}
+
+// kernelGradient aplica um kernel separável 3x3 do tipo [corner, middle, corner] (Sobel ou Scharr).
+func kernelGradient(frame Frame, y, x int, corner, middle int) (gx, gy float64) {
+	top, row, bottom := frame[y-1], frame[y], frame[y+1]
+
+	gxInt := corner*(int(top[x+1])-int(top[x-1])) +
+		middle*(int(row[x+1])-int(row[x-1])) +
+		corner*(int(bottom[x+1])-int(bottom[x-1]))
+
+	gyInt := corner*(int(bottom[x-1])-int(top[x-1])) +
+		middle*(int(bottom[x])-int(top[x])) +
+		corner*(int(bottom[x+1])-int(top[x+1]))
+
+	return float64(gxInt), float64(gyInt)
+}
+
+// GradientMagnitude retorna a magnitude do gradiente no pixel (y, x).
+// Pixels na borda do quadro (sem os oito vizinhos) retornam +Inf, pois são sempre tratados como borda.
+func GradientMagnitude(frame Frame, y, x int, op GradientOperator) float64 {
+	if !hasFullNeighborhood(frame, y, x) {
+		return math.Inf(1)
+	}
+	gx, gy := Gradient(frame, y, x, op)
+	return math.Sqrt(gx*gx + gy*gy)
+}
+
+// IsEdge determina se o pixel (y, x) do quadro é uma borda segundo o operador e o limiar informados.
+func IsEdge(frame Frame, y, x int, op GradientOperator, threshold float64) bool {
+	return GradientMagnitude(frame, y, x, op) > threshold
+}
+
+// hasFullNeighborhood verifica se o pixel (y, x) possui os oito vizinhos dentro do quadro.
+func hasFullNeighborhood(frame Frame, y, x int) bool {
+	return y > 0 && y < len(frame)-1 && x > 0 && x < len(frame[y])-1
+}
+
+// EdgeMap marca, para cada pixel de um quadro, se ele é uma borda.
+type EdgeMap [][]bool
+
+// At retorna se o pixel (y, x) é uma borda.
+func (m EdgeMap) At(y, x int) bool {
+	return m[y][x]
+}
+
+// Count retorna o número de pixels marcados como borda.
+func (m EdgeMap) Count() int {
+	count := 0
+	for _, row := range m {
+		for _, edge := range row {
+			if edge {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// ComputeEdgeMap calcula o mapa de bordas de um quadro inteiro de uma só vez.
+// Deve ser calculado antes de o quadro ser modificado, para que os filtros que o consultam
+// enxerguem as bordas do quadro original e não de linhas já filtradas.
+func ComputeEdgeMap(frame Frame, op GradientOperator, threshold float64) EdgeMap {
+	edges := make(EdgeMap, len(frame))
+	for y, row := range frame {
+		edges[y] = make([]bool, len(row))
+		for x := range row {
+			edges[y][x] = IsEdge(frame, y, x, op, threshold)
+		}
+	}
+	return edges
+}
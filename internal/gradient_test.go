@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"math"
+	"testing"
+)
+
+// Helper function to create a frame with a vertical step edge at the given column
+func createVerticalEdgeFrame(height, width, column int, dark, bright uint8) Frame {
+	frame := make(Frame, height)
+	for y := range frame {
+		frame[y] = make([]uint8, width)
+		for x := range frame[y] {
+			if x < column {
+				frame[y][x] = dark
+			} else {
+				frame[y][x] = bright
+			}
+		}
+	}
+	return frame
+}
+
+func TestGradient(t *testing.T) {
+	frame := createVerticalEdgeFrame(5, 5, 2, 0, 100)
+
+	tests := []struct {
+		name       string
+		op         GradientOperator
+		expectedGx float64
+		expectedGy float64
+	}{
+		{"central difference", GradientCentralDifference, 50, 0},
+		{"sobel", GradientSobel, 400, 0},
+		{"scharr", GradientScharr, 1600, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gx, gy := Gradient(frame, 2, 2, tt.op)
+			if gx != tt.expectedGx || gy != tt.expectedGy {
+				t.Errorf("Gradient(%v) = (%f, %f), expected (%f, %f)", tt.op, gx, gy, tt.expectedGx, tt.expectedGy)
+			}
+		})
+	}
+}
+
+func TestGradientMagnitude_Border(t *testing.T) {
+	frame := createTestFrame(5, 5, 100)
+
+	borderPixels := [][2]int{{0, 2}, {4, 2}, {2, 0}, {2, 4}}
+	for _, p := range borderPixels {
+		if magnitude := GradientMagnitude(frame, p[0], p[1], GradientSobel); !math.IsInf(magnitude, 1) {
+			t.Errorf("GradientMagnitude(%d, %d) = %f, expected +Inf for border pixel", p[0], p[1], magnitude)
+		}
+	}
+
+	if magnitude := GradientMagnitude(frame, 2, 2, GradientSobel); magnitude != 0 {
+		t.Errorf("GradientMagnitude on uniform frame = %f, expected 0", magnitude)
+	}
+}
+
+func TestParseGradientOperator(t *testing.T) {
+	for _, op := range []GradientOperator{GradientCentralDifference, GradientSobel, GradientScharr} {
+		parsed, err := ParseGradientOperator(op.String())
+		if err != nil {
+			t.Fatalf("ParseGradientOperator(%q) returned error: %v", op.String(), err)
+		}
+		if parsed != op {
+			t.Errorf("ParseGradientOperator(%q) = %v, expected %v", op.String(), parsed, op)
+		}
+	}
+
+	if _, err := ParseGradientOperator("prewitt"); err == nil {
+		t.Error("ParseGradientOperator should reject unknown operators")
+	}
+}
+
+func TestComputeEdgeMap(t *testing.T) {
+	frame := createVerticalEdgeFrame(5, 6, 3, 0, 200)
+	edges := ComputeEdgeMap(frame, GradientSobel, DefaultEdgeThreshold)
+
+	for y := range frame {
+		for x := range frame[y] {
+			border := y == 0 || y == len(frame)-1 || x == 0 || x == len(frame[y])-1
+			nearStep := x == 2 || x == 3
+			expected := border || nearStep
+			if edges.At(y, x) != expected {
+				t.Errorf("edge map at (%d, %d) = %v, expected %v", y, x, edges.At(y, x), expected)
+			}
+		}
+	}
+
+	// 5x6 frame: 18 border pixels plus 3 interior rows crossing the step at x=2 and x=3.
+	if count := edges.Count(); count != 24 {
+		t.Errorf("Count() = %d, expected 24", count)
+	}
+}
+
+// The spatial and temporal stages must agree on what an edge is.
+func TestEdgeAgreementBetweenStages(t *testing.T) {
+	frame := createPatternFrame(8, 8)
+	for y := 2; y < 6; y++ {
+		frame[y][4] = 250
+	}
+	videoFrames := VideoFrames{frame}
+	edges := ComputeEdgeMap(frame, DefaultEdgeOperator, DefaultEdgeThreshold)
+
+	for y := range frame {
+		for x := range frame[y] {
+			spatial := GetPixelRadius(frame, y, x, 1).IsEdgePixel(DefaultEdgeThreshold)
+			temporal := isEdgePixel(videoFrames, 0, y, x)
+			if spatial != temporal || spatial != edges.At(y, x) {
+				t.Errorf("edge disagreement at (%d, %d): spatial=%v temporal=%v map=%v",
+					y, x, spatial, temporal, edges.At(y, x))
+			}
+		}
+	}
+}
+
+func BenchmarkComputeEdgeMap(b *testing.B) {
+	frame := createPatternFrame(100, 100)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ComputeEdgeMap(frame, GradientSobel, DefaultEdgeThreshold)
+	}
+}
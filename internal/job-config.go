@@ -0,0 +1,272 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// JobConfig descreve um trabalho completo num arquivo JSON: vídeo de entrada, intervalo de quadros,
+// a lista ordenada de filtros com seus parâmetros, a saída e os relatórios.
+type JobConfig struct {
+	Input        string         `json:"input"`
+	Frames       FrameRange     `json:"frames"`
+	AutoStrength bool           `json:"auto_strength"` // Ajusta os filtros NoiseScalable ao ruído estimado.
+	Filters      []FilterConfig `json:"filters"`
+	Output       OutputConfig   `json:"output"`
+	Reports      ReportsConfig  `json:"reports"`
+}
+
+// FrameRange seleciona os quadros [Start, End) do vídeo de entrada. End 0 vai até o último quadro.
+type FrameRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// FilterConfig é um estágio do trabalho: o nome do filtro registrado e os seus parâmetros, no mesmo
+// formato de Filter.Config. Parâmetros ausentes mantêm o valor padrão do filtro.
+//
+// A janela do timetravaler só olha para trás ("direction": "backward", o padrão); "bidirectional" é
+// rejeitado por Validate. Para usar quadros anteriores e posteriores, use o filtro spatiotemporal,
+// cujo temporal_radius vale para os dois lados.
+type FilterConfig struct {
+	Filter string          `json:"filter"`
+	Name   string          `json:"name,omitempty"`   // Nome do estágio; vazio, usa o nome do filtro.
+	Passes int             `json:"passes,omitempty"` // Passadas de filtros espaciais e pontuais; 0 equivale a 1.
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// OutputConfig descreve o vídeo de saída.
+type OutputConfig struct {
+	Path     string  `json:"path"`
+	Codec    string  `json:"codec"` // FourCC do codec; ignorado em saídas .y4m.
+	FPS      float64 `json:"fps"`
+	Original string  `json:"original,omitempty"` // Se definido, grava também o trecho de entrada sem filtrar.
+}
+
+// ReportsConfig lista os relatórios opcionais do trabalho.
+type ReportsConfig struct {
+	StatsJSON       string  `json:"stats_json,omitempty"`
+	StatsCSV        string  `json:"stats_csv,omitempty"`
+	DecisionsDir    string  `json:"decisions_dir,omitempty"`
+	Visualize       string  `json:"visualize,omitempty"` // none, side-by-side ou diff.
+	VisualizeOutput string  `json:"visualize_output,omitempty"`
+	DiffGain        float64 `json:"diff_gain,omitempty"`
+}
+
+// DefaultJobConfig retorna os valores usados para os campos ausentes do arquivo.
+func DefaultJobConfig() JobConfig {
+	return JobConfig{
+		Output:  OutputConfig{Codec: "avc1", FPS: 24},
+		Reports: ReportsConfig{Visualize: VisualNone.String(), DiffGain: DefaultDiffGain},
+	}
+}
+
+// ParseJobConfig lê e valida uma configuração em JSON. Campos desconhecidos são rejeitados,
+// inclusive nos parâmetros dos filtros.
+func ParseJobConfig(r io.Reader) (JobConfig, error) {
+	config, err := DecodeJobConfig(r)
+	if err != nil {
+		return JobConfig{}, err
+	}
+	if err := config.Validate(); err != nil {
+		return JobConfig{}, err
+	}
+	return config, nil
+}
+
+// DecodeJobConfig lê uma configuração em JSON sobre os valores padrão, sem validá-la, para quem
+// precisa completar campos (como os caminhos de saída) antes de chamar Validate.
+func DecodeJobConfig(r io.Reader) (JobConfig, error) {
+	config := DefaultJobConfig()
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return JobConfig{}, fmt.Errorf("configuração inválida: %w", err)
+	}
+	return config, nil
+}
+
+// LoadJobConfig lê e valida o arquivo de configuração. Caminhos relativos de entrada, saída e
+// relatórios são resolvidos a partir do diretório do arquivo.
+func LoadJobConfig(path string) (JobConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return JobConfig{}, err
+	}
+	defer file.Close()
+
+	config, err := ParseJobConfig(file)
+	if err != nil {
+		return JobConfig{}, fmt.Errorf("%s: %w", path, err)
+	}
+	config.resolvePaths(filepath.Dir(path))
+	return config, nil
+}
+
+// resolvePaths torna relativos a dir os caminhos relativos da configuração.
+func (c *JobConfig) resolvePaths(dir string) {
+	for _, path := range []*string{&c.Input, &c.Output.Path, &c.Output.Original, &c.Reports.StatsJSON,
+		&c.Reports.StatsCSV, &c.Reports.DecisionsDir, &c.Reports.VisualizeOutput} {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(dir, *path)
+		}
+	}
+}
+
+// Validate verifica a configuração e constrói os filtros, rejeitando nomes desconhecidos e
+// parâmetros fora do intervalo.
+func (c JobConfig) Validate() error {
+	if c.Input == "" {
+		return fmt.Errorf("configuração sem input")
+	}
+	if c.Frames.Start < 0 {
+		return fmt.Errorf("frames.start deve ser >= 0, recebido %d", c.Frames.Start)
+	}
+	if c.Frames.End != 0 && c.Frames.End <= c.Frames.Start {
+		return fmt.Errorf("frames.end deve ser 0 ou > frames.start (%d), recebido %d", c.Frames.Start, c.Frames.End)
+	}
+	if c.Output.Path == "" {
+		return fmt.Errorf("configuração sem output.path")
+	}
+	if !strings.EqualFold(filepath.Ext(c.Output.Path), ".y4m") && len(c.Output.Codec) != 4 {
+		return fmt.Errorf("output.codec deve ser um FourCC de 4 caracteres, recebido %q", c.Output.Codec)
+	}
+	if !(c.Output.FPS > 0) {
+		return fmt.Errorf("output.fps deve ser > 0, recebido %f", c.Output.FPS)
+	}
+	mode, err := ParseVisualMode(c.Reports.Visualize)
+	if err != nil {
+		return fmt.Errorf("reports.visualize: %w", err)
+	}
+	if mode != VisualNone && c.Reports.VisualizeOutput == "" {
+		return fmt.Errorf("reports.visualize_output é obrigatório com reports.visualize %q", mode)
+	}
+	_, err = c.Stages()
+	return err
+}
+
+// Stages constrói os estágios na ordem da configuração.
+func (c JobConfig) Stages() ([]Stage, error) {
+	if len(c.Filters) == 0 {
+		return nil, fmt.Errorf("configuração sem filtros")
+	}
+
+	stages := make([]Stage, len(c.Filters))
+	for i, fc := range c.Filters {
+		stage, err := fc.stage()
+		if err != nil {
+			return nil, fmt.Errorf("filters[%d]: %w", i, err)
+		}
+		stages[i] = stage
+	}
+	return stages, nil
+}
+
+// stage constrói o filtro pelo registro e monta o estágio.
+func (fc FilterConfig) stage() (Stage, error) {
+	var decode func(any) error
+	if len(fc.Params) > 0 {
+		decode = func(v any) error {
+			decoder := json.NewDecoder(bytes.NewReader(fc.Params))
+			decoder.DisallowUnknownFields()
+			return decoder.Decode(v)
+		}
+	}
+	filter, err := NewFilter(fc.Filter, decode)
+	if err != nil {
+		return Stage{}, err
+	}
+
+	passes := fc.Passes
+	if passes == 0 {
+		passes = 1
+	}
+	if filter.Kind() == FilterTemporal && passes != 1 {
+		return Stage{}, fmt.Errorf("filtro temporal %s roda uma única vez, recebido passes %d", fc.Filter, fc.Passes)
+	}
+	stage := Stage{Name: fc.Name, Filter: filter, Passes: passes}
+	return stage, stage.validate()
+}
+
+// Pipeline constrói o pipeline descrito pela configuração.
+func (c JobConfig) Pipeline() (*Pipeline, error) {
+	stages, err := c.Stages()
+	if err != nil {
+		return nil, err
+	}
+	return NewPipelineFromStages(stages, c.AutoStrength)
+}
+
+// Resolved retorna uma cópia da configuração com todos os parâmetros de cada filtro preenchidos,
+// inclusive os padrão.
+func (c JobConfig) Resolved() (JobConfig, error) {
+	stages, err := c.Stages()
+	if err != nil {
+		return JobConfig{}, err
+	}
+	filters, err := FilterConfigs(stages)
+	if err != nil {
+		return JobConfig{}, err
+	}
+	c.Filters = filters
+	return c, nil
+}
+
+// Hash identifica o que determina os quadros de saída do trabalho: a entrada, o intervalo, o ajuste
+// ao ruído e os filtros com os parâmetros resolvidos. Um checkpoint só é retomado com o mesmo hash.
+func (c JobConfig) Hash() (string, error) {
+	resolved, err := c.Resolved()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(JobConfig{
+		Input:        resolved.Input,
+		Frames:       resolved.Frames,
+		AutoStrength: resolved.AutoStrength,
+		Filters:      resolved.Filters,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// FilterConfigs descreve estágios já construídos como FilterConfig, com os parâmetros de Filter.Config.
+func FilterConfigs(stages []Stage) ([]FilterConfig, error) {
+	filters := make([]FilterConfig, len(stages))
+	for i, stage := range stages {
+		params, err := json.Marshal(stage.Filter.Config())
+		if err != nil {
+			return nil, fmt.Errorf("estágio %s: %w", stage.label(), err)
+		}
+		filters[i] = FilterConfig{Filter: stage.Filter.Name(), Name: stage.Name, Passes: stage.Passes, Params: params}
+	}
+	return filters, nil
+}
+
+// WriteJSON escreve a configuração em JSON indentado.
+func (c JobConfig) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c)
+}
+
+// Apply retorna os quadros do intervalo. End além do último quadro é limitado ao tamanho do vídeo.
+func (r FrameRange) Apply(frames VideoFrames) (VideoFrames, error) {
+	end := r.End
+	if end == 0 || end > len(frames) {
+		end = len(frames)
+	}
+	if r.Start >= end {
+		return nil, fmt.Errorf("intervalo de quadros [%d, %d) vazio num vídeo de %d quadros", r.Start, r.End, len(frames))
+	}
+	return frames[r.Start:end], nil
+}
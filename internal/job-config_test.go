@@ -0,0 +1,218 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Helper function to parse a job config from a string
+func parseJobConfigString(t *testing.T, config string) (JobConfig, error) {
+	t.Helper()
+	return ParseJobConfig(strings.NewReader(config))
+}
+
+func TestLoadJobConfig_Example(t *testing.T) {
+	path := filepath.Join("testdata", "config", "job.json")
+	config, err := LoadJobConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Frames != (FrameRange{Start: 400, End: 640}) {
+		t.Errorf("frames = %+v", config.Frames)
+	}
+	if expected := filepath.Join("testdata", "config", "out", "video2.mp4"); config.Output.Path != expected {
+		t.Errorf("output path = %q, expected %q (relative to the config file)", config.Output.Path, expected)
+	}
+	if config.Reports.DiffGain != DefaultDiffGain || config.Reports.Visualize != VisualNone.String() {
+		t.Errorf("reports defaults not applied: %+v", config.Reports)
+	}
+
+	stages, err := config.Stages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stages) != 2 {
+		t.Fatalf("got %d stages, expected 2", len(stages))
+	}
+	adaptive := stages[0].Filter.(*AdaptiveFilter)
+	if stages[0].Passes != 3 || adaptive.Radius != 2 || adaptive.Params != DefaultAdaptiveParams() {
+		t.Errorf("spatial stage = %+v, filter %+v", stages[0], adaptive)
+	}
+	temporal := stages[1].Filter.(*TimeTravalerFilter)
+	expected := DefaultTemporalParams()
+	expected.NoiseAlpha = 0.5
+	if stages[1].Name != StageTemporal || temporal.Window != 7 || temporal.Params != expected {
+		t.Errorf("temporal stage = %+v, filter %+v", stages[1], temporal)
+	}
+}
+
+func TestParseJobConfig_Rejects(t *testing.T) {
+	cases := map[string]string{
+		"unknown filter":       `{"input": "a.y4m", "output": {"path": "b.y4m"}, "filters": [{"filter": "sharpen"}]}`,
+		"unknown param":        `{"input": "a.y4m", "output": {"path": "b.y4m"}, "filters": [{"filter": "adaptive", "params": {"raidus": 2}}]}`,
+		"out of range radius":  `{"input": "a.y4m", "output": {"path": "b.y4m"}, "filters": [{"filter": "adaptive", "params": {"radius": 0}}]}`,
+		"out of range alpha":   `{"input": "a.y4m", "output": {"path": "b.y4m"}, "filters": [{"filter": "timetravaler", "params": {"params": {"weak_alpha": 1.5}}}]}`,
+		"bidirectional window": `{"input": "a.y4m", "output": {"path": "b.y4m"}, "filters": [{"filter": "timetravaler", "params": {"window": 7, "direction": "bidirectional"}}]}`,
+		"unknown direction":    `{"input": "a.y4m", "output": {"path": "b.y4m"}, "filters": [{"filter": "timetravaler", "params": {"direction": "forward"}}]}`,
+		"temporal passes":      `{"input": "a.y4m", "output": {"path": "b.y4m"}, "filters": [{"filter": "timetravaler", "passes": 2}]}`,
+		"no filters":           `{"input": "a.y4m", "output": {"path": "b.y4m"}}`,
+		"unknown field":        `{"input": "a.y4m", "output": {"path": "b.y4m"}, "filters": [{"filter": "gamma"}], "extra": 1}`,
+		"missing input":        `{"output": {"path": "b.y4m"}, "filters": [{"filter": "gamma"}]}`,
+		"bad frame range":      `{"input": "a.y4m", "frames": {"start": 10, "end": 5}, "output": {"path": "b.y4m"}, "filters": [{"filter": "gamma"}]}`,
+		"bad codec":            `{"input": "a.y4m", "output": {"path": "b.mp4", "codec": "h264x"}, "filters": [{"filter": "gamma"}]}`,
+		"bad visualize":        `{"input": "a.y4m", "output": {"path": "b.y4m"}, "filters": [{"filter": "gamma"}], "reports": {"visualize": "overlay"}}`,
+	}
+	for name, config := range cases {
+		if _, err := parseJobConfigString(t, config); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestParseJobConfig_TemporalDirection(t *testing.T) {
+	config, err := parseJobConfigString(t, `{"input": "a.y4m", "output": {"path": "b.y4m"},
+		"filters": [{"filter": "timetravaler", "params": {"window": 5, "direction": "backward"}}]}`)
+	if err != nil {
+		t.Fatalf("backward window rejected: %v", err)
+	}
+	stages, err := config.Stages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter := stages[0].Filter.(*TimeTravalerFilter); filter.Window != 5 || filter.Direction != WindowBackward {
+		t.Errorf("filter = %+v, expected a backward window of 5", filter)
+	}
+
+	_, err = parseJobConfigString(t, `{"input": "a.y4m", "output": {"path": "b.y4m"},
+		"filters": [{"filter": "timetravaler", "params": {"direction": "bidirectional"}}]}`)
+	if err == nil || !strings.Contains(err.Error(), FilterNameSpatioTemporal) {
+		t.Errorf("bidirectional window: got error %v, expected one pointing to %s", err, FilterNameSpatioTemporal)
+	}
+}
+
+func TestJobConfig_ResolvedRoundTrip(t *testing.T) {
+	config, err := parseJobConfigString(t, `{"input": "a.y4m", "output": {"path": "b.y4m"},
+		"filters": [{"filter": "bilateral", "params": {"radius": 3}}, {"filter": "gamma", "passes": 2}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := config.Resolved()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var params BilateralParams
+	if err := json.Unmarshal(resolved.Filters[0].Params, &params); err != nil {
+		t.Fatal(err)
+	}
+	expected := DefaultBilateralParams()
+	expected.Radius = 3
+	if params != expected {
+		t.Errorf("resolved bilateral params = %+v, expected %+v", params, expected)
+	}
+	if resolved.Filters[1].Passes != 2 {
+		t.Errorf("resolved gamma passes = %d, expected 2", resolved.Filters[1].Passes)
+	}
+
+	// A configuração resolvida precisa ser lida de volta com o mesmo resultado.
+	var buf bytes.Buffer
+	if err := resolved.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	reparsed, err := ParseJobConfig(&buf)
+	if err != nil {
+		t.Fatalf("resolved config does not parse: %v\n%s", err, buf.String())
+	}
+	again, err := reparsed.Resolved()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range again.Filters {
+		if !bytes.Equal(again.Filters[i].Params, resolved.Filters[i].Params) {
+			t.Errorf("filter %d: params changed after round trip: %s vs %s", i, again.Filters[i].Params, resolved.Filters[i].Params)
+		}
+	}
+}
+
+func TestJobConfig_Hash(t *testing.T) {
+	hash := func(config string) string {
+		t.Helper()
+		parsed, err := parseJobConfigString(t, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := parsed.Hash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	base := hash(`{"input": "a.y4m", "output": {"path": "b.y4m"}, "filters": [{"filter": "gamma"}]}`)
+	// Parâmetros explícitos iguais aos padrão e outra saída não mudam os quadros gerados.
+	same := hash(`{"input": "a.y4m", "output": {"path": "c.y4m"}, "filters": [{"filter": "gamma", "params": {"gamma": 1}}]}`)
+	if same != base {
+		t.Error("hash changed with default params or another output path")
+	}
+	other := hash(`{"input": "a.y4m", "output": {"path": "b.y4m"}, "filters": [{"filter": "gamma", "params": {"gamma": 2}}]}`)
+	if other == base {
+		t.Error("hash did not change with the filter params")
+	}
+}
+
+func TestJobConfig_MatchesPipelineParams(t *testing.T) {
+	params := DefaultPipelineParams()
+	stages, err := params.Stages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	filters, err := FilterConfigs(stages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultJobConfig()
+	config.Input, config.Output.Path, config.Filters = "a.y4m", "b.y4m", filters
+	config.AutoStrength = params.AutoStrength
+
+	fromConfig, err := config.Pipeline()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromParams, err := NewPipeline(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := createVideoWithSpike(6, 3)
+	a, err := fromConfig.Run(frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := fromParams.Run(frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a.Frames, b.Frames) {
+		t.Error("config and params pipelines produced different frames")
+	}
+}
+
+func TestFrameRange_Apply(t *testing.T) {
+	frames := make(VideoFrames, 10)
+	got, err := FrameRange{Start: 2, End: 20}.Apply(frames)
+	if err != nil || len(got) != 8 {
+		t.Errorf("Apply [2, 20) on 10 frames = %d frames, err %v; expected 8", len(got), err)
+	}
+	got, err = FrameRange{}.Apply(frames)
+	if err != nil || len(got) != 10 {
+		t.Errorf("Apply [0, 0) = %d frames, err %v; expected all 10", len(got), err)
+	}
+	if _, err := (FrameRange{Start: 10}).Apply(frames); err == nil {
+		t.Error("expected error for a range past the end")
+	}
+}
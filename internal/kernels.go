@@ -0,0 +1,170 @@
+package internal
+
+import "math"
+
+// Kernels de mistura (alpha*alvo + (1-alpha)*atual) e de variância usados pelos filtros. Cada um
+// tem a versão de referência, em float64, e a versão em ponto fixo, só com inteiros. A tag de build
+// fixedpoint seleciona a versão em ponto fixo (veja fixedPoint); sem a tag, os filtros usam a de
+// referência e o resultado não muda. A versão em ponto fixo difere da de referência em no máximo
+// uma unidade por pixel, por causa do arredondamento dos pesos.
+
+// blendShift é o número de bits da parte fracionária dos pesos em ponto fixo.
+const blendShift = 16
+
+// blendOne é o peso 1 em ponto fixo.
+const blendOne = 1 << blendShift
+
+// mix é um peso de mistura nas duas representações, calculado uma vez por parâmetro: alpha para o
+// kernel de referência e weight, o mesmo peso em ponto fixo, para o de ponto fixo.
+type mix struct {
+	alpha  float64
+	weight uint32
+}
+
+// newMix converte um alfa em [0, 1] para as duas representações.
+func newMix(alpha float64) mix {
+	return mix{alpha: alpha, weight: uint32(math.Round(min(max(alpha, 0), 1) * blendOne))}
+}
+
+// roundPixel arredonda uma média ponderada para o pixel mais próximo, limitado a [0, 255]. Usado
+// pelos filtros que calculam médias em float64 fora dos kernels de mistura.
+func roundPixel(value float64) uint8 {
+	if value <= 0 {
+		return 0
+	}
+	if value >= 255 {
+		return 255
+	}
+	return uint8(value + 0.5)
+}
+
+// blend mistura target e current com o kernel selecionado pela tag de build.
+func blend(target, current uint8, m mix) uint8 {
+	if fixedPoint {
+		return blendFixed(target, current, m.weight)
+	}
+	return blendFloat(target, current, m.alpha)
+}
+
+// blendFloat é o kernel de referência: alpha*target + (1-alpha)*current, limitado a [0, 255] e
+// truncado.
+func blendFloat(target, current uint8, alpha float64) uint8 {
+	result := alpha*float64(target) + (1-alpha)*float64(current)
+	if result < 0 {
+		return 0
+	}
+	if result > 255 {
+		return 255
+	}
+	return uint8(result)
+}
+
+// blendFixed é o blendFloat em ponto fixo, com weight = newMix(alpha).weight. Com o peso em [0, 1],
+// o resultado já está em [0, 255].
+func blendFixed(target, current uint8, weight uint32) uint8 {
+	return uint8((weight*uint32(target) + (blendOne-weight)*uint32(current)) >> blendShift)
+}
+
+// blendMean mistura a média sum/n e current com o kernel selecionado pela tag de build.
+func blendMean(sum, n int, current uint8, m mix) uint8 {
+	if fixedPoint {
+		return blendMeanFixed(sum, n, current, m.weight)
+	}
+	return blendMeanFloat(sum, n, current, m.alpha)
+}
+
+// blendMeanFloat é o kernel de referência da mistura com uma média, que não é inteira.
+func blendMeanFloat(sum, n int, current uint8, alpha float64) uint8 {
+	mean := float64(sum) / float64(n)
+	result := alpha*mean + (1-alpha)*float64(current)
+	if result < 0 {
+		return 0
+	}
+	if result > 255 {
+		return 255
+	}
+	return uint8(result)
+}
+
+// blendMeanFixed é o blendMeanFloat em ponto fixo: a média entra com blendShift bits fracionários.
+func blendMeanFixed(sum, n int, current uint8, weight uint32) uint8 {
+	mean := (uint64(sum) << blendShift) / uint64(n)
+	result := uint64(weight)*mean + uint64(blendOne-weight)*(uint64(current)<<blendShift)
+	return uint8(result >> (2 * blendShift))
+}
+
+// blendRow grava em dst a mistura de cada targets[i] com current[i] pelo peso mixes[i], com o kernel
+// selecionado pela tag de build. Os quatro slices têm o mesmo tamanho.
+func blendRow(dst, targets, current []uint8, mixes []mix) {
+	if fixedPoint {
+		blendRowFixed(dst, targets, current, mixes)
+	} else {
+		blendRowFloat(dst, targets, current, mixes)
+	}
+}
+
+// blendRowFloat é o blendFloat aplicado a uma linha inteira.
+func blendRowFloat(dst, targets, current []uint8, mixes []mix) {
+	// Fatiar todos com o tamanho de dst deixa o compilador eliminar as verificações de limite.
+	targets, current, mixes = targets[:len(dst)], current[:len(dst)], mixes[:len(dst)]
+	for i := range dst {
+		dst[i] = blendFloat(targets[i], current[i], mixes[i].alpha)
+	}
+}
+
+// blendRowFixed é o blendFixed aplicado a uma linha inteira, sem ramificações nem float.
+func blendRowFixed(dst, targets, current []uint8, mixes []mix) {
+	targets, current, mixes = targets[:len(dst)], current[:len(dst)], mixes[:len(dst)]
+	for i := range dst {
+		w := mixes[i].weight
+		dst[i] = uint8((w*uint32(targets[i]) + (blendOne-w)*uint32(current[i])) >> blendShift)
+	}
+}
+
+// blendMeanRow grava em dst a mistura de cada média sums[i]/counts[i] com current[i] pelo peso
+// mixes[i], com o kernel selecionado pela tag de build. Os cinco slices têm o mesmo tamanho.
+func blendMeanRow(dst []uint8, sums, counts []int32, current []uint8, mixes []mix) {
+	if fixedPoint {
+		blendMeanRowFixed(dst, sums, counts, current, mixes)
+	} else {
+		blendMeanRowFloat(dst, sums, counts, current, mixes)
+	}
+}
+
+// blendMeanRowFloat é o blendMeanFloat aplicado a uma linha inteira.
+func blendMeanRowFloat(dst []uint8, sums, counts []int32, current []uint8, mixes []mix) {
+	sums, counts, current, mixes = sums[:len(dst)], counts[:len(dst)], current[:len(dst)], mixes[:len(dst)]
+	for i := range dst {
+		dst[i] = blendMeanFloat(int(sums[i]), int(counts[i]), current[i], mixes[i].alpha)
+	}
+}
+
+// blendMeanRowFixed é o blendMeanFixed aplicado a uma linha inteira.
+func blendMeanRowFixed(dst []uint8, sums, counts []int32, current []uint8, mixes []mix) {
+	sums, counts, current, mixes = sums[:len(dst)], counts[:len(dst)], current[:len(dst)], mixes[:len(dst)]
+	for i := range dst {
+		dst[i] = blendMeanFixed(int(sums[i]), int(counts[i]), current[i], mixes[i].weight)
+	}
+}
+
+// varianceFromSums calcula a variância populacional de n valores a partir da soma e da soma dos
+// quadrados, com o kernel selecionado pela tag de build.
+func varianceFromSums(sum, sumSq int64, n int) float64 {
+	if fixedPoint {
+		return varianceFixed(sum, sumSq, n)
+	}
+	return varianceFloat(sum, sumSq, n)
+}
+
+// varianceFloat é o kernel de referência: E[X²] - E[X]².
+func varianceFloat(sum, sumSq int64, n int) float64 {
+	mean := float64(sum) / float64(n)
+	return float64(sumSq)/float64(n) - mean*mean
+}
+
+// varianceFixed calcula a variância como (n·Σx² - (Σx)²) / n², com o numerador exato em inteiros e
+// uma única divisão, sem o cancelamento de E[X²] - E[X]².
+func varianceFixed(sum, sumSq int64, n int) float64 {
+	count := int64(n)
+	return float64(count*sumSq-sum*sum) / float64(count*count)
+}
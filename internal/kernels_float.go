@@ -0,0 +1,6 @@
+//go:build !fixedpoint
+
+package internal
+
+// fixedPoint seleciona os kernels em ponto fixo; é ligado pela tag de build fixedpoint.
+const fixedPoint = false
@@ -0,0 +1,162 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Helper function to list the alphas used by the default parameters plus random ones
+func kernelTestAlphas(rng *rand.Rand) []float64 {
+	adaptive, temporal := DefaultAdaptiveParams(), DefaultTemporalParams()
+	alphas := []float64{
+		0, 1,
+		adaptive.EdgeAlpha, adaptive.SmoothAlpha, adaptive.MidAlpha, adaptive.TextureAlpha,
+		temporal.BlurAlpha, temporal.NoiseAlpha, temporal.StrongAlpha, temporal.MediumAlpha, temporal.WeakAlpha,
+	}
+	for range 20 {
+		alphas = append(alphas, rng.Float64())
+	}
+	return alphas
+}
+
+// Helper function to compute the absolute difference between two pixel values
+func pixelDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+func TestBlendFixed_MatchesFloat(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, alpha := range kernelTestAlphas(rng) {
+		m := newMix(alpha)
+		for target := range 256 {
+			for current := range 256 {
+				expected := blendFloat(uint8(target), uint8(current), alpha)
+				got := blendFixed(uint8(target), uint8(current), m.weight)
+				if pixelDiff(got, expected) > 1 {
+					t.Fatalf("alpha %v, target %d, current %d: fixed %d, float %d", alpha, target, current, got, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestBlendMeanFixed_MatchesFloat(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, alpha := range kernelTestAlphas(rng) {
+		m := newMix(alpha)
+		for trial := range 5000 {
+			n := 1 + rng.Intn(24)
+			sum := rng.Intn(255*n + 1)
+			current := uint8(rng.Intn(256))
+			expected := blendMeanFloat(sum, n, current, alpha)
+			if got := blendMeanFixed(sum, n, current, m.weight); pixelDiff(got, expected) > 1 {
+				t.Fatalf("alpha %v, trial %d (sum %d, n %d, current %d): fixed %d, float %d",
+					alpha, trial, sum, n, current, got, expected)
+			}
+		}
+	}
+}
+
+func TestBlendRow_MatchesScalar(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	const width = 257
+	targets, current := make([]uint8, width), make([]uint8, width)
+	mixes := make([]mix, width)
+	for i := range width {
+		targets[i], current[i] = uint8(rng.Intn(256)), uint8(rng.Intn(256))
+		mixes[i] = newMix(rng.Float64())
+	}
+
+	dst := make([]uint8, width)
+	blendRowFloat(dst, targets, current, mixes)
+	for i, v := range dst {
+		if expected := blendFloat(targets[i], current[i], mixes[i].alpha); v != expected {
+			t.Errorf("blendRowFloat pixel %d = %d, expected %d", i, v, expected)
+		}
+	}
+	blendRowFixed(dst, targets, current, mixes)
+	for i, v := range dst {
+		if expected := blendFixed(targets[i], current[i], mixes[i].weight); v != expected {
+			t.Errorf("blendRowFixed pixel %d = %d, expected %d", i, v, expected)
+		}
+	}
+}
+
+func TestBlendFloat_Truncates(t *testing.T) {
+	tests := []struct {
+		target, current uint8
+		alpha           float64
+		expected        uint8
+	}{
+		{100, 200, 0.3, 170},
+		{0, 1, 0.5, 0},
+		{0, 10, 0.26, 7},
+		{255, 0, 1, 255},
+		{255, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := blendFloat(tt.target, tt.current, tt.alpha); got != tt.expected {
+			t.Errorf("blendFloat(%d, %d, %v) = %d, expected %d", tt.target, tt.current, tt.alpha, got, tt.expected)
+		}
+	}
+}
+
+func TestVarianceFixed_MatchesFloat(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	for _, n := range []int{2, 3, 7, 30, 60} {
+		for trial := range 1000 {
+			var sum, sumSq int64
+			for range n {
+				v := int64(rng.Intn(256))
+				sum += v
+				sumSq += v * v
+			}
+			expected := varianceFloat(sum, sumSq, n)
+			if got := varianceFixed(sum, sumSq, n); math.Abs(got-expected) > 1e-6 {
+				t.Fatalf("n=%d trial %d: fixed %v, float %v", n, trial, got, expected)
+			}
+		}
+	}
+}
+
+func TestVarianceFixed_ConstantIsZero(t *testing.T) {
+	for _, v := range []int64{0, 1, 128, 255} {
+		const n = 30
+		if got := varianceFixed(v*n, v*v*n, n); got != 0 {
+			t.Errorf("variance of %d repeated = %v, expected 0", v, got)
+		}
+	}
+}
+
+// Benchmark for the float and fixed-point row blends over a 1080p line
+func BenchmarkBlendRow(b *testing.B) {
+	rng := rand.New(rand.NewSource(5))
+	const width = 1920
+	targets, current, dst := make([]uint8, width), make([]uint8, width), make([]uint8, width)
+	mixes := make([]mix, width)
+	for i := range width {
+		targets[i], current[i] = uint8(rng.Intn(256)), uint8(rng.Intn(256))
+		mixes[i] = newMix(DefaultTemporalParams().MediumAlpha)
+	}
+
+	kernels := []struct {
+		name string
+		fn   func(dst, targets, current []uint8, mixes []mix)
+	}{
+		{"float", blendRowFloat},
+		{"fixed", blendRowFixed},
+	}
+	for _, kernel := range kernels {
+		b.Run(fmt.Sprintf("kernel=%s", kernel.name), func(b *testing.B) {
+			b.SetBytes(width)
+			for range b.N {
+				kernel.fn(dst, targets, current, mixes)
+			}
+		})
+	}
+}
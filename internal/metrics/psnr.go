@@ -0,0 +1,60 @@
+// Package metrics calcula métricas objetivas de qualidade (PSNR, SSIM e MS-SSIM) entre duas
+// sequências de quadros em escala de cinza, para medir o efeito dos filtros em vez de comparar os vídeos a olho.
+package metrics
+
+import (
+	"fmt"
+	"math"
+)
+
+// MaxPSNR é o valor retornado para quadros idênticos, no lugar de +Inf, para que relatórios
+// em JSON e médias continuem válidos.
+const MaxPSNR = 100.0
+
+// maxPixelValue é o maior valor de um pixel de 8 bits.
+const maxPixelValue = 255.0
+
+// MSE calcula o erro quadrático médio entre dois quadros de mesmas dimensões.
+func MSE(reference, test [][]uint8) float64 {
+	var sum float64
+	count := 0
+	for y, row := range reference {
+		testRow := test[y]
+		for x, value := range row {
+			diff := float64(value) - float64(testRow[x])
+			sum += diff * diff
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+// PSNR calcula a relação sinal-ruído de pico, em dB, entre dois quadros de mesmas dimensões.
+// Quadros idênticos retornam MaxPSNR.
+func PSNR(reference, test [][]uint8) float64 {
+	return psnrFromMSE(MSE(reference, test))
+}
+
+// psnrFromMSE converte um erro quadrático médio em PSNR, limitado a MaxPSNR.
+func psnrFromMSE(mse float64) float64 {
+	if mse == 0 {
+		return MaxPSNR
+	}
+	return math.Min(MaxPSNR, 10*math.Log10(maxPixelValue*maxPixelValue/mse))
+}
+
+// checkSameSize verifica se dois quadros têm as mesmas dimensões.
+func checkSameSize(reference, test [][]uint8) error {
+	if len(reference) != len(test) {
+		return fmt.Errorf("alturas diferentes: %d e %d", len(reference), len(test))
+	}
+	for y := range reference {
+		if len(reference[y]) != len(test[y]) {
+			return fmt.Errorf("larguras diferentes na linha %d: %d e %d", y, len(reference[y]), len(test[y]))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+// Helper function to create a frame filled with a constant value
+func createFrame(height, width int, value uint8) [][]uint8 {
+	frame := make([][]uint8, height)
+	for y := range frame {
+		frame[y] = make([]uint8, width)
+		for x := range frame[y] {
+			frame[y][x] = value
+		}
+	}
+	return frame
+}
+
+// Helper function to create a frame with a diagonal pattern
+func createPatternFrame(height, width int) [][]uint8 {
+	frame := make([][]uint8, height)
+	for y := range frame {
+		frame[y] = make([]uint8, width)
+		for x := range frame[y] {
+			frame[y][x] = uint8((x*7 + y*13) % 256)
+		}
+	}
+	return frame
+}
+
+func TestMSE(t *testing.T) {
+	tests := []struct {
+		name      string
+		reference [][]uint8
+		test      [][]uint8
+		expected  float64
+	}{
+		{"identical", createFrame(4, 4, 100), createFrame(4, 4, 100), 0},
+		{"constant offset", createFrame(4, 4, 100), createFrame(4, 4, 110), 100},
+		{"empty", [][]uint8{}, [][]uint8{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := MSE(tt.reference, tt.test); result != tt.expected {
+				t.Errorf("MSE() = %f, expected %f", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPSNR(t *testing.T) {
+	if result := PSNR(createFrame(4, 4, 50), createFrame(4, 4, 50)); result != MaxPSNR {
+		t.Errorf("PSNR of identical frames = %f, expected %f", result, MaxPSNR)
+	}
+
+	// MSE 100 -> 10*log10(255^2/100) = 28.1308 dB
+	result := PSNR(createFrame(4, 4, 100), createFrame(4, 4, 110))
+	if math.Abs(result-28.1308) > 1e-3 {
+		t.Errorf("PSNR() = %f, expected 28.1308", result)
+	}
+}
+
+func TestCheckSameSize(t *testing.T) {
+	if err := checkSameSize(createFrame(3, 4, 0), createFrame(3, 4, 0)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := checkSameSize(createFrame(3, 4, 0), createFrame(4, 4, 0)); err == nil {
+		t.Error("expected error for different heights")
+	}
+	if err := checkSameSize(createFrame(3, 4, 0), createFrame(3, 5, 0)); err == nil {
+		t.Error("expected error for different widths")
+	}
+}
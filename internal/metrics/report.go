@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// FrameMetrics guarda as métricas de um par de quadros.
+type FrameMetrics struct {
+	Frame  int     `json:"frame"`
+	PSNR   float64 `json:"psnr"`
+	SSIM   float64 `json:"ssim"`
+	MSSSIM float64 `json:"ms_ssim"`
+}
+
+// Summary agrega as métricas de todos os quadros.
+type Summary struct {
+	Frames     int     `json:"frames"`
+	MeanPSNR   float64 `json:"mean_psnr"`
+	MinPSNR    float64 `json:"min_psnr"`
+	MinPSNRAt  int     `json:"min_psnr_frame"`
+	MeanSSIM   float64 `json:"mean_ssim"`
+	MinSSIM    float64 `json:"min_ssim"`
+	MinSSIMAt  int     `json:"min_ssim_frame"`
+	MeanMSSSIM float64 `json:"mean_ms_ssim"`
+	MinMSSSIM  float64 `json:"min_ms_ssim"`
+	// GlobalPSNR é o PSNR calculado a partir do MSE de todo o vídeo, e não a média dos PSNRs por quadro.
+	GlobalPSNR float64 `json:"global_psnr"`
+}
+
+// Report é o resultado da comparação entre duas sequências de quadros.
+type Report struct {
+	Summary  Summary        `json:"summary"`
+	PerFrame []FrameMetrics `json:"per_frame"`
+}
+
+// CompareFrames calcula PSNR, SSIM e MS-SSIM entre dois quadros de mesmas dimensões.
+func CompareFrames(reference, test [][]uint8) (FrameMetrics, error) {
+	if err := checkSameSize(reference, test); err != nil {
+		return FrameMetrics{}, err
+	}
+	return FrameMetrics{
+		PSNR:   PSNR(reference, test),
+		SSIM:   SSIM(reference, test),
+		MSSSIM: MSSSIM(reference, test),
+	}, nil
+}
+
+// Compare calcula as métricas de cada par de quadros e o resumo do vídeo.
+// As duas sequências precisam ter o mesmo número de quadros e as mesmas dimensões.
+// Os quadros são comparados em paralelo, um worker por CPU.
+func Compare(reference, test [][][]uint8) (Report, error) {
+	if len(reference) != len(test) {
+		return Report{}, fmt.Errorf("número de quadros diferente: %d e %d", len(reference), len(test))
+	}
+	for i := range reference {
+		if err := checkSameSize(reference[i], test[i]); err != nil {
+			return Report{}, fmt.Errorf("quadro %d: %w", i, err)
+		}
+	}
+
+	report := Report{PerFrame: make([]FrameMetrics, len(reference))}
+	mses := make([]float64, len(reference))
+
+	var wg sync.WaitGroup
+	frameChan := make(chan int, len(reference))
+	for i := range reference {
+		frameChan <- i
+	}
+	close(frameChan)
+
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range frameChan {
+				mses[i] = MSE(reference[i], test[i])
+				report.PerFrame[i] = FrameMetrics{
+					Frame:  i,
+					PSNR:   psnrFromMSE(mses[i]),
+					SSIM:   SSIM(reference[i], test[i]),
+					MSSSIM: MSSSIM(reference[i], test[i]),
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	report.Summary = summarize(report.PerFrame, mses)
+	return report, nil
+}
+
+// summarize calcula médias e mínimos a partir das métricas por quadro.
+func summarize(perFrame []FrameMetrics, mses []float64) Summary {
+	summary := Summary{Frames: len(perFrame)}
+	if len(perFrame) == 0 {
+		return summary
+	}
+
+	summary.MinPSNR, summary.MinSSIM, summary.MinMSSSIM = math.Inf(1), math.Inf(1), math.Inf(1)
+	var totalMSE float64
+	for i, m := range perFrame {
+		summary.MeanPSNR += m.PSNR
+		summary.MeanSSIM += m.SSIM
+		summary.MeanMSSSIM += m.MSSSIM
+		totalMSE += mses[i]
+		if m.PSNR < summary.MinPSNR {
+			summary.MinPSNR, summary.MinPSNRAt = m.PSNR, m.Frame
+		}
+		if m.SSIM < summary.MinSSIM {
+			summary.MinSSIM, summary.MinSSIMAt = m.SSIM, m.Frame
+		}
+		summary.MinMSSSIM = math.Min(summary.MinMSSSIM, m.MSSSIM)
+	}
+
+	n := float64(len(perFrame))
+	summary.MeanPSNR /= n
+	summary.MeanSSIM /= n
+	summary.MeanMSSSIM /= n
+	summary.GlobalPSNR = psnrFromMSE(totalMSE / n)
+	return summary
+}
+
+// String formata o resumo em poucas linhas para o terminal.
+func (s Summary) String() string {
+	return fmt.Sprintf("Quadros: %d\n"+
+		"PSNR    médio %.2f dB  global %.2f dB  mínimo %.2f dB (quadro %d)\n"+
+		"SSIM    médio %.4f  mínimo %.4f (quadro %d)\n"+
+		"MS-SSIM médio %.4f  mínimo %.4f",
+		s.Frames, s.MeanPSNR, s.GlobalPSNR, s.MinPSNR, s.MinPSNRAt,
+		s.MeanSSIM, s.MinSSIM, s.MinSSIMAt,
+		s.MeanMSSSIM, s.MinMSSSIM)
+}
+
+// WriteCSV escreve as métricas por quadro em CSV, com cabeçalho.
+func (r Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"frame", "psnr", "ssim", "ms_ssim"}); err != nil {
+		return err
+	}
+	for _, m := range r.PerFrame {
+		record := []string{
+			strconv.Itoa(m.Frame),
+			strconv.FormatFloat(m.PSNR, 'f', 4, 64),
+			strconv.FormatFloat(m.SSIM, 'f', 6, 64),
+			strconv.FormatFloat(m.MSSSIM, 'f', 6, 64),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON escreve o relatório completo (resumo e métricas por quadro) em JSON indentado.
+func (r Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	reference := [][][]uint8{createFrame(16, 16, 100), createFrame(16, 16, 100), createFrame(16, 16, 100)}
+	test := [][][]uint8{createFrame(16, 16, 100), createFrame(16, 16, 110), createFrame(16, 16, 105)}
+
+	report, err := Compare(reference, test)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+
+	if len(report.PerFrame) != 3 || report.Summary.Frames != 3 {
+		t.Fatalf("expected 3 frames, got %d per-frame and summary %d", len(report.PerFrame), report.Summary.Frames)
+	}
+	if report.PerFrame[0].PSNR != MaxPSNR {
+		t.Errorf("identical frame PSNR = %f, expected %f", report.PerFrame[0].PSNR, MaxPSNR)
+	}
+	if report.Summary.MinPSNRAt != 1 {
+		t.Errorf("worst PSNR frame = %d, expected 1", report.Summary.MinPSNRAt)
+	}
+	for i, m := range report.PerFrame {
+		if m.Frame != i {
+			t.Errorf("PerFrame[%d].Frame = %d", i, m.Frame)
+		}
+	}
+	// Global PSNR comes from the mean MSE ((0+100+25)/3), not from averaging the per-frame dB values
+	if report.Summary.GlobalPSNR >= report.Summary.MeanPSNR {
+		t.Errorf("GlobalPSNR %f should be below the mean PSNR %f inflated by the identical frame",
+			report.Summary.GlobalPSNR, report.Summary.MeanPSNR)
+	}
+}
+
+func TestCompare_Mismatch(t *testing.T) {
+	if _, err := Compare([][][]uint8{createFrame(4, 4, 0)}, nil); err == nil {
+		t.Error("expected error for different frame counts")
+	}
+	if _, err := Compare([][][]uint8{createFrame(4, 4, 0)}, [][][]uint8{createFrame(4, 5, 0)}); err == nil {
+		t.Error("expected error for different frame sizes")
+	}
+	if _, err := CompareFrames(createFrame(4, 4, 0), createFrame(5, 4, 0)); err == nil {
+		t.Error("expected error for different frame sizes")
+	}
+}
+
+func TestReport_WriteCSVAndJSON(t *testing.T) {
+	report, err := Compare([][][]uint8{createPatternFrame(12, 12), createPatternFrame(12, 12)},
+		[][][]uint8{createPatternFrame(12, 12), createFrame(12, 12, 90)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var csvBuffer bytes.Buffer
+	if err := report.WriteCSV(&csvBuffer); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	records, err := csv.NewReader(&csvBuffer).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 3 || strings.Join(records[0], ",") != "frame,psnr,ssim,ms_ssim" {
+		t.Errorf("unexpected CSV: %v", records)
+	}
+
+	var jsonBuffer bytes.Buffer
+	if err := report.WriteJSON(&jsonBuffer); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(jsonBuffer.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded.Summary.Frames != 2 || len(decoded.PerFrame) != 2 {
+		t.Errorf("decoded report = %+v", decoded.Summary)
+	}
+
+	if !strings.Contains(report.Summary.String(), "PSNR") {
+		t.Error("Summary.String() should mention PSNR")
+	}
+}
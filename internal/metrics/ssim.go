@@ -0,0 +1,184 @@
+package metrics
+
+import "math"
+
+// Constantes do SSIM (Wang et al., 2004) para pixels de 8 bits.
+const (
+	ssimWindowSize  = 11
+	ssimWindowSigma = 1.5
+	ssimC1          = (0.01 * maxPixelValue) * (0.01 * maxPixelValue)
+	ssimC2          = (0.03 * maxPixelValue) * (0.03 * maxPixelValue)
+)
+
+// msssimWeights são os pesos de cada escala do MS-SSIM, da mais fina para a mais grossa.
+var msssimWeights = []float64{0.0448, 0.2856, 0.3001, 0.2363, 0.1333}
+
+// plane é um quadro em ponto flutuante, armazenado linha a linha.
+type plane struct {
+	width, height int
+	data          []float64
+}
+
+// newPlane converte um quadro de uint8 em plane.
+func newPlane(frame [][]uint8) plane {
+	p := plane{height: len(frame)}
+	if p.height > 0 {
+		p.width = len(frame[0])
+	}
+	p.data = make([]float64, 0, p.width*p.height)
+	for _, row := range frame {
+		for _, value := range row {
+			p.data = append(p.data, float64(value))
+		}
+	}
+	return p
+}
+
+// multiply retorna o produto pixel a pixel de dois planes.
+func (p plane) multiply(other plane) plane {
+	result := plane{width: p.width, height: p.height, data: make([]float64, len(p.data))}
+	for i, value := range p.data {
+		result.data[i] = value * other.data[i]
+	}
+	return result
+}
+
+// downsample reduz o plane pela metade em cada dimensão, pela média de blocos 2x2.
+func (p plane) downsample() plane {
+	result := plane{width: p.width / 2, height: p.height / 2}
+	result.data = make([]float64, result.width*result.height)
+	for y := 0; y < result.height; y++ {
+		for x := 0; x < result.width; x++ {
+			i := 2*y*p.width + 2*x
+			result.data[y*result.width+x] = (p.data[i] + p.data[i+1] + p.data[i+p.width] + p.data[i+p.width+1]) / 4
+		}
+	}
+	return result
+}
+
+// gaussianKernel retorna um kernel gaussiano 1D normalizado de tamanho size.
+func gaussianKernel(size int, sigma float64) []float64 {
+	kernel := make([]float64, size)
+	center := float64(size-1) / 2
+	var sum float64
+	for i := range kernel {
+		d := float64(i) - center
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	return kernel
+}
+
+// filterValid aplica o kernel separável nas duas direções, mantendo só a região válida
+// (onde a janela cabe inteira), que fica menor em len(kernel)-1 pixels em cada dimensão.
+func (p plane) filterValid(kernel []float64) plane {
+	size := len(kernel)
+	horizontal := plane{width: p.width - size + 1, height: p.height}
+	horizontal.data = make([]float64, horizontal.width*horizontal.height)
+	for y := 0; y < p.height; y++ {
+		row := p.data[y*p.width : (y+1)*p.width]
+		for x := 0; x < horizontal.width; x++ {
+			var sum float64
+			for k, weight := range kernel {
+				sum += weight * row[x+k]
+			}
+			horizontal.data[y*horizontal.width+x] = sum
+		}
+	}
+
+	result := plane{width: horizontal.width, height: p.height - size + 1}
+	result.data = make([]float64, result.width*result.height)
+	for y := 0; y < result.height; y++ {
+		for x := 0; x < result.width; x++ {
+			var sum float64
+			for k, weight := range kernel {
+				sum += weight * horizontal.data[(y+k)*horizontal.width+x]
+			}
+			result.data[y*result.width+x] = sum
+		}
+	}
+	return result
+}
+
+// windowSizeFor retorna o tamanho da janela gaussiana para um plane: 11, ou o maior ímpar que caiba.
+func windowSizeFor(width, height int) int {
+	size := min(ssimWindowSize, width, height)
+	if size%2 == 0 {
+		size--
+	}
+	return size
+}
+
+// ssimComponents calcula o SSIM médio e o termo médio de contraste-estrutura (cs) entre dois planes.
+func ssimComponents(reference, test plane) (ssim, cs float64) {
+	size := windowSizeFor(reference.width, reference.height)
+	if size < 1 {
+		return 1, 1
+	}
+	kernel := gaussianKernel(size, ssimWindowSigma)
+
+	mu1 := reference.filterValid(kernel)
+	mu2 := test.filterValid(kernel)
+	sigma11 := reference.multiply(reference).filterValid(kernel)
+	sigma22 := test.multiply(test).filterValid(kernel)
+	sigma12 := reference.multiply(test).filterValid(kernel)
+
+	var ssimSum, csSum float64
+	for i := range mu1.data {
+		m1, m2 := mu1.data[i], mu2.data[i]
+		variance1 := sigma11.data[i] - m1*m1
+		variance2 := sigma22.data[i] - m2*m2
+		covariance := sigma12.data[i] - m1*m2
+
+		contrastStructure := (2*covariance + ssimC2) / (variance1 + variance2 + ssimC2)
+		luminance := (2*m1*m2 + ssimC1) / (m1*m1 + m2*m2 + ssimC1)
+		ssimSum += luminance * contrastStructure
+		csSum += contrastStructure
+	}
+
+	n := float64(len(mu1.data))
+	return ssimSum / n, csSum / n
+}
+
+// SSIM calcula o índice de similaridade estrutural médio entre dois quadros de mesmas dimensões,
+// com janela gaussiana 11x11 (sigma 1,5). Quadros menores que a janela usam a maior janela que caiba.
+func SSIM(reference, test [][]uint8) float64 {
+	ssim, _ := ssimComponents(newPlane(reference), newPlane(test))
+	return ssim
+}
+
+// MSSSIM calcula o SSIM multiescala entre dois quadros de mesmas dimensões.
+// Usa até cinco escalas, cada uma com metade da resolução da anterior; quadros pequenos usam
+// menos escalas, com os pesos renormalizados.
+func MSSSIM(reference, test [][]uint8) float64 {
+	x, y := newPlane(reference), newPlane(test)
+
+	scales := 1
+	for scales < len(msssimWeights) &&
+		min(x.width, x.height)>>scales >= ssimWindowSize {
+		scales++
+	}
+	weights := msssimWeights[:scales]
+	var weightSum float64
+	for _, w := range weights {
+		weightSum += w
+	}
+
+	result := 1.0
+	for i, w := range weights {
+		ssim, cs := ssimComponents(x, y)
+		value := cs
+		if i == scales-1 {
+			value = ssim
+		}
+		// Valores negativos (estruturas invertidas) não têm potência real; contam como zero.
+		result *= math.Pow(math.Max(value, 0), w/weightSum)
+		if i < scales-1 {
+			x, y = x.downsample(), y.downsample()
+		}
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSSIM_Identical(t *testing.T) {
+	frame := createPatternFrame(32, 40)
+	if result := SSIM(frame, frame); math.Abs(result-1) > 1e-9 {
+		t.Errorf("SSIM of identical frames = %f, expected 1", result)
+	}
+	if result := MSSSIM(frame, frame); math.Abs(result-1) > 1e-9 {
+		t.Errorf("MSSSIM of identical frames = %f, expected 1", result)
+	}
+}
+
+func TestSSIM_DecreasesWithDistortion(t *testing.T) {
+	reference := createPatternFrame(64, 64)
+	slightly := make([][]uint8, len(reference))
+	heavily := make([][]uint8, len(reference))
+	for y := range reference {
+		slightly[y] = make([]uint8, len(reference[y]))
+		heavily[y] = make([]uint8, len(reference[y]))
+		for x, v := range reference[y] {
+			noise := (x*31 + y*17) % 11
+			slightly[y][x] = uint8(min(255, int(v)+noise/4))
+			heavily[y][x] = uint8(min(255, int(v)+noise*6))
+		}
+	}
+
+	slightSSIM, heavySSIM := SSIM(reference, slightly), SSIM(reference, heavily)
+	if !(heavySSIM < slightSSIM && slightSSIM < 1) {
+		t.Errorf("SSIM should decrease with distortion: slight=%f heavy=%f", slightSSIM, heavySSIM)
+	}
+
+	slightMS, heavyMS := MSSSIM(reference, slightly), MSSSIM(reference, heavily)
+	if !(heavyMS < slightMS && slightMS < 1) {
+		t.Errorf("MSSSIM should decrease with distortion: slight=%f heavy=%f", slightMS, heavyMS)
+	}
+}
+
+func TestSSIM_SmallFrames(t *testing.T) {
+	// Frames smaller than the 11x11 window must not panic
+	for _, size := range []int{1, 2, 5, 10} {
+		frame := createPatternFrame(size, size)
+		if result := SSIM(frame, frame); math.IsNaN(result) {
+			t.Errorf("SSIM on %dx%d frame returned NaN", size, size)
+		}
+		if result := MSSSIM(frame, frame); math.IsNaN(result) {
+			t.Errorf("MSSSIM on %dx%d frame returned NaN", size, size)
+		}
+	}
+}
+
+func TestGaussianKernel(t *testing.T) {
+	kernel := gaussianKernel(ssimWindowSize, ssimWindowSigma)
+	var sum float64
+	for i, w := range kernel {
+		sum += w
+		if math.Abs(w-kernel[len(kernel)-1-i]) > 1e-12 {
+			t.Errorf("kernel is not symmetric at %d", i)
+		}
+	}
+	if math.Abs(sum-1) > 1e-12 {
+		t.Errorf("kernel sum = %f, expected 1", sum)
+	}
+}
+
+func BenchmarkSSIM(b *testing.B) {
+	reference := createPatternFrame(240, 320)
+	test := createFrame(240, 320, 128)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		SSIM(reference, test)
+	}
+}
+
+func BenchmarkMSSSIM(b *testing.B) {
+	reference := createPatternFrame(240, 320)
+	test := createFrame(240, 320, 128)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		MSSSIM(reference, test)
+	}
+}
@@ -0,0 +1,73 @@
+package monitor
+
+import (
+	"runtime"
+	"time"
+
+	"video-processor/internal"
+)
+
+// PipelineMetrics implementa internal.PipelineObserver com métricas do Prometheus:
+//
+//	video_processor_frames_processed_total{stage}        quadros concluídos por estágio
+//	video_processor_stage_frame_seconds{stage}           histograma do tempo por quadro em cada estágio
+//	video_processor_stage_queue_depth{stage}             quadros esperando para entrar no estágio
+//	video_processor_frame_buffer_bytes                   bytes de quadros do pool mantidos pelos pipelines em execução
+//	video_processor_temporal_decisions_total{stage,decision} pixels decididos por cada ramo do TimeTravaler
+//	video_processor_heap_inuse_bytes                     memória do heap em uso pelo processo
+type PipelineMetrics struct {
+	frames    *CounterVec
+	latency   *HistogramVec
+	queue     *GaugeVec
+	buffers   *Gauge
+	decisions *CounterVec
+}
+
+// NewPipelineMetrics registra as métricas do pipeline no registro.
+func NewPipelineMetrics(r *Registry) *PipelineMetrics {
+	m := &PipelineMetrics{
+		frames: r.NewCounterVec("video_processor_frames_processed_total",
+			"Quadros que terminaram cada estágio.", "stage"),
+		latency: r.NewHistogramVec("video_processor_stage_frame_seconds",
+			"Tempo gasto em cada quadro, por estágio.", DefaultLatencyBuckets, "stage"),
+		queue: r.NewGaugeVec("video_processor_stage_queue_depth",
+			"Quadros esperando para entrar em cada estágio.", "stage"),
+		buffers: r.NewGaugeVec("video_processor_frame_buffer_bytes",
+			"Bytes de quadros do pool mantidos pelos pipelines em execução.").With(),
+		decisions: r.NewCounterVec("video_processor_temporal_decisions_total",
+			"Pixels decididos por cada ramo do TimeTravaler.", "stage", "decision"),
+	}
+
+	heap := r.NewGaugeVec("video_processor_heap_inuse_bytes", "Memória do heap em uso pelo processo.").With()
+	r.OnCollect(func() {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		heap.Set(float64(stats.HeapInuse))
+	})
+	return m
+}
+
+// FrameDone conta o quadro e registra o tempo gasto nele.
+func (m *PipelineMetrics) FrameDone(stage string, elapsed time.Duration) {
+	m.frames.With(stage).Inc()
+	m.latency.With(stage).Observe(elapsed.Seconds())
+}
+
+// QueueDepth atualiza a fila do estágio.
+func (m *PipelineMetrics) QueueDepth(stage string, depth int) {
+	m.queue.With(stage).Set(float64(depth))
+}
+
+// Decisions soma as decisões de um quadro. Pixels sem decisão (quadros sem histórico) não são contados.
+func (m *PipelineMetrics) Decisions(stage string, counts internal.DecisionCounts) {
+	for d, count := range counts {
+		if decision := internal.TemporalDecision(d); decision != internal.DecisionSkipped && count > 0 {
+			m.decisions.With(stage, decision.String()).Add(float64(count))
+		}
+	}
+}
+
+// FrameBuffers atualiza os bytes de quadros do pool em uso.
+func (m *PipelineMetrics) FrameBuffers(delta int64) {
+	m.buffers.Add(float64(delta))
+}
@@ -0,0 +1,79 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+
+	"video-processor/internal"
+)
+
+// Helper function to create a static video with a noisy pixel in every frame
+func createNoisyVideo(frames int) internal.VideoFrames {
+	video := make(internal.VideoFrames, frames)
+	for i := range video {
+		frame := make(internal.Frame, 12)
+		for y := range frame {
+			frame[y] = make([]uint8, 12)
+			for x := range frame[y] {
+				frame[y][x] = 100
+			}
+		}
+		frame[6][6] = uint8(100 + 10*(i%2))
+		video[i] = frame
+	}
+	return video
+}
+
+func TestPipelineMetrics_ObservesRun(t *testing.T) {
+	r := NewRegistry()
+	m := NewPipelineMetrics(r)
+
+	params := internal.DefaultPipelineParams()
+	params.SpatialPasses, params.TemporalWindow, params.AutoStrength = 1, 3, false
+	pipeline, err := internal.NewPipeline(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline.Observer = m
+
+	video := createNoisyVideo(6)
+	result, err := pipeline.Run(video)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, stage := range []string{internal.StageSpatial, internal.StageTemporal} {
+		if got := m.frames.With(stage).Value(); got != 6 {
+			t.Errorf("frames processed in %s = %v, expected 6", stage, got)
+		}
+		if got := m.queue.With(stage).Value(); got != 0 {
+			t.Errorf("queue depth of %s after the run = %v, expected 0", stage, got)
+		}
+	}
+	if got := m.buffers.Value(); got != 0 {
+		t.Errorf("frame buffer bytes after the run = %v, expected 0", got)
+	}
+
+	var expected, got float64
+	for _, s := range result.Stats {
+		for d, count := range s.Temporal {
+			if internal.TemporalDecision(d) != internal.DecisionSkipped {
+				expected += float64(count)
+			}
+		}
+	}
+	for d := internal.DecisionPassthrough; d <= internal.DecisionAdaptive; d++ {
+		got += m.decisions.With(internal.StageTemporal, d.String()).Value()
+	}
+	if got != expected || expected == 0 {
+		t.Errorf("temporal decisions counted = %v, expected %v (> 0)", got, expected)
+	}
+
+	text := renderText(t, r)
+	for _, name := range []string{"video_processor_stage_frame_seconds_count{stage=\"spatial\"} 6",
+		"video_processor_heap_inuse_bytes"} {
+		if !strings.Contains(text, name) {
+			t.Errorf("missing %q in:\n%s", name, text)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+// Package monitor expõe métricas no formato texto do Prometheus, sem dependências externas:
+// contadores, gauges e histogramas com rótulos, e um http.Handler para /metrics.
+package monitor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry guarda as métricas registradas e as escreve no formato texto do Prometheus.
+type Registry struct {
+	mu        sync.Mutex
+	families  []family
+	names     map[string]bool
+	onCollect []func()
+}
+
+// family é um grupo de séries com o mesmo nome, HELP e TYPE.
+type family interface {
+	header() (name, help, kind string)
+	write(w *bufio.Writer)
+}
+
+// NewRegistry cria um registro vazio.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// register adiciona a família; entra em pânico com nomes repetidos, como um erro de programação.
+func (r *Registry) register(f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name, _, _ := f.header()
+	if r.names[name] {
+		panic("monitor: métrica registrada duas vezes: " + name)
+	}
+	r.names[name] = true
+	r.families = append(r.families, f)
+}
+
+// OnCollect registra uma função chamada antes de cada coleta, para atualizar gauges a partir
+// de um estado externo.
+func (r *Registry) OnCollect(f func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onCollect = append(r.onCollect, f)
+}
+
+// WriteText escreve todas as métricas no formato texto do Prometheus (versão 0.0.4).
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	hooks := append([]func(){}, r.onCollect...)
+	families := append([]family(nil), r.families...)
+	r.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook()
+	}
+	buf := bufio.NewWriter(w)
+	for _, f := range families {
+		name, help, kind := f.header()
+		fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, kind)
+		f.write(buf)
+	}
+	return buf.Flush()
+}
+
+// Handler retorna o handler de /metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteText(w)
+	})
+}
+
+// vec guarda as séries de uma família, indexadas pelos valores dos rótulos.
+type vec[T any] struct {
+	name, help, kind string
+	labels           []string
+	newSeries        func() *T
+
+	mu     sync.Mutex
+	series map[string]*T
+	values map[string][]string
+}
+
+func newVec[T any](name, help, kind string, labels []string, newSeries func() *T) *vec[T] {
+	return &vec[T]{
+		name: name, help: help, kind: kind, labels: labels, newSeries: newSeries,
+		series: make(map[string]*T), values: make(map[string][]string),
+	}
+}
+
+func (v *vec[T]) header() (string, string, string) { return v.name, v.help, v.kind }
+
+// with retorna a série dos valores informados, criando-a na primeira vez.
+func (v *vec[T]) with(values []string) *T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("monitor: %s espera %d rótulos, recebeu %d", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.series[key]
+	if !ok {
+		s = v.newSeries()
+		v.series[key] = s
+		v.values[key] = append([]string(nil), values...)
+	}
+	return s
+}
+
+// each percorre as séries em ordem dos rótulos, para uma saída estável.
+func (v *vec[T]) each(f func(labels string, s *T)) {
+	v.mu.Lock()
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	series := make([]*T, len(keys))
+	labels := make([]string, len(keys))
+	for i, key := range keys {
+		series[i] = v.series[key]
+		labels[i] = formatLabels(v.labels, v.values[key])
+	}
+	v.mu.Unlock()
+
+	for i := range keys {
+		f(labels[i], series[i])
+	}
+}
+
+// Counter é um valor que só cresce.
+type Counter struct{ bits atomic.Uint64 }
+
+// Add soma delta, que deve ser >= 0.
+func (c *Counter) Add(delta float64) { addFloat(&c.bits, delta) }
+
+// Inc soma 1.
+func (c *Counter) Inc() { c.Add(1) }
+
+// Value retorna o valor atual.
+func (c *Counter) Value() float64 { return math.Float64frombits(c.bits.Load()) }
+
+// Gauge é um valor que sobe e desce.
+type Gauge struct{ bits atomic.Uint64 }
+
+// Set define o valor.
+func (g *Gauge) Set(value float64) { g.bits.Store(math.Float64bits(value)) }
+
+// Add soma delta, que pode ser negativo.
+func (g *Gauge) Add(delta float64) { addFloat(&g.bits, delta) }
+
+// Value retorna o valor atual.
+func (g *Gauge) Value() float64 { return math.Float64frombits(g.bits.Load()) }
+
+// addFloat soma delta a um float64 guardado como bits, sem lock.
+func addFloat(bits *atomic.Uint64, delta float64) {
+	for {
+		old := bits.Load()
+		if bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+// CounterVec é uma família de contadores com rótulos.
+type CounterVec struct{ *vec[Counter] }
+
+// NewCounterVec registra uma família de contadores. O nome deve terminar em _total.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{newVec(name, help, "counter", labels, func() *Counter { return &Counter{} })}
+	r.register(v)
+	return v
+}
+
+// With retorna o contador dos valores de rótulos informados, na ordem do registro.
+func (v *CounterVec) With(values ...string) *Counter { return v.with(values) }
+
+func (v *CounterVec) write(w *bufio.Writer) {
+	v.each(func(labels string, c *Counter) {
+		fmt.Fprintf(w, "%s%s %s\n", v.name, labels, formatValue(c.Value()))
+	})
+}
+
+// GaugeVec é uma família de gauges com rótulos.
+type GaugeVec struct{ *vec[Gauge] }
+
+// NewGaugeVec registra uma família de gauges. Sem rótulos, With() retorna o gauge único.
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	v := &GaugeVec{newVec(name, help, "gauge", labels, func() *Gauge { return &Gauge{} })}
+	r.register(v)
+	return v
+}
+
+// With retorna o gauge dos valores de rótulos informados, na ordem do registro.
+func (v *GaugeVec) With(values ...string) *Gauge { return v.with(values) }
+
+func (v *GaugeVec) write(w *bufio.Writer) {
+	v.each(func(labels string, g *Gauge) {
+		fmt.Fprintf(w, "%s%s %s\n", v.name, labels, formatValue(g.Value()))
+	})
+}
+
+// Histogram conta observações em faixas cumulativas.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64 // Limites superiores, em ordem crescente.
+	counts  []uint64  // Observações em cada faixa (não cumulativo); a última é +Inf.
+	sum     float64
+}
+
+// Observe registra uma observação.
+func (h *Histogram) Observe(value float64) {
+	i := sort.SearchFloat64s(h.buckets, value)
+	h.mu.Lock()
+	h.counts[i]++
+	h.sum += value
+	h.mu.Unlock()
+}
+
+// HistogramVec é uma família de histogramas com rótulos.
+type HistogramVec struct {
+	*vec[Histogram]
+	buckets []float64
+}
+
+// DefaultLatencyBuckets são as faixas padrão para latências em segundos, de 1 ms a 10 s.
+var DefaultLatencyBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// NewHistogramVec registra uma família de histogramas com as faixas informadas, em ordem crescente.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	buckets = append([]float64(nil), buckets...)
+	if !sort.Float64sAreSorted(buckets) {
+		panic("monitor: faixas do histograma fora de ordem: " + name)
+	}
+	v := &HistogramVec{buckets: buckets}
+	v.vec = newVec(name, help, "histogram", labels, func() *Histogram {
+		return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets)+1)}
+	})
+	r.register(v)
+	return v
+}
+
+// With retorna o histograma dos valores de rótulos informados, na ordem do registro.
+func (v *HistogramVec) With(values ...string) *Histogram { return v.with(values) }
+
+func (v *HistogramVec) write(w *bufio.Writer) {
+	v.each(func(labels string, h *Histogram) {
+		h.mu.Lock()
+		counts := append([]uint64(nil), h.counts...)
+		sum := h.sum
+		h.mu.Unlock()
+
+		var cumulative uint64
+		for i, count := range counts {
+			cumulative += count
+			le := "+Inf"
+			if i < len(v.buckets) {
+				le = formatValue(v.buckets[i])
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, withLabel(labels, "le", le), cumulative)
+		}
+		fmt.Fprintf(w, "%s_sum%s %s\n", v.name, labels, formatValue(sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", v.name, labels, cumulative)
+	})
+}
+
+// formatLabels monta {a="1",b="2"}, ou vazio sem rótulos.
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// withLabel acrescenta um rótulo a um conjunto já formatado.
+func withLabel(labels, name, value string) string {
+	pair := name + `="` + escapeLabel(value) + `"`
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return labels[:len(labels)-1] + "," + pair + "}"
+}
+
+// formatValue formata um valor como o Prometheus espera.
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
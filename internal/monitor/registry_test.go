@@ -0,0 +1,88 @@
+package monitor
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Helper function to render the registry as text
+func renderText(t *testing.T, r *Registry) string {
+	t.Helper()
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestRegistry_TextFormat(t *testing.T) {
+	r := NewRegistry()
+	frames := r.NewCounterVec("test_frames_total", "Frames.", "stage")
+	frames.With("temporal").Add(2)
+	frames.With("spatial").Inc()
+	r.NewGaugeVec("test_bytes", "Bytes\nin use.").With().Set(1024)
+
+	expected := `# HELP test_frames_total Frames.
+# TYPE test_frames_total counter
+test_frames_total{stage="spatial"} 1
+test_frames_total{stage="temporal"} 2
+# HELP test_bytes Bytes\nin use.
+# TYPE test_bytes gauge
+test_bytes 1024
+`
+	if got := renderText(t, r); got != expected {
+		t.Errorf("text output:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestRegistry_Histogram(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("test_seconds", "Latency.", []float64{0.1, 1}, "stage").With("spatial")
+	for _, v := range []float64{0.05, 0.1, 0.5, 3} {
+		h.Observe(v)
+	}
+
+	text := renderText(t, r)
+	for _, line := range []string{
+		`test_seconds_bucket{stage="spatial",le="0.1"} 2`,
+		`test_seconds_bucket{stage="spatial",le="1"} 3`,
+		`test_seconds_bucket{stage="spatial",le="+Inf"} 4`,
+		`test_seconds_sum{stage="spatial"} 3.65`,
+		`test_seconds_count{stage="spatial"} 4`,
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("missing line %q in:\n%s", line, text)
+		}
+	}
+}
+
+func TestRegistry_EscapesLabelsAndRunsHooks(t *testing.T) {
+	r := NewRegistry()
+	g := r.NewGaugeVec("test_value", "Value.", "name")
+	collected := 0
+	r.OnCollect(func() {
+		collected++
+		g.With(`a"b\c`).Set(float64(collected))
+	})
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), `test_value{name="a\"b\\c"} 1`) {
+		t.Errorf("unexpected output:\n%s", rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+func TestRegistry_DuplicateNamePanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_total", "A.")
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for a duplicate metric")
+		}
+	}()
+	r.NewGaugeVec("test_total", "B.")
+}
@@ -0,0 +1,220 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+)
+
+// NLMeansParams define os parâmetros do filtro non-local means.
+type NLMeansParams struct {
+	PatchRadius  int     `json:"patch_radius"`  // Raio do patch comparado (patch de (2r+1)x(2r+1) pixels).
+	SearchRadius int     `json:"search_radius"` // Raio da janela de busca por patches semelhantes.
+	H            float64 `json:"h"`             // Intensidade da filtragem: quanto maior, mais patches diferentes contribuem.
+	Fast         bool    `json:"fast"`          // Usa a variante com imagem integral, O(1) por patch em vez de O(patch).
+}
+
+// DefaultNLMeansParams retorna parâmetros adequados para vídeo comprimido com ruído moderado.
+func DefaultNLMeansParams() NLMeansParams {
+	return NLMeansParams{
+		PatchRadius:  1,
+		SearchRadius: 5,
+		H:            10.0,
+		Fast:         true,
+	}
+}
+
+// Validate verifica se os parâmetros estão dentro de intervalos válidos.
+func (p NLMeansParams) Validate() error {
+	if p.PatchRadius < 0 {
+		return fmt.Errorf("raio do patch do non-local means deve ser >= 0, recebido %d", p.PatchRadius)
+	}
+	if p.SearchRadius < 1 {
+		return fmt.Errorf("raio de busca do non-local means deve ser >= 1, recebido %d", p.SearchRadius)
+	}
+	if p.H <= 0 {
+		return fmt.Errorf("parâmetro h do non-local means deve ser > 0, recebido %f", p.H)
+	}
+	return nil
+}
+
+// patchArea retorna o número de pixels de um patch.
+func (p NLMeansParams) patchArea() int {
+	side := 2*p.PatchRadius + 1
+	return side * side
+}
+
+// nlMeansMaxExponent é o expoente a partir do qual o peso de um candidato é desprezível (e^-30 ≈ 1e-13).
+const nlMeansMaxExponent = 30.0
+
+// weight converte a soma das diferenças quadradas entre dois patches no peso do candidato.
+func (p NLMeansParams) weight(distance int64) float64 {
+	exponent := float64(distance) / (p.H * p.H * float64(p.patchArea()))
+	if exponent > nlMeansMaxExponent {
+		return 0
+	}
+	return math.Exp(-exponent)
+}
+
+// clampIndex limita um índice ao intervalo [0, size-1], replicando os pixels da borda.
+func clampIndex(i, size int) int {
+	if i < 0 {
+		return 0
+	}
+	if i >= size {
+		return size - 1
+	}
+	return i
+}
+
+// ApplyNLMeans aplica o filtro non-local means a um quadro e retorna um novo quadro.
+// Cada pixel é substituído pela média dos pixels da janela de busca, ponderada pela
+// semelhança entre o patch em torno de cada candidato e o patch em torno do pixel.
+// O quadro é dividido em blocos processados em paralelo.
+func ApplyNLMeans(frame Frame, params NLMeansParams) Frame {
+	if params.Fast {
+		return ApplyNLMeansFast(frame, params)
+	}
+
+	result := acquireFrameLike(frame)
+	if len(frame) == 0 {
+		return result
+	}
+
+	forEachTile(splitTiles(len(frame), len(frame[0]), defaultTileSize), func(t tile) {
+		for y := t.Y0; y < t.Y1; y++ {
+			for x := t.X0; x < t.X1; x++ {
+				result[y][x] = nlMeansPixel(frame, y, x, params)
+			}
+		}
+	})
+
+	return result
+}
+
+// nlMeansPixel calcula o valor filtrado de um único pixel comparando os patches diretamente.
+func nlMeansPixel(frame Frame, y, x int, params NLMeansParams) uint8 {
+	height, width := len(frame), len(frame[0])
+	search, patch := params.SearchRadius, params.PatchRadius
+
+	var weightedSum, weightTotal float64
+	for dy := -search; dy <= search; dy++ {
+		for dx := -search; dx <= search; dx++ {
+			qy, qx := y+dy, x+dx
+			if qy < 0 || qy >= height || qx < 0 || qx >= width {
+				continue
+			}
+
+			var distance int64
+			for py := -patch; py <= patch; py++ {
+				rowP := frame[clampIndex(y+py, height)]
+				rowQ := frame[clampIndex(qy+py, height)]
+				for px := -patch; px <= patch; px++ {
+					diff := int64(rowP[clampIndex(x+px, width)]) - int64(rowQ[clampIndex(qx+px, width)])
+					distance += diff * diff
+				}
+			}
+
+			w := params.weight(distance)
+			weightedSum += w * float64(frame[qy][qx])
+			weightTotal += w
+		}
+	}
+
+	return roundPixel(weightedSum / weightTotal)
+}
+
+// ApplyNLMeansFast aplica o non-local means usando imagens integrais.
+// Para cada deslocamento da janela de busca, calcula a imagem das diferenças quadradas entre o quadro
+// e sua versão deslocada e a integra; a distância entre dois patches passa a ser a soma de um retângulo,
+// obtida com quatro acessos. O resultado é idêntico ao de ApplyNLMeans com Fast desligado.
+func ApplyNLMeansFast(frame Frame, params NLMeansParams) Frame {
+	result := acquireFrameLike(frame)
+	if len(frame) == 0 {
+		return result
+	}
+
+	candidates := []Frame{frame}
+	forEachTile(splitTiles(len(frame), len(frame[0]), defaultTileSize), func(t tile) {
+		nlMeansFastTile(frame, candidates, result, t, params.PatchRadius, params.SearchRadius, params.weight)
+	})
+
+	return result
+}
+
+// nlMeansFastTile processa um bloco do quadro com a variante de imagem integral.
+// Os patches do quadro são comparados com os patches de cada quadro candidato (o próprio quadro,
+// no caso espacial, ou também os quadros vizinhos no caso espaço-temporal), todos do mesmo tamanho.
+func nlMeansFastTile(frame Frame, candidates []Frame, result Frame, t tile, patch, search int, weight func(int64) float64) {
+	tileHeight, tileWidth := t.Y1-t.Y0, t.X1-t.X0
+	// A região estendida cobre o bloco mais o raio do patch em cada lado.
+	extHeight, extWidth := tileHeight+2*patch, tileWidth+2*patch
+	integral := make([]int64, (extHeight+1)*(extWidth+1))
+
+	weightedSum := make([]float64, tileHeight*tileWidth)
+	weightTotal := make([]float64, tileHeight*tileWidth)
+
+	for _, candidate := range candidates {
+		for dy := -search; dy <= search; dy++ {
+			for dx := -search; dx <= search; dx++ {
+				nlMeansAccumulateOffset(frame, candidate, t, patch, dy, dx, integral, weight, weightedSum, weightTotal)
+			}
+		}
+	}
+
+	for y := t.Y0; y < t.Y1; y++ {
+		for x := t.X0; x < t.X1; x++ {
+			i := (y-t.Y0)*tileWidth + (x - t.X0)
+			result[y][x] = roundPixel(weightedSum[i] / weightTotal[i])
+		}
+	}
+}
+
+// nlMeansAccumulateOffset soma, para cada pixel do bloco, a contribuição do candidato deslocado de (dy, dx)
+// no quadro candidato. A imagem integral é reaproveitada entre deslocamentos para evitar alocações.
+func nlMeansAccumulateOffset(frame, candidate Frame, t tile, patch, dy, dx int, integral []int64,
+	weight func(int64) float64, weightedSum, weightTotal []float64) {
+	height, width := len(frame), len(frame[0])
+	tileWidth := t.X1 - t.X0
+	extHeight, extWidth := t.Y1-t.Y0+2*patch, tileWidth+2*patch
+	stride := extWidth + 1
+
+	// Imagem integral das diferenças quadradas na região estendida.
+	for ey := 0; ey < extHeight; ey++ {
+		fy := t.Y0 - patch + ey
+		rowP := frame[clampIndex(fy, height)]
+		rowQ := candidate[clampIndex(fy+dy, height)]
+		var rowSum int64
+		for ex := 0; ex < extWidth; ex++ {
+			fx := t.X0 - patch + ex
+			diff := int64(rowP[clampIndex(fx, width)]) - int64(rowQ[clampIndex(fx+dx, width)])
+			rowSum += diff * diff
+			integral[(ey+1)*stride+ex+1] = integral[ey*stride+ex+1] + rowSum
+		}
+	}
+
+	for y := t.Y0; y < t.Y1; y++ {
+		qy := y + dy
+		if qy < 0 || qy >= height {
+			continue
+		}
+		// Linha do canto superior esquerdo do patch na região estendida.
+		top := y - t.Y0
+		bottom := top + 2*patch + 1
+		candidateRow := candidate[qy]
+		for x := t.X0; x < t.X1; x++ {
+			qx := x + dx
+			if qx < 0 || qx >= width {
+				continue
+			}
+			left := x - t.X0
+			right := left + 2*patch + 1
+			distance := integral[bottom*stride+right] - integral[top*stride+right] -
+				integral[bottom*stride+left] + integral[top*stride+left]
+
+			w := weight(distance)
+			i := (y-t.Y0)*tileWidth + (x - t.X0)
+			weightedSum[i] += w * float64(candidateRow[qx])
+			weightTotal[i] += w
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Helper function to create a deterministic noisy frame around a constant value
+func createRandomNoiseFrame(height, width int, value uint8, amplitude int, seed int64) Frame {
+	rng := rand.New(rand.NewSource(seed))
+	frame := make(Frame, height)
+	for y := range frame {
+		frame[y] = make([]uint8, width)
+		for x := range frame[y] {
+			v := int(value) + rng.Intn(2*amplitude+1) - amplitude
+			frame[y][x] = uint8(max(0, min(255, v)))
+		}
+	}
+	return frame
+}
+
+func TestNLMeansParams_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  NLMeansParams
+		wantErr bool
+	}{
+		{"defaults", DefaultNLMeansParams(), false},
+		{"negative patch radius", NLMeansParams{PatchRadius: -1, SearchRadius: 3, H: 10}, true},
+		{"zero search radius", NLMeansParams{PatchRadius: 1, SearchRadius: 0, H: 10}, true},
+		{"zero h", NLMeansParams{PatchRadius: 1, SearchRadius: 3, H: 0}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.params.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyNLMeans_FastMatchesReference(t *testing.T) {
+	// Non-square frame larger than one tile so tile borders are exercised
+	frame := createRandomNoiseFrame(70, 90, 120, 20, 1)
+	for y := 20; y < 50; y++ {
+		for x := 30; x < 60; x++ {
+			frame[y][x] = 220
+		}
+	}
+
+	params := NLMeansParams{PatchRadius: 1, SearchRadius: 3, H: 12}
+	params.Fast = false
+	reference := ApplyNLMeans(frame, params)
+	params.Fast = true
+	fast := ApplyNLMeans(frame, params)
+
+	if !reflect.DeepEqual(reference, fast) {
+		t.Error("ApplyNLMeansFast() output differs from the reference implementation")
+	}
+}
+
+func TestApplyNLMeans_ReducesNoise(t *testing.T) {
+	frame := createRandomNoiseFrame(32, 32, 128, 15, 2)
+	result := ApplyNLMeans(frame, DefaultNLMeansParams())
+
+	before := PixelsRadius{Pixels: frame}.CalculateVariance()
+	after := PixelsRadius{Pixels: result}.CalculateVariance()
+	if after >= before/2 {
+		t.Errorf("variance after NL-means = %f, expected well below %f", after, before)
+	}
+}
+
+func TestApplyNLMeans_UniformAndEmpty(t *testing.T) {
+	uniform := createTestFrame(10, 10, 42)
+	if result := ApplyNLMeans(uniform, DefaultNLMeansParams()); !reflect.DeepEqual(result, uniform) {
+		t.Error("uniform frame should be unchanged by NL-means")
+	}
+
+	if result := ApplyNLMeans(Frame{}, DefaultNLMeansParams()); len(result) != 0 {
+		t.Errorf("empty frame returned %d rows", len(result))
+	}
+}
+
+func BenchmarkApplyNLMeans(b *testing.B) {
+	frame := createRandomNoiseFrame(100, 100, 128, 15, 3)
+	params := DefaultNLMeansParams()
+	params.Fast = false
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ApplyNLMeans(frame, params)
+	}
+}
+
+func BenchmarkApplyNLMeansFast(b *testing.B) {
+	frame := createRandomNoiseFrame(100, 100, 128, 15, 3)
+	params := DefaultNLMeansParams()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ApplyNLMeansFast(frame, params)
+	}
+}
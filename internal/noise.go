@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"math"
+	"sort"
+)
+
+// madToSigma converte a mediana dos desvios absolutos no desvio padrão de uma distribuição normal.
+const madToSigma = 1.4826
+
+// noiseKernelNorm é a norma L2 do kernel passa-alta usado pelo estimador:
+//
+//	 1 -2  1
+//	-2  4 -2
+//	 1 -2  1
+//
+// O kernel anula regiões planas e rampas lineares, sobrando basicamente o ruído,
+// cujo desvio padrão fica multiplicado por essa norma.
+const noiseKernelNorm = 6.0
+
+// NoiseEstimate guarda a estimativa de ruído de um vídeo: o sigma de cada quadro e o do clipe inteiro.
+type NoiseEstimate struct {
+	PerFrame []float64 // Desvio padrão estimado do ruído em cada quadro.
+	Clip     float64   // Mediana dos sigmas por quadro, robusta a quadros atípicos (cortes, flashes).
+}
+
+// noiseEdgeFraction é a fração de pixels com maior gradiente descartada pelo estimador.
+// Um limiar relativo (e não DefaultEdgeThreshold) é necessário porque, em vídeo muito ruidoso,
+// o próprio ruído ultrapassa qualquer limiar fixo de borda.
+const noiseEdgeFraction = 0.1
+
+// EstimateNoiseSigma estima o desvio padrão do ruído de um quadro.
+// Aplica um filtro passa-alta 3x3 e calcula a mediana do valor absoluto do resíduo (MAD),
+// descartando os pixels de maior gradiente (Sobel) para que contornos não sejam confundidos com ruído.
+func EstimateNoiseSigma(frame Frame) float64 {
+	if len(frame) < 3 || len(frame[0]) < 3 {
+		return 0
+	}
+
+	height, width := len(frame), len(frame[0])
+	interior := (height - 2) * (width - 2)
+	residuals := make([]int, 0, interior)
+	magnitudes := make([]int, 0, interior)
+	// Resíduos e magnitudes são inteiros limitados, então histogramas dão os percentis sem ordenar.
+	magnitudeHistogram := make([]int, 1443) // sqrt(2)*4*255 < 1443
+	for y := 1; y < height-1; y++ {
+		top, row, bottom := frame[y-1], frame[y], frame[y+1]
+		for x := 1; x < width-1; x++ {
+			residual := int(top[x-1]) - 2*int(top[x]) + int(top[x+1]) -
+				2*int(row[x-1]) + 4*int(row[x]) - 2*int(row[x+1]) +
+				int(bottom[x-1]) - 2*int(bottom[x]) + int(bottom[x+1])
+			if residual < 0 {
+				residual = -residual
+			}
+			magnitude := int(GradientMagnitude(frame, y, x, GradientSobel))
+
+			residuals = append(residuals, residual)
+			magnitudes = append(magnitudes, magnitude)
+			magnitudeHistogram[magnitude]++
+		}
+	}
+
+	edgeThreshold := histogramPercentile(magnitudeHistogram, interior, 1-noiseEdgeFraction)
+
+	residualHistogram := make([]int, 16*255+1)
+	total := 0
+	for i, residual := range residuals {
+		if magnitudes[i] > edgeThreshold {
+			continue
+		}
+		residualHistogram[residual]++
+		total++
+	}
+
+	return madToSigma * float64(histogramPercentile(residualHistogram, total, 0.5)) / noiseKernelNorm
+}
+
+// histogramPercentile retorna o menor valor cujo acumulado do histograma atinge a fração informada do total.
+func histogramPercentile(histogram []int, total int, fraction float64) int {
+	if total == 0 {
+		return 0
+	}
+	target := int(math.Ceil(fraction * float64(total)))
+	seen := 0
+	for value, count := range histogram {
+		seen += count
+		if seen >= target {
+			return value
+		}
+	}
+	return len(histogram) - 1
+}
+
+// EstimateClipNoise estima o ruído de cada quadro e do clipe inteiro.
+func EstimateClipNoise(videoFrames VideoFrames) NoiseEstimate {
+	estimate := NoiseEstimate{PerFrame: make([]float64, len(videoFrames))}
+	if len(videoFrames) == 0 {
+		return estimate
+	}
+
+	for i, frame := range videoFrames {
+		estimate.PerFrame[i] = EstimateNoiseSigma(frame)
+	}
+
+	sorted := make([]float64, len(estimate.PerFrame))
+	copy(sorted, estimate.PerFrame)
+	sort.Float64s(sorted)
+	if n := len(sorted); n%2 == 1 {
+		estimate.Clip = sorted[n/2]
+	} else {
+		estimate.Clip = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+
+	return estimate
+}
+
+// Scale retorna o fator aplicado aos parâmetros dos filtros para o sigma do clipe.
+func (e NoiseEstimate) Scale() float64 {
+	return noiseScale(e.Clip)
+}
+
+// Min retorna o menor sigma por quadro.
+func (e NoiseEstimate) Min() float64 {
+	if len(e.PerFrame) == 0 {
+		return 0
+	}
+	lowest := math.Inf(1)
+	for _, sigma := range e.PerFrame {
+		lowest = math.Min(lowest, sigma)
+	}
+	return lowest
+}
+
+// Max retorna o maior sigma por quadro.
+func (e NoiseEstimate) Max() float64 {
+	highest := 0.0
+	for _, sigma := range e.PerFrame {
+		highest = math.Max(highest, sigma)
+	}
+	return highest
+}
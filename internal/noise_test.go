@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Helper function to add gaussian noise with the given sigma to a copy of a frame
+func addGaussianNoise(frame Frame, sigma float64, seed int64) Frame {
+	rng := rand.New(rand.NewSource(seed))
+	noisy := make(Frame, len(frame))
+	for y := range frame {
+		noisy[y] = make([]uint8, len(frame[y]))
+		for x := range frame[y] {
+			v := float64(frame[y][x]) + rng.NormFloat64()*sigma
+			noisy[y][x] = uint8(math.Max(0, math.Min(255, math.Round(v))))
+		}
+	}
+	return noisy
+}
+
+// Helper function to create a smooth ramp with a hard edge in the middle
+func createRampWithEdgeFrame(height, width int) Frame {
+	frame := make(Frame, height)
+	for y := range frame {
+		frame[y] = make([]uint8, width)
+		for x := range frame[y] {
+			v := 60 + x/2
+			if x >= width/2 {
+				v += 80
+			}
+			frame[y][x] = uint8(v)
+		}
+	}
+	return frame
+}
+
+func TestEstimateNoiseSigma(t *testing.T) {
+	clean := createRampWithEdgeFrame(120, 160)
+
+	if sigma := EstimateNoiseSigma(clean); sigma > 0.5 {
+		t.Errorf("clean frame estimated sigma = %f, expected close to 0", sigma)
+	}
+
+	for _, sigma := range []float64{3, 8, 20} {
+		estimated := EstimateNoiseSigma(addGaussianNoise(clean, sigma, int64(sigma)))
+		if math.Abs(estimated-sigma)/sigma > 0.2 {
+			t.Errorf("sigma %.0f estimated as %f (more than 20%% off)", sigma, estimated)
+		}
+	}
+}
+
+func TestEstimateNoiseSigma_SmallFrames(t *testing.T) {
+	if sigma := EstimateNoiseSigma(Frame{}); sigma != 0 {
+		t.Errorf("empty frame sigma = %f, expected 0", sigma)
+	}
+	if sigma := EstimateNoiseSigma(createTestFrame(2, 10, 100)); sigma != 0 {
+		t.Errorf("2-row frame sigma = %f, expected 0", sigma)
+	}
+}
+
+func TestEstimateClipNoise(t *testing.T) {
+	clean := createRampWithEdgeFrame(60, 80)
+	video := VideoFrames{
+		addGaussianNoise(clean, 5, 1),
+		addGaussianNoise(clean, 5, 2),
+		addGaussianNoise(clean, 40, 3), // outlier frame (e.g. a flash)
+		addGaussianNoise(clean, 5, 4),
+		addGaussianNoise(clean, 5, 5),
+	}
+
+	estimate := EstimateClipNoise(video)
+
+	if len(estimate.PerFrame) != len(video) {
+		t.Fatalf("PerFrame has %d entries, expected %d", len(estimate.PerFrame), len(video))
+	}
+	if math.Abs(estimate.Clip-5) > 1 {
+		t.Errorf("clip sigma = %f, expected about 5 despite the outlier frame", estimate.Clip)
+	}
+	if estimate.Max() < 30 || estimate.Min() > 6 {
+		t.Errorf("Min()/Max() = %f/%f, expected to include the outlier", estimate.Min(), estimate.Max())
+	}
+
+	if empty := EstimateClipNoise(nil); empty.Clip != 0 || len(empty.PerFrame) != 0 {
+		t.Errorf("empty clip estimate = %+v", empty)
+	}
+}
+
+func BenchmarkEstimateNoiseSigma(b *testing.B) {
+	frame := addGaussianNoise(createRampWithEdgeFrame(100, 100), 5, 1)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		EstimateNoiseSigma(frame)
+	}
+}
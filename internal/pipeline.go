@@ -0,0 +1,506 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Nomes dos estágios montados a partir de PipelineParams, usados nos callbacks de progresso
+// e nas estatísticas.
+const (
+	StageSpatial  = "spatial"
+	StageTemporal = "temporal"
+	StageJoint    = "joint"
+)
+
+// PipelineParams define o processamento completo de um vídeo.
+type PipelineParams struct {
+	Spatial        SpatialParams
+	SpatialPasses  int // Quantas vezes o filtro espacial é aplicado a cada frame.
+	Temporal       TemporalParams
+	TemporalWindow int  // Número de frames anteriores usados pelo TimeTravaler.
+	AutoStrength   bool // Estima o ruído do vídeo e escala os parâmetros adaptativo e temporal.
+	// Joint substitui os estágios espacial e temporal pelo denoiser espaço-temporal conjunto.
+	Joint          bool
+	SpatioTemporal SpatioTemporalParams
+}
+
+// DefaultPipelineParams retorna o pipeline usado pela CLI: 10 passadas do filtro adaptativo
+// seguidas do TimeTravaler com janela de 7 frames, com força ajustada pelo ruído estimado.
+func DefaultPipelineParams() PipelineParams {
+	return PipelineParams{
+		Spatial:        DefaultSpatialParams(),
+		SpatialPasses:  10,
+		Temporal:       DefaultTemporalParams(),
+		TemporalWindow: 7,
+		AutoStrength:   true,
+		SpatioTemporal: DefaultSpatioTemporalParams(),
+	}
+}
+
+// Validate verifica os parâmetros dos estágios que serão executados.
+func (p PipelineParams) Validate() error {
+	if p.Joint {
+		return p.SpatioTemporal.Validate()
+	}
+	if p.SpatialPasses < 0 {
+		return fmt.Errorf("número de passadas espaciais deve ser >= 0, recebido %d", p.SpatialPasses)
+	}
+	if p.TemporalWindow < 0 {
+		return fmt.Errorf("janela temporal deve ser >= 0, recebido %d", p.TemporalWindow)
+	}
+	if p.SpatialPasses > 0 {
+		if err := p.Spatial.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.TemporalWindow > 0 {
+		return p.Temporal.Validate()
+	}
+	return nil
+}
+
+// Stages valida os parâmetros e monta os estágios equivalentes: o denoiser conjunto, ou o filtro
+// espacial seguido do TimeTravaler, omitindo os que estiverem desligados.
+func (p PipelineParams) Stages() ([]Stage, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	if p.Joint {
+		return []Stage{{Name: StageJoint, Filter: &SpatioTemporalFilter{Params: p.SpatioTemporal}, Passes: 1}}, nil
+	}
+
+	var stages []Stage
+	if p.SpatialPasses > 0 {
+		spatial, err := p.Spatial.filter()
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, Stage{Name: StageSpatial, Filter: spatial, Passes: p.SpatialPasses})
+	}
+	if p.TemporalWindow > 0 {
+		temporal := &TimeTravalerFilter{Window: p.TemporalWindow, Params: p.Temporal}
+		stages = append(stages, Stage{Name: StageTemporal, Filter: temporal, Passes: 1})
+	}
+	return stages, nil
+}
+
+// Stage é um filtro do pipeline.
+type Stage struct {
+	// Name identifica o estágio em OnFrame e nas estatísticas. Vazio, usa o nome do filtro.
+	Name   string
+	Filter Filter
+	// Passes é quantas vezes um filtro espacial ou pontual é reaplicado a cada quadro.
+	// Filtros temporais sempre rodam uma vez.
+	Passes int
+}
+
+// label retorna o nome do estágio.
+func (s Stage) label() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Filter.Name()
+}
+
+// pooled indica se os quadros produzidos pelo estágio podem voltar ao pool quando não forem mais
+// usados: os dos filtros pontuais e dos que implementam pooledFilter.
+func (s Stage) pooled() bool {
+	_, ok := s.Filter.(pooledFilter)
+	return ok || s.Filter.Kind() == FilterPoint
+}
+
+// validate verifica o estágio e se o filtro implementa a interface do seu tipo.
+func (s Stage) validate() error {
+	if s.Filter == nil {
+		return fmt.Errorf("estágio %q sem filtro", s.Name)
+	}
+	if s.Passes < 1 {
+		return fmt.Errorf("estágio %s: número de passadas deve ser >= 1, recebido %d", s.label(), s.Passes)
+	}
+
+	var ok bool
+	switch s.Filter.Kind() {
+	case FilterSpatial:
+		_, ok = s.Filter.(SpatialFilter)
+	case FilterTemporal:
+		_, ok = s.Filter.(TemporalFilter)
+	case FilterPoint:
+		_, ok = s.Filter.(PointFilter)
+	}
+	if !ok {
+		return fmt.Errorf("estágio %s: filtro %s não implementa a interface de filtro %s",
+			s.label(), s.Filter.Name(), s.Filter.Kind())
+	}
+	return nil
+}
+
+// PipelineResult é o resultado de uma execução do pipeline.
+type PipelineResult struct {
+	Frames VideoFrames
+	Noise  NoiseEstimate // Estimativa de ruído; vazia quando nenhum filtro é ajustado pelo ruído.
+	Stages []Stage       // Estágios efetivamente usados, já escalados pelo ruído.
+	Stats  []FrameStats  // Estatísticas de cada quadro, na ordem dos quadros.
+
+	// pooled indica, por quadro de Frames, se ele saiu do pool e pode ser devolvido por Release.
+	pooled []bool
+}
+
+// Release devolve ao pool os quadros de Frames produzidos pelos filtros embutidos, para que a
+// próxima execução os reaproveite. Depois de Release, Frames não pode mais ser usado.
+func (r *PipelineResult) Release() {
+	for i, frame := range r.Frames {
+		if i < len(r.pooled) && r.pooled[i] {
+			releaseFrame(frame)
+		}
+	}
+	r.Frames, r.pooled = nil, nil
+}
+
+// Pipeline executa uma sequência de filtros sobre um vídeo inteiro em memória. Cada estágio
+// processa todos os quadros antes do próximo começar.
+type Pipeline struct {
+	Stages []Stage
+	// AutoStrength estima o ruído do vídeo e escala os filtros que implementam NoiseScalable.
+	AutoStrength bool
+	// OnFrame, se definido, é chamado sempre que um frame termina um estágio.
+	// Pode ser chamado de várias goroutines ao mesmo tempo.
+	OnFrame func(stage string, frameID int)
+	// Decisions, se definido, recebe o mapa de decisões do TimeTravaler de cada quadro.
+	// É chamado de uma goroutine só, na ordem dos quadros.
+	Decisions DecisionSink
+	// Observer, se definido, recebe medições para monitoramento.
+	Observer PipelineObserver
+	// CPU, se definido, limita quantos quadros são processados ao mesmo tempo, somando todos os
+	// pipelines que compartilham o mesmo orçamento.
+	CPU *CPUBudget
+}
+
+// CPUBudget é um orçamento de CPUs compartilhado entre pipelines: cada quadro em processamento
+// ocupa uma CPU do orçamento.
+type CPUBudget struct {
+	tokens chan struct{}
+}
+
+// NewCPUBudget cria um orçamento de n CPUs; n < 1 equivale a 1.
+func NewCPUBudget(n int) *CPUBudget {
+	return &CPUBudget{tokens: make(chan struct{}, max(n, 1))}
+}
+
+// acquire ocupa uma CPU, esperando se o orçamento estiver esgotado. Num orçamento nil não espera.
+func (b *CPUBudget) acquire(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	select {
+	case b.tokens <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release devolve a CPU ocupada por acquire.
+func (b *CPUBudget) release() {
+	if b != nil {
+		<-b.tokens
+	}
+}
+
+// PipelineObserver recebe medições do pipeline para monitoramento. Os métodos podem ser chamados
+// de várias goroutines ao mesmo tempo e não devem bloquear.
+type PipelineObserver interface {
+	// FrameDone é chamado quando um quadro termina um estágio, com o tempo gasto nele.
+	FrameDone(stage string, elapsed time.Duration)
+	// QueueDepth informa quantos quadros ainda esperam para entrar no estágio.
+	QueueDepth(stage string, depth int)
+	// Decisions recebe as contagens de decisões de um quadro num estágio temporal.
+	Decisions(stage string, counts DecisionCounts)
+	// FrameBuffers soma delta aos bytes dos quadros do pool mantidos pelo pipeline em execução:
+	// positivo quando um filtro pega um quadro do pool, negativo quando o pipeline o devolve ou
+	// o entrega a quem o chamou. Ao fim da execução, a soma dos deltas é zero.
+	FrameBuffers(delta int64)
+}
+
+// NewPipeline valida os parâmetros e cria o pipeline com os estágios equivalentes.
+func NewPipeline(params PipelineParams) (*Pipeline, error) {
+	stages, err := params.Stages()
+	if err != nil {
+		return nil, err
+	}
+	return &Pipeline{Stages: stages, AutoStrength: params.AutoStrength}, nil
+}
+
+// NewPipelineFromStages valida os estágios e cria o pipeline.
+func NewPipelineFromStages(stages []Stage, autoStrength bool) (*Pipeline, error) {
+	for _, stage := range stages {
+		if err := stage.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return &Pipeline{Stages: stages, AutoStrength: autoStrength}, nil
+}
+
+// Run processa os frames e retorna o vídeo filtrado. Os frames de entrada não são modificados.
+func (p *Pipeline) Run(videoFrames VideoFrames) (PipelineResult, error) {
+	return p.RunContext(context.Background(), videoFrames)
+}
+
+// RunContext é o Run que pode ser cancelado; o contexto é verificado antes de cada quadro de cada estágio.
+func (p *Pipeline) RunContext(ctx context.Context, videoFrames VideoFrames) (PipelineResult, error) {
+	var noise NoiseEstimate
+	if p.AutoStrength && len(videoFrames) > 0 && anyNoiseScalable(p.Stages) {
+		noise = EstimateClipNoise(videoFrames)
+	}
+	result := PipelineResult{Noise: noise, Stages: scaledStages(p.Stages, noise)}
+
+	result.Stats = make([]FrameStats, len(videoFrames))
+	for i, frame := range videoFrames {
+		result.Stats[i].Frame = i
+		result.Stats[i].InputMean, result.Stats[i].InputVariance = frameMeanVariance(frame)
+		result.Stats[i].StageTime = make(map[string]time.Duration, len(result.Stages))
+	}
+
+	// Cópia rasa de cada frame: os estágios substituem linhas e frames inteiros, nunca os bytes
+	// das linhas originais, então a entrada permanece intacta.
+	frames := make(VideoFrames, len(videoFrames))
+	for i, frame := range videoFrames {
+		frames[i] = append(Frame(nil), frame...)
+	}
+	meter := newBufferMeter(p.Observer)
+	defer meter.settle()
+
+	// pooled[i] indica se frames[i] saiu do pool e só é referenciado por frames.
+	pooled := make([]bool, len(frames))
+	for _, stage := range result.Stages {
+		if err := stage.validate(); err != nil {
+			return result, err
+		}
+
+		previous := append(VideoFrames(nil), frames...)
+		var err error
+		switch filter := stage.Filter.(type) {
+		case TemporalFilter:
+			err = p.runTemporal(ctx, frames, stage.label(), filter, result.Stats, meter)
+		default:
+			err = p.runFrames(ctx, frames, 0, stage, result.Stats, meter)
+		}
+		if err != nil {
+			return result, err
+		}
+
+		releaseReplaced(previous, pooled, stage, meter)
+	}
+
+	result.Frames, result.pooled = frames, pooled
+	result.finishStats()
+	return result, nil
+}
+
+// anyNoiseScalable indica se algum estágio pode ser ajustado pelo ruído.
+func anyNoiseScalable(stages []Stage) bool {
+	for _, stage := range stages {
+		if _, ok := stage.Filter.(NoiseScalable); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// scaledStages retorna uma cópia dos estágios com os filtros NoiseScalable ajustados ao ruído.
+// Com a estimativa vazia, os estágios são copiados sem mudança.
+func scaledStages(stages []Stage, noise NoiseEstimate) []Stage {
+	scaled := append([]Stage(nil), stages...)
+	if len(noise.PerFrame) == 0 {
+		return scaled
+	}
+	for i, stage := range scaled {
+		if scalable, ok := stage.Filter.(NoiseScalable); ok {
+			scaled[i].Filter = scalable.ScaledForNoise(noise.Clip)
+		}
+	}
+	return scaled
+}
+
+// releaseReplaced devolve ao pool os quadros previous que entraram no estágio, se ele os substituiu
+// por quadros do pool, e atualiza pooled para a saída do estágio. A saída de um estágio do pool não
+// reaproveita linhas da entrada, então os quadros de entrada que saíram do pool não são mais usados.
+// Com outros filtros, não há como saber se a saída aponta para a entrada, e os quadros ficam para o
+// coletor de lixo.
+func releaseReplaced(previous VideoFrames, pooled []bool, stage Stage, meter *bufferMeter) {
+	stagePooled := stage.pooled()
+	for i := range pooled {
+		switch {
+		case stagePooled && pooled[i]:
+			meter.release(previous[i])
+		case pooled[i]:
+			meter.dropped(previous[i])
+		}
+		pooled[i] = stagePooled
+	}
+}
+
+// finishStats preenche as estatísticas dos quadros de saída.
+func (r *PipelineResult) finishStats() {
+	for i, frame := range r.Frames {
+		r.Stats[i].OutputMean, r.Stats[i].OutputVariance = frameMeanVariance(frame)
+	}
+}
+
+// runFrames aplica um filtro espacial ou pontual a cada frame, em paralelo, com um worker por CPU.
+// Filtros que classificam pixels como o adaptativo têm as classificações somadas em stats.
+// first é o número do primeiro quadro de frames no vídeo, usado só nos callbacks. Com filtros do
+// pool, os quadros intermediários entre as passadas voltam ao pool e cada saída é contada em meter.
+// Retorna o erro do contexto se ele for cancelado; os quadros restantes ficam sem filtrar.
+func (p *Pipeline) runFrames(ctx context.Context, frames VideoFrames, first int, stage Stage, stats []FrameStats, meter *bufferMeter) error {
+	var apply func(frameID int, frame Frame) Frame
+	switch filter := stage.Filter.(type) {
+	case adaptiveCounter:
+		apply = func(frameID int, frame Frame) Frame {
+			filtered, counts := filter.applyFrameWithStats(frame)
+			stats[frameID].Adaptive.Add(counts)
+			return filtered
+		}
+	case SpatialFilter:
+		apply = func(_ int, frame Frame) Frame { return filter.ApplyFrame(frame) }
+	case PointFilter:
+		lut := newPointLUT(filter)
+		apply = func(_ int, frame Frame) Frame { return lut.apply(frame) }
+	}
+
+	label, pooled := stage.label(), stage.pooled()
+	var wg sync.WaitGroup
+	frameChan := make(chan int, len(frames))
+	for i := range frames {
+		frameChan <- i
+	}
+	close(frameChan)
+	p.observeQueue(label, len(frameChan))
+
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for frameID := range frameChan {
+				if ctx.Err() != nil {
+					continue
+				}
+				p.observeQueue(label, len(frameChan))
+				if p.CPU.acquire(ctx) != nil {
+					continue
+				}
+				start := time.Now()
+				frame := frames[frameID]
+				for pass := range stage.Passes {
+					filtered := apply(frameID, frame)
+					if pooled {
+						meter.acquired(filtered)
+					}
+					if pass > 0 && pooled {
+						// Resultado da passada anterior, que só esta passada lia.
+						meter.release(frame)
+					}
+					frame = filtered
+				}
+				frames[frameID] = frame
+				elapsed := time.Since(start)
+				p.CPU.release()
+				stats[frameID].StageTime[label] += elapsed
+				p.notify(label, first+frameID, elapsed)
+			}
+		}()
+	}
+
+	wg.Wait()
+	return ctx.Err()
+}
+
+// runTemporal aplica um filtro temporal aos frames, em ordem. Filtros que registram decisões
+// têm as contagens somadas em stats e os mapas enviados para p.Decisions. Saídas do pool são contadas em meter.
+func (p *Pipeline) runTemporal(ctx context.Context, frames VideoFrames, label string, filter TemporalFilter, stats []FrameStats, meter *bufferMeter) error {
+	input := append(VideoFrames(nil), frames...)
+	apply := temporalApplier(filter)
+	_, pooled := filter.(pooledFilter)
+
+	for frameID := range frames {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		p.observeQueue(label, len(frames)-frameID-1)
+		if err := p.CPU.acquire(ctx); err != nil {
+			return err
+		}
+		start := time.Now()
+		var decisions DecisionMap
+		frames[frameID], decisions = apply(input, frames, frameID)
+		elapsed := time.Since(start)
+		p.CPU.release()
+		if pooled {
+			meter.acquired(frames[frameID])
+		}
+
+		if err := p.temporalDone(label, frameID, elapsed, decisions, &stats[frameID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// temporalApplyFunc filtra o quadro frameID de um estágio temporal e retorna as decisões, se houver.
+type temporalApplyFunc func(input, processed VideoFrames, frameID int) (Frame, DecisionMap)
+
+// temporalApplier retorna a função que filtra um quadro de um estágio temporal, com as decisões se o
+// filtro as registra. Filtros sequenciais ganham uma sequência nova, então a função só pode ser usada
+// numa execução do estágio, com os quadros em ordem.
+func temporalApplier(filter TemporalFilter) temporalApplyFunc {
+	if sequential, ok := filter.(sequentialFilter); ok {
+		return sequential.sequence().apply
+	}
+	if recorder, ok := filter.(decisionRecorder); ok {
+		return recorder.applyTemporalWithDecisions
+	}
+	return func(input, processed VideoFrames, frameID int) (Frame, DecisionMap) {
+		return filter.ApplyTemporal(input, processed, frameID), nil
+	}
+}
+
+// temporalDone registra um quadro que terminou um estágio temporal: soma as decisões, se o filtro
+// as registra, em stats e no Observer, envia o mapa para p.Decisions e chama notify.
+func (p *Pipeline) temporalDone(label string, frameID int, elapsed time.Duration, decisions DecisionMap, stats *FrameStats) error {
+	if decisions != nil {
+		counts := decisions.Counts()
+		stats.Temporal.Add(counts)
+		if p.Observer != nil {
+			p.Observer.Decisions(label, counts)
+		}
+		if p.Decisions != nil {
+			if err := p.Decisions.WriteDecisions(frameID, decisions); err != nil {
+				return fmt.Errorf("mapa de decisões do quadro %d: %w", frameID, err)
+			}
+		}
+	}
+	stats.StageTime[label] += elapsed
+	p.notify(label, frameID, elapsed)
+	return nil
+}
+
+// notify chama OnFrame e o Observer, se definidos.
+func (p *Pipeline) notify(stage string, frameID int, elapsed time.Duration) {
+	if p.Observer != nil {
+		p.Observer.FrameDone(stage, elapsed)
+	}
+	if p.OnFrame != nil {
+		p.OnFrame(stage, frameID)
+	}
+}
+
+// observeQueue informa ao Observer, se definido, a fila do estágio.
+func (p *Pipeline) observeQueue(stage string, depth int) {
+	if p.Observer != nil {
+		p.Observer.QueueDepth(stage, depth)
+	}
+}
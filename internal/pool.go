@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Quadros do pool: cada quadro é um bloco contíguo de altura x largura bytes, com as linhas fatiadas
+// dele, o que troca uma alocação por linha por uma por quadro. O pipeline pega quadros com
+// acquireFrame para a saída dos filtros embutidos e devolve com releaseFrame os que não são mais
+// usados, como os resultados intermediários de cada passada; um quadro devolvido não pode mais ser
+// lido nem escrito por ninguém.
+
+// frameSize identifica o pool de um tamanho de quadro.
+type frameSize struct {
+	height, width int
+}
+
+// framePools guarda um *sync.Pool por frameSize. Os pools guardam *Frame, não Frame: colocar um
+// slice num any aloca uma cópia do cabeçalho a cada Put.
+var framePools sync.Map
+
+// frameHolders recicla os *Frame vazios que acquireFrame tira dos pools, para que releaseFrame não
+// aloque um novo a cada quadro devolvido.
+var frameHolders = sync.Pool{New: func() any { return new(Frame) }}
+
+// poolFrames liga o pool; desligado, acquireFrame sempre aloca e releaseFrame não faz nada. Só é
+// alterado pelos benchmarks, para comparar as duas situações.
+var poolFrames = true
+
+// NewFrame cria um quadro zerado de height x width pixels, com as linhas num bloco contíguo.
+func NewFrame(height, width int) Frame {
+	data := make([]uint8, height*width)
+	frame := make(Frame, height)
+	for y := range frame {
+		frame[y] = data[y*width : (y+1)*width : (y+1)*width]
+	}
+	return frame
+}
+
+// framePool retorna o pool dos quadros do tamanho informado.
+func framePool(size frameSize) *sync.Pool {
+	if pool, ok := framePools.Load(size); ok {
+		return pool.(*sync.Pool)
+	}
+	pool, _ := framePools.LoadOrStore(size, &sync.Pool{
+		New: func() any {
+			frame := NewFrame(size.height, size.width)
+			return &frame
+		},
+	})
+	return pool.(*sync.Pool)
+}
+
+// acquireFrame retorna um quadro de height x width pixels do pool. O conteúdo é indefinido: quem
+// pede o quadro precisa escrever todos os pixels.
+func acquireFrame(height, width int) Frame {
+	if !poolFrames || height == 0 || width == 0 {
+		return NewFrame(height, width)
+	}
+	holder := framePool(frameSize{height, width}).Get().(*Frame)
+	frame := *holder
+	*holder = nil
+	frameHolders.Put(holder)
+	return frame
+}
+
+// acquireFrameLike é o acquireFrame com as dimensões do quadro informado.
+func acquireFrameLike(frame Frame) Frame {
+	return acquireFrame(len(frame), frameWidth(frame))
+}
+
+// releaseFrame devolve ao pool um quadro que não é mais usado e cujas linhas não são compartilhadas
+// com nenhum outro quadro. Quadros vazios ou com linhas de larguras diferentes são ignorados.
+func releaseFrame(frame Frame) {
+	if !poolFrames || len(frame) == 0 || len(frame[0]) == 0 {
+		return
+	}
+	width := len(frame[0])
+	for _, row := range frame {
+		if len(row) != width {
+			return
+		}
+	}
+	holder := frameHolders.Get().(*Frame)
+	*holder = frame
+	framePool(frameSize{len(frame), width}).Put(holder)
+}
+
+// bufferMeter conta os bytes dos quadros do pool mantidos por uma execução do pipeline: os que os
+// filtros pegaram com acquireFrame e que o pipeline ainda não devolveu com releaseFrame nem entregou
+// a quem o chamou. Cada variação é somada no Observer com FrameBuffers; um bufferMeter nil não conta.
+type bufferMeter struct {
+	observer PipelineObserver
+	held     atomic.Int64
+}
+
+// newBufferMeter retorna o medidor de uma execução, ou nil sem Observer.
+func newBufferMeter(observer PipelineObserver) *bufferMeter {
+	if observer == nil {
+		return nil
+	}
+	return &bufferMeter{observer: observer}
+}
+
+// acquired conta um quadro que um filtro pegou do pool.
+func (m *bufferMeter) acquired(frame Frame) {
+	m.add(frameBytes(frame))
+}
+
+// release devolve o quadro ao pool e o desconta.
+func (m *bufferMeter) release(frame Frame) {
+	releaseFrame(frame)
+	m.dropped(frame)
+}
+
+// dropped desconta um quadro contado que o pipeline deixa de controlar sem devolvê-lo ao pool,
+// como os que ficam para o coletor de lixo.
+func (m *bufferMeter) dropped(frame Frame) {
+	m.add(-frameBytes(frame))
+}
+
+// settle desconta o que ainda está contado, como os quadros entregues a quem chamou o pipeline.
+func (m *bufferMeter) settle() {
+	if m != nil {
+		m.add(-m.held.Load())
+	}
+}
+
+// add soma delta aos bytes contados e no Observer.
+func (m *bufferMeter) add(delta int64) {
+	if m == nil || delta == 0 {
+		return
+	}
+	m.held.Add(delta)
+	m.observer.FrameBuffers(delta)
+}
+
+// frameBytes retorna o número de pixels do quadro.
+func frameBytes(frame Frame) int64 {
+	var total int64
+	for _, row := range frame {
+		total += int64(len(row))
+	}
+	return total
+}
@@ -0,0 +1,175 @@
+package internal
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+	"video-processor/internal/degradation"
+)
+
+// Helper function to run a test body with the frame pool switched on or off
+func withFramePool(t testing.TB, enabled bool, body func()) {
+	t.Helper()
+	previous := poolFrames
+	poolFrames = enabled
+	defer func() { poolFrames = previous }()
+	body()
+}
+
+func TestNewFrame_RowsDoNotOverlap(t *testing.T) {
+	frame := NewFrame(3, 4)
+	if len(frame) != 3 || len(frame[0]) != 4 {
+		t.Fatalf("got %dx%d frame, expected 3x4", len(frame), len(frame[0]))
+	}
+	frame[0] = append(frame[0], 9)
+	if frame[1][0] != 0 {
+		t.Error("appending to a row overwrote the next one")
+	}
+}
+
+func TestPipeline_PooledMatchesUnpooled(t *testing.T) {
+	video := createNoisyStreamClip(t)
+	pipeline := createStreamPipeline(t, 3)
+
+	var expected PipelineResult
+	withFramePool(t, false, func() {
+		var err error
+		if expected, err = pipeline.Run(video); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// The second run reuses the frames released by the first one.
+	for range 2 {
+		result, err := pipeline.Run(video)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertSameFrames(t, expected.Frames, result.Frames)
+		result.Release()
+	}
+	assertSameFrames(t, createNoisyStreamClip(t), video)
+}
+
+func TestTimeTravalerFilter_SkippedFramesAreCopies(t *testing.T) {
+	video := createNoisyStreamClip(t)
+	filter := &TimeTravalerFilter{Window: 7, Params: DefaultTemporalParams()}
+	got := filter.ApplyTemporal(video, video, 1)
+	assertSameFrame(t, video[1], got)
+	if &got[0][0] == &video[1][0][0] {
+		t.Error("output shares rows with the input, so it cannot go back to the pool")
+	}
+}
+
+// bufferObserver records the frame buffer gauge; the other measurements are ignored
+type bufferObserver struct {
+	mu         sync.Mutex
+	held, peak int64
+}
+
+func (o *bufferObserver) FrameDone(string, time.Duration)  {}
+func (o *bufferObserver) QueueDepth(string, int)           {}
+func (o *bufferObserver) Decisions(string, DecisionCounts) {}
+
+func (o *bufferObserver) FrameBuffers(delta int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.held += delta
+	o.peak = max(o.peak, o.held)
+}
+
+func TestPipeline_FrameBuffersFollowPool(t *testing.T) {
+	video := createNoisyStreamClip(t)
+	videoBytes := int64(len(video) * len(video[0]) * len(video[0][0]))
+
+	observer := &bufferObserver{}
+	pipeline := createStreamPipeline(t, 3)
+	pipeline.Observer = observer
+	result, err := pipeline.Run(video)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.Release()
+	// Every frame holds at least its output while the pipeline runs.
+	if observer.peak < videoBytes || observer.held != 0 {
+		t.Errorf("RunContext: peak %d bytes, %d at the end; expected at least %d and 0", observer.peak, observer.held, videoBytes)
+	}
+
+	observer = &bufferObserver{}
+	pipeline.Observer = observer
+	var state StreamState
+	emit := func(VideoFrames, *StreamState) error { return nil }
+	if _, err := pipeline.RunStream(context.Background(), video, &state, emit); err != nil {
+		t.Fatal(err)
+	}
+	// Blocks of two frames never hold the whole video.
+	if observer.peak == 0 || observer.peak >= videoBytes || observer.held != 0 {
+		t.Errorf("RunStream: peak %d bytes, %d at the end; expected between 0 and %d, and 0", observer.peak, observer.held, videoBytes)
+	}
+}
+
+// Helper function to create a noisy 1080p clip
+func createNoisy1080pClip(b *testing.B, frames int) VideoFrames {
+	b.Helper()
+	clip := make(VideoFrames, frames)
+	for i := range clip {
+		clip[i] = createRampWithEdgeFrame(1080, 1920)
+	}
+	clip, err := degradation.Apply(clip, degradation.Config{Seed: 9, GaussianSigma: 5})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return clip
+}
+
+// Benchmark for allocations and GC pauses of the default pipeline on a 1080p clip, with and without the frame pool
+func BenchmarkPipeline_1080p(b *testing.B) {
+	video := createNoisy1080pClip(b, 5)
+	pipeline, err := NewPipeline(DefaultPipelineParams())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, enabled := range []bool{true, false} {
+		name := "pooled"
+		if !enabled {
+			name = "unpooled"
+		}
+		b.Run(name, func(b *testing.B) {
+			withFramePool(b, enabled, func() {
+				b.ReportAllocs()
+				runtime.GC()
+				var before, after runtime.MemStats
+				runtime.ReadMemStats(&before)
+				b.ResetTimer()
+				for range b.N {
+					result, err := pipeline.Run(video)
+					if err != nil {
+						b.Fatal(err)
+					}
+					result.Release()
+				}
+				b.StopTimer()
+				runtime.ReadMemStats(&after)
+				pause := time.Duration(after.PauseTotalNs - before.PauseTotalNs)
+				b.ReportMetric(float64(pause.Nanoseconds())/float64(b.N), "gc-pause-ns/op")
+				b.ReportMetric(float64(after.NumGC-before.NumGC)/float64(b.N), "gc/op")
+			})
+		})
+	}
+}
+
+func TestFramePool_ReleaseDoesNotAllocate(t *testing.T) {
+	withFramePool(t, true, func() {
+		releaseFrame(acquireFrame(4, 8))
+		allocs := testing.AllocsPerRun(100, func() {
+			releaseFrame(acquireFrame(4, 8))
+		})
+		// A GC between runs may empty the pools; steady state allocates nothing.
+		if allocs >= 1 {
+			t.Errorf("acquire and release allocated %.1f times per frame, expected 0", allocs)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultProgressInterval é o intervalo mínimo entre dois registros de progresso do mesmo estágio.
+const DefaultProgressInterval = 2 * time.Second
+
+// ProgressReporter registra, para cada estágio do pipeline, quantos quadros já foram
+// processados, a taxa em quadros por segundo e o tempo estimado até o fim do estágio.
+// Frame tem a assinatura de Pipeline.OnFrame e pode ser chamado de várias goroutines.
+//
+// Um estágio começa na criação do reporter ou, se outro estágio já concluiu quadros, no último
+// quadro concluído antes do seu primeiro: quando os estágios rodam um depois do outro, é o fim do
+// anterior. Assim o tempo do primeiro quadro de cada estágio entra na taxa.
+type ProgressReporter struct {
+	logger   *slog.Logger
+	total    int
+	interval time.Duration
+	now      func() time.Time
+
+	mu     sync.Mutex
+	stages map[string]*stageProgress
+	last   time.Time // Criação do reporter ou último quadro concluído em qualquer estágio.
+}
+
+// stageProgress é o andamento de um estágio.
+type stageProgress struct {
+	done       int
+	start      time.Time
+	lastReport time.Time
+}
+
+// NewProgressReporter cria um reporter para um vídeo de total quadros. Registros intermediários
+// saem no máximo a cada interval por estágio; o fim de cada estágio é sempre registrado.
+func NewProgressReporter(logger *slog.Logger, total int, interval time.Duration) *ProgressReporter {
+	return &ProgressReporter{
+		logger:   logger,
+		total:    total,
+		interval: interval,
+		now:      time.Now,
+		stages:   make(map[string]*stageProgress),
+		last:     time.Now(),
+	}
+}
+
+// Frame conta um quadro concluído no estágio.
+func (r *ProgressReporter) Frame(stage string, frameID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	progress, ok := r.stages[stage]
+	if !ok {
+		progress = &stageProgress{start: r.last, lastReport: now}
+		r.stages[stage] = progress
+	}
+	progress.done++
+	r.last = now
+
+	elapsed := now.Sub(progress.start)
+	fps := 0.0
+	if elapsed > 0 {
+		fps = float64(progress.done) / elapsed.Seconds()
+	}
+
+	if progress.done >= r.total {
+		r.logger.Info("estágio concluído", "stage", stage, "frames", progress.done,
+			"fps", roundTo(fps, 2), "elapsed", elapsed.Round(time.Millisecond))
+		return
+	}
+	if now.Sub(progress.lastReport) < r.interval {
+		return
+	}
+	progress.lastReport = now
+
+	var eta time.Duration
+	if fps > 0 {
+		eta = time.Duration(float64(r.total-progress.done) / fps * float64(time.Second))
+	}
+	r.logger.Info("progresso", "stage", stage, "frames", progress.done, "total", r.total,
+		"fps", roundTo(fps, 2), "eta", eta.Round(time.Second))
+}
+
+// roundTo arredonda value para a quantidade de casas decimais informada, para registros legíveis.
+func roundTo(value float64, decimals int) float64 {
+	scale := 1.0
+	for range decimals {
+		scale *= 10
+	}
+	return float64(int64(value*scale+0.5)) / scale
+}
@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Helper function to create a reporter with a fake clock that advances one second per frame
+func createTestReporter(total int, interval time.Duration) (*ProgressReporter, *bytes.Buffer) {
+	var output bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&output, nil))
+	reporter := NewProgressReporter(logger, total, interval)
+
+	clock := time.Unix(0, 0)
+	reporter.last = clock
+	reporter.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	return reporter, &output
+}
+
+// Helper function to decode JSON log lines
+func decodeLogLines(t *testing.T, output *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestProgressReporter_ReportsEachStageUntilDone(t *testing.T) {
+	reporter, output := createTestReporter(10, 3*time.Second)
+
+	for i := range 10 {
+		reporter.Frame(StageSpatial, i)
+	}
+	for i := range 10 {
+		reporter.Frame(StageTemporal, i)
+	}
+
+	records := decodeLogLines(t, output)
+	var spatial, done int
+	for _, record := range records {
+		if record["stage"] == StageSpatial && record["msg"] == "progresso" {
+			spatial++
+			if record["total"] != float64(10) {
+				t.Errorf("total = %v, expected 10", record["total"])
+			}
+		}
+		if record["msg"] == "estágio concluído" {
+			done++
+		}
+	}
+
+	// Um segundo por quadro e intervalo de 3s: registros no 4º e no 7º quadro; o 10º é a conclusão.
+	if spatial != 2 {
+		t.Errorf("%d spatial progress records, expected 2", spatial)
+	}
+	if done != 2 {
+		t.Errorf("%d stage completion records, expected 2", done)
+	}
+}
+
+func TestProgressReporter_ETA(t *testing.T) {
+	reporter, output := createTestReporter(5, time.Second)
+	reporter.Frame(StageJoint, 0)
+	reporter.Frame(StageJoint, 1)
+
+	records := decodeLogLines(t, output)
+	if len(records) != 1 {
+		t.Fatalf("%d records, expected 1: %v", len(records), records)
+	}
+	// Dois quadros em dois segundos desde a criação: 1 fps, 3 quadros faltando.
+	if records[0]["fps"] != float64(1) {
+		t.Errorf("fps = %v, expected 1", records[0]["fps"])
+	}
+	if eta, ok := records[0]["eta"].(float64); !ok || time.Duration(eta) != 3*time.Second {
+		t.Errorf("eta = %v, expected 3s", records[0]["eta"])
+	}
+}
+
+func TestProgressReporter_StageStartsAfterPreviousStage(t *testing.T) {
+	reporter, output := createTestReporter(2, time.Hour)
+	for i := range 2 {
+		reporter.Frame(StageSpatial, i)
+	}
+	for i := range 2 {
+		reporter.Frame(StageTemporal, i)
+	}
+
+	records := decodeLogLines(t, output)
+	if len(records) != 2 {
+		t.Fatalf("%d records, expected one completion per stage: %v", len(records), records)
+	}
+	// Cada estágio leva dois segundos, contando o primeiro quadro.
+	for _, record := range records {
+		if elapsed, ok := record["elapsed"].(float64); !ok || time.Duration(elapsed) != 2*time.Second {
+			t.Errorf("stage %v: elapsed = %v, expected 2s", record["stage"], record["elapsed"])
+		}
+	}
+}
+
+func TestProgressReporter_ConcurrentFrames(t *testing.T) {
+	var output bytes.Buffer
+	reporter := NewProgressReporter(slog.New(slog.NewTextHandler(&output, nil)), 100, time.Hour)
+
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 25 {
+				reporter.Frame(StageSpatial, w*25+i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !strings.Contains(output.String(), "frames=100") {
+		t.Errorf("expected completion with 100 frames, got %q", output.String())
+	}
+}
@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"video-processor/internal/degradation"
+	"video-processor/internal/metrics"
+)
+
+// Regenera os clipes de referência e as saídas golden:
+//
+//	go test ./internal -run TestGolden -update
+var updateGolden = flag.Bool("update", false, "regenera os arquivos golden em testdata/regression")
+
+const (
+	regressionDir = "testdata/regression"
+	// goldenMinPSNR é o PSNR mínimo, por frame, entre a saída atual e a golden. Refatorações
+	// que só mudam arredondamentos passam; mudanças de decisão dos filtros não.
+	goldenMinPSNR = 50.0
+	goldenFPS     = 24.0
+)
+
+// goldenClip descreve um clipe de referência e como ele foi gerado.
+type goldenClip struct {
+	name     string
+	generate func() (VideoFrames, error)
+}
+
+// goldenConfig descreve uma configuração do pipeline verificada em todos os clipes.
+type goldenConfig struct {
+	name   string
+	params func() PipelineParams
+}
+
+var goldenClips = []goldenClip{
+	{"ramp-noise", func() (VideoFrames, error) {
+		return degradation.Apply(createCleanClip(10, 32, 40), degradation.Config{
+			Seed: 11, GaussianSigma: 6, SaltPepperDensity: 0.01,
+		})
+	}},
+	{"moving-flare", func() (VideoFrames, error) {
+		return degradation.Apply(createMovingSquareClip(10, 32, 40), degradation.Config{
+			Seed: 12, GaussianSigma: 4, FlareProbability: 0.3, FlareRadius: 6, FlareIntensity: 90,
+			DropoutProbability: 0.2, DropoutSize: 5, DropoutDarkness: 0.8, FlickerAmplitude: 0.05,
+		})
+	}},
+}
+
+var goldenConfigs = []goldenConfig{
+	{"default", DefaultPipelineParams},
+	{"bilateral", func() PipelineParams {
+		params := DefaultPipelineParams()
+		params.Spatial.Mode = SpatialBilateral
+		params.SpatialPasses = 2
+		return params
+	}},
+	{"nlmeans", func() PipelineParams {
+		params := DefaultPipelineParams()
+		params.Spatial.Mode = SpatialNLMeans
+		params.SpatialPasses = 1
+		return params
+	}},
+	{"joint", func() PipelineParams {
+		params := DefaultPipelineParams()
+		params.Joint = true
+		return params
+	}},
+}
+
+// Helper function to create a clip with a bright square moving one pixel per frame
+func createMovingSquareClip(frames, height, width int) VideoFrames {
+	clip := make(VideoFrames, frames)
+	for i := range clip {
+		frame := createRampWithEdgeFrame(height, width)
+		for y := height / 4; y < height/4+8 && y < height; y++ {
+			for x := 2 + i; x < 10+i && x < width; x++ {
+				frame[y][x] = 220
+			}
+		}
+		clip[i] = frame
+	}
+	return clip
+}
+
+// Helper function to load a reference clip, creating it in update mode
+func loadGoldenClip(t *testing.T, clip goldenClip) VideoFrames {
+	t.Helper()
+	path := filepath.Join(regressionDir, clip.name+".y4m")
+
+	if *updateGolden {
+		frames, err := clip.generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeGoldenFile(t, path, frames)
+	}
+
+	frames, _, err := ReadY4MFile(path)
+	if err != nil {
+		t.Fatalf("clipe de referência ausente (rode com -update): %v", err)
+	}
+	return frames
+}
+
+// Helper function to write a Y4M file, creating the directories as needed
+func writeGoldenFile(t *testing.T, path string, frames VideoFrames) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteY4MFile(path, frames, goldenFPS); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGolden_PipelineOutputIsStable(t *testing.T) {
+	for _, clip := range goldenClips {
+		input := loadGoldenClip(t, clip)
+
+		for _, config := range goldenConfigs {
+			t.Run(clip.name+"/"+config.name, func(t *testing.T) {
+				pipeline, err := NewPipeline(config.params())
+				if err != nil {
+					t.Fatal(err)
+				}
+				result, err := pipeline.Run(input)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				path := filepath.Join(regressionDir, "golden", clip.name+"-"+config.name+".y4m")
+				if *updateGolden {
+					writeGoldenFile(t, path, result.Frames)
+					return
+				}
+
+				golden, _, err := ReadY4MFile(path)
+				if err != nil {
+					t.Fatalf("golden ausente (rode com -update): %v", err)
+				}
+				if len(golden) != len(result.Frames) {
+					t.Fatalf("%d frames, golden has %d", len(result.Frames), len(golden))
+				}
+				for i := range golden {
+					if len(golden[i]) != len(result.Frames[i]) || frameWidth(golden[i]) != frameWidth(result.Frames[i]) {
+						t.Fatalf("frame %d: size differs from golden", i)
+					}
+					if psnr := metrics.PSNR(golden[i], result.Frames[i]); psnr < goldenMinPSNR {
+						t.Errorf("frame %d: PSNR %.2f dB against golden, expected at least %.0f dB", i, psnr, goldenMinPSNR)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestPipeline_DoesNotModifyInput(t *testing.T) {
+	input := createMovingSquareClip(8, 16, 24)
+	original := copyClip(input)
+
+	pipeline, err := NewPipeline(DefaultPipelineParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pipeline.Run(input); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range input {
+		for y := range input[i] {
+			for x := range input[i][y] {
+				if input[i][y][x] != original[i][y][x] {
+					t.Fatalf("input modified at frame %d (%d, %d)", i, y, x)
+				}
+			}
+		}
+	}
+}
+
+func TestPipelineParams_Validate(t *testing.T) {
+	params := DefaultPipelineParams()
+	params.SpatialPasses = -1
+	if _, err := NewPipeline(params); err == nil {
+		t.Error("expected error for negative spatial passes")
+	}
+
+	params = DefaultPipelineParams()
+	params.Joint = true
+	params.SpatioTemporal.H = 0
+	if _, err := NewPipeline(params); err == nil {
+		t.Error("expected error for invalid joint params")
+	}
+}
+
+func TestPipeline_RunContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, config := range goldenConfigs {
+		pipeline, err := NewPipeline(config.params())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pipeline.RunContext(ctx, createMovingSquareClip(4, 8, 8)); err != context.Canceled {
+			t.Errorf("%s: err = %v, expected context.Canceled", config.name, err)
+		}
+	}
+}
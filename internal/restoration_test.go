@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"testing"
+	"video-processor/internal/degradation"
+	"video-processor/internal/metrics"
+)
+
+// Helper function to create a static clean clip with smooth shading and a hard edge
+func createCleanClip(frames, height, width int) VideoFrames {
+	clip := make(VideoFrames, frames)
+	for i := range clip {
+		clip[i] = createRampWithEdgeFrame(height, width)
+	}
+	return clip
+}
+
+// Helper function to deep copy a clip, since TimeTravaler works in place
+func copyClip(clip VideoFrames) VideoFrames {
+	copied := make(VideoFrames, len(clip))
+	for i, frame := range clip {
+		copied[i] = make(Frame, len(frame))
+		for y := range frame {
+			copied[i][y] = append([]uint8(nil), frame[y]...)
+		}
+	}
+	return copied
+}
+
+// Helper function for the mean PSNR of a clip against the ground truth, skipping the first frames
+func clipPSNR(t *testing.T, groundTruth, test VideoFrames, skip int) float64 {
+	t.Helper()
+	report, err := metrics.Compare(groundTruth[skip:], test[skip:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report.Summary.MeanPSNR
+}
+
+func TestRestoration_AdaptiveFilterRemovesSaltAndPepper(t *testing.T) {
+	clean := createCleanClip(3, 48, 64)
+	degraded, err := degradation.Apply(clean, degradation.Config{Seed: 7, SaltPepperDensity: 0.03})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := make(VideoFrames, len(degraded))
+	for i, frame := range degraded {
+		restored[i] = ApplyAdaptiveFilterFrame(frame, 1)
+	}
+
+	before, after := clipPSNR(t, clean, degraded, 0), clipPSNR(t, clean, restored, 0)
+	if after <= before+3 {
+		t.Errorf("PSNR %.2f dB -> %.2f dB, expected at least +3 dB", before, after)
+	}
+}
+
+func TestRestoration_TimeTravalerReducesTemporalNoise(t *testing.T) {
+	const window = 7
+	clean := createCleanClip(16, 32, 48)
+	degraded, err := degradation.Apply(clean, degradation.Config{Seed: 11, GaussianSigma: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := copyClip(degraded)
+	for i := range restored {
+		TimeTravaler(restored, i, window)
+	}
+
+	before, after := clipPSNR(t, clean, degraded, window), clipPSNR(t, clean, restored, window)
+	if after <= before {
+		t.Errorf("PSNR %.2f dB -> %.2f dB, expected an improvement", before, after)
+	}
+}
+
+func TestRestoration_TimeTravalerFixesDarkDropouts(t *testing.T) {
+	const window = 5
+	clean := createCleanClip(12, 32, 48)
+	degraded, err := degradation.Apply(clean, degradation.Config{
+		Seed:               3,
+		DropoutProbability: 0.3,
+		DropoutSize:        12,
+		DropoutDarkness:    0.2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := copyClip(degraded)
+	for i := range restored {
+		TimeTravaler(restored, i, window)
+	}
+
+	before, after := clipPSNR(t, clean, degraded, window), clipPSNR(t, clean, restored, window)
+	if after <= before {
+		t.Errorf("PSNR %.2f dB -> %.2f dB, expected an improvement", before, after)
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+
+	"video-processor/internal"
+)
+
+// maxConfigBytes limita o tamanho do corpo de POST /jobs.
+const maxConfigBytes = 1 << 20
+
+// NewHandler retorna a API HTTP da fila:
+//
+//	POST /jobs                     envia um trabalho (corpo: JobConfig em JSON)
+//	GET  /jobs                     lista os trabalhos
+//	GET  /jobs/{id}                estado e progresso
+//	POST /jobs/{id}/cancel         cancela
+//	GET  /jobs/{id}/result         baixa o vídeo processado
+//	GET  /jobs/{id}/reports/{name} baixa stats.json, stats.csv, decisions.csv ou visualization
+func NewHandler(q *Queue) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs", func(w http.ResponseWriter, r *http.Request) {
+		config, err := internal.DecodeJobConfig(http.MaxBytesReader(w, r.Body, maxConfigBytes))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		job, err := q.Submit(config)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		w.Header().Set("Location", "/jobs/"+job.ID)
+		writeJSON(w, http.StatusCreated, job)
+	})
+	mux.HandleFunc("GET /jobs", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, q.List())
+	})
+	mux.HandleFunc("GET /jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		job, err := q.Get(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	})
+	mux.HandleFunc("POST /jobs/{id}/cancel", func(w http.ResponseWriter, r *http.Request) {
+		job, err := q.Cancel(r.PathValue("id"))
+		switch {
+		case errors.Is(err, ErrNotFound):
+			writeError(w, http.StatusNotFound, err)
+		case errors.Is(err, ErrFinished):
+			writeError(w, http.StatusConflict, err)
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err)
+		default:
+			writeJSON(w, http.StatusAccepted, job)
+		}
+	})
+	mux.HandleFunc("GET /jobs/{id}/result", func(w http.ResponseWriter, r *http.Request) {
+		serveJobFile(w, r, q, "")
+	})
+	mux.HandleFunc("GET /jobs/{id}/reports/{name}", func(w http.ResponseWriter, r *http.Request) {
+		serveJobFile(w, r, q, r.PathValue("name"))
+	})
+	return mux
+}
+
+// serveJobFile envia um arquivo do trabalho como anexo.
+func serveJobFile(w http.ResponseWriter, r *http.Request, q *Queue, name string) {
+	id := r.PathValue("id")
+	path, err := q.File(id, name)
+	if err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+id+"-"+filepath.Base(path)+`"`)
+	http.ServeFile(w, r, path)
+}
+
+// writeJSON responde com o valor em JSON.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		slog.Warn("erro ao escrever a resposta", "err", err)
+	}
+}
+
+// writeError responde com {"error": mensagem}.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
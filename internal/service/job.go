@@ -0,0 +1,99 @@
+// Package service executa trabalhos de redução de ruído enviados por HTTP. Os trabalhos ficam numa
+// fila local persistida em disco, um diretório por trabalho, e sobrevivem a reinícios do servidor:
+// os que estavam em execução voltam para a fila.
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"video-processor/internal"
+)
+
+// State é a situação de um trabalho na fila.
+type State string
+
+const (
+	StateQueued    State = "queued"
+	StateRunning   State = "running"
+	StateDone      State = "done"
+	StateFailed    State = "failed"
+	StateCancelled State = "cancelled"
+)
+
+// Finished indica se o trabalho não vai mais mudar de estado.
+func (s State) Finished() bool {
+	return s == StateDone || s == StateFailed || s == StateCancelled
+}
+
+// Progress conta os quadros já processados, somados em todos os estágios.
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// Job é um trabalho da fila. Config já tem os caminhos de saída e relatórios apontando para
+// o diretório do trabalho.
+type Job struct {
+	ID       string             `json:"id"`
+	State    State              `json:"state"`
+	Config   internal.JobConfig `json:"config"`
+	Error    string             `json:"error,omitempty"`
+	Progress Progress           `json:"progress"`
+	Created  time.Time          `json:"created"`
+	Started  *time.Time         `json:"started,omitempty"`
+	Finished *time.Time         `json:"finished,omitempty"`
+}
+
+// Runner executa um trabalho. progress recebe os quadros processados e o total, somados em todos
+// os estágios, e pode ser chamado de várias goroutines. Quando ctx é cancelado, o Runner deve
+// parar e retornar o erro do contexto.
+type Runner func(ctx context.Context, config internal.JobConfig, progress func(done, total int)) error
+
+// ErrNotFound é retornado para trabalhos inexistentes.
+var ErrNotFound = errors.New("trabalho não encontrado")
+
+// ErrFinished é retornado ao cancelar um trabalho já concluído.
+var ErrFinished = errors.New("trabalho já concluído")
+
+// jobFile é o nome do arquivo com o estado do trabalho, dentro do diretório do trabalho.
+const jobFile = "job.json"
+
+// newJobID gera um identificador ordenável pela data de criação.
+func newJobID(now time.Time) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return now.UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix), nil
+}
+
+// saveJob grava o estado do trabalho de forma atômica: um arquivo temporário renomeado por cima do anterior.
+func saveJob(dir string, job Job) error {
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, jobFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, jobFile))
+}
+
+// loadJob lê o estado gravado por saveJob.
+func loadJob(dir string) (Job, error) {
+	data, err := os.ReadFile(filepath.Join(dir, jobFile))
+	if err != nil {
+		return Job{}, err
+	}
+	var job Job
+	err = json.Unmarshal(data, &job)
+	return job, err
+}
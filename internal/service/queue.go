@@ -0,0 +1,416 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"video-processor/internal"
+)
+
+// Options configura a fila.
+type Options struct {
+	// Workers é quantos trabalhos rodam ao mesmo tempo; 0 equivale a 1.
+	Workers int
+	// InputRoot é o diretório compartilhado de onde as entradas são lidas, obrigatório: caminhos
+	// relativos são resolvidos a partir dele e caminhos que, seguidos os links simbólicos, ficam
+	// fora dele são rejeitados.
+	InputRoot string
+	// Logger recebe os eventos da fila; nil usa slog.Default().
+	Logger *slog.Logger
+}
+
+// Queue é a fila persistente de trabalhos.
+type Queue struct {
+	dir     string
+	run     Runner
+	options Options
+	logger  *slog.Logger
+
+	mu        sync.Mutex
+	jobs      map[string]*Job
+	pending   []string                      // IDs dos trabalhos na fila, em ordem de chegada.
+	cancels   map[string]context.CancelFunc // Trabalhos em execução.
+	cancelled map[string]bool               // Trabalhos em execução com cancelamento pedido.
+	wake      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// Open abre a fila gravada em dir, criando o diretório se necessário. Trabalhos que estavam em
+// execução quando o servidor parou voltam para a fila.
+func Open(dir string, run Runner, options Options) (*Queue, error) {
+	if options.InputRoot == "" {
+		return nil, errors.New("diretório das entradas (InputRoot) não informado")
+	}
+	root, err := filepath.Abs(options.InputRoot)
+	if err != nil {
+		return nil, err
+	}
+	if options.InputRoot, err = filepath.EvalSymlinks(root); err != nil {
+		return nil, fmt.Errorf("diretório das entradas: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if options.Workers < 1 {
+		options.Workers = 1
+	}
+	logger := options.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	q := &Queue{
+		dir:       dir,
+		run:       run,
+		options:   options,
+		logger:    logger,
+		jobs:      make(map[string]*Job),
+		cancels:   make(map[string]context.CancelFunc),
+		cancelled: make(map[string]bool),
+		wake:      make(chan struct{}, 1),
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		job, err := loadJob(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			logger.Warn("trabalho ignorado", "dir", entry.Name(), "err", err)
+			continue
+		}
+		if job.State == StateRunning {
+			job.State, job.Started, job.Progress = StateQueued, nil, Progress{}
+			if err := saveJob(q.jobDir(job.ID), job); err != nil {
+				return nil, err
+			}
+		}
+		q.jobs[job.ID] = &job
+		if job.State == StateQueued {
+			q.pending = append(q.pending, job.ID)
+		}
+	}
+	sort.Slice(q.pending, func(i, j int) bool {
+		return q.jobs[q.pending[i]].Created.Before(q.jobs[q.pending[j]].Created)
+	})
+	return q, nil
+}
+
+// jobDir retorna o diretório do trabalho.
+func (q *Queue) jobDir(id string) string {
+	return filepath.Join(q.dir, id)
+}
+
+// Start inicia os workers. Quando ctx é cancelado, os trabalhos em execução são interrompidos e
+// voltam para a fila; Wait espera os workers terminarem.
+func (q *Queue) Start(ctx context.Context) {
+	for range q.options.Workers {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			q.worker(ctx)
+		}()
+	}
+	q.signal()
+}
+
+// Wait espera os workers terminarem depois do cancelamento do contexto de Start.
+func (q *Queue) Wait() {
+	q.wg.Wait()
+}
+
+// signal acorda um worker ocioso, se houver.
+func (q *Queue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Submit valida a configuração, direciona as saídas e relatórios para o diretório do trabalho
+// e coloca o trabalho na fila. A extensão de output.path, se informada, escolhe o formato da saída.
+func (q *Queue) Submit(config internal.JobConfig) (Job, error) {
+	now := time.Now()
+	id, err := newJobID(now)
+	if err != nil {
+		return Job{}, err
+	}
+	dir := q.jobDir(id)
+
+	if config.Input, err = q.resolveInput(config.Input); err != nil {
+		return Job{}, err
+	}
+	q.redirectOutputs(&config, dir)
+	if err := config.Validate(); err != nil {
+		return Job{}, err
+	}
+	if _, err := os.Stat(config.Input); err != nil {
+		return Job{}, fmt.Errorf("entrada inacessível: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Job{}, err
+	}
+	job := Job{ID: id, State: StateQueued, Config: config, Created: now}
+	if err := saveJob(dir, job); err != nil {
+		return Job{}, err
+	}
+
+	q.mu.Lock()
+	queued := job
+	q.jobs[id] = &queued
+	q.pending = append(q.pending, id)
+	q.mu.Unlock()
+	q.signal()
+
+	q.logger.Info("trabalho na fila", "job", id, "input", config.Input)
+	return job, nil
+}
+
+// resolveInput resolve o caminho de entrada em relação a InputRoot, seguindo os links simbólicos,
+// e rejeita caminhos fora dele. Retorna o caminho resolvido, que é o que o trabalho lê.
+func (q *Queue) resolveInput(input string) (string, error) {
+	if input == "" {
+		return input, nil
+	}
+	root := q.options.InputRoot
+	if !filepath.IsAbs(input) {
+		input = filepath.Join(root, input)
+	}
+	resolved, err := filepath.EvalSymlinks(input)
+	if err != nil {
+		return "", fmt.Errorf("entrada inacessível: %w", err)
+	}
+	if rel, err := filepath.Rel(root, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("entrada %q fora do diretório compartilhado %s", input, root)
+	}
+	return resolved, nil
+}
+
+// Nomes dos arquivos de saída e relatórios dentro do diretório do trabalho.
+const (
+	ReportStatsJSON     = "stats.json"
+	ReportStatsCSV      = "stats.csv"
+	ReportDecisionsCSV  = "decisions.csv"
+	ReportVisualization = "visualization"
+	resultBase          = "result"
+	decisionsDir        = "decisions"
+)
+
+// redirectOutputs aponta a saída e os relatórios para o diretório do trabalho, mantendo a extensão
+// da saída pedida. As estatísticas são sempre gravadas.
+func (q *Queue) redirectOutputs(config *internal.JobConfig, dir string) {
+	ext := filepath.Ext(config.Output.Path)
+	if ext == "" {
+		ext = ".mp4"
+	}
+	config.Output.Path = filepath.Join(dir, resultBase+ext)
+	config.Output.Original = ""
+	config.Reports.StatsJSON = filepath.Join(dir, ReportStatsJSON)
+	config.Reports.StatsCSV = filepath.Join(dir, ReportStatsCSV)
+	if config.Reports.DecisionsDir != "" {
+		config.Reports.DecisionsDir = filepath.Join(dir, decisionsDir)
+	}
+	if mode, err := internal.ParseVisualMode(config.Reports.Visualize); err == nil && mode != internal.VisualNone {
+		config.Reports.VisualizeOutput = filepath.Join(dir, ReportVisualization+ext)
+	}
+}
+
+// Get retorna uma cópia do trabalho.
+func (q *Queue) Get(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return *job, nil
+}
+
+// List retorna cópias de todos os trabalhos, do mais antigo ao mais recente.
+func (q *Queue) List() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]Job, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Created.Before(jobs[j].Created) })
+	return jobs
+}
+
+// Counts retorna quantos trabalhos há em cada estado, incluindo os estados sem trabalhos.
+func (q *Queue) Counts() map[State]int {
+	counts := map[State]int{StateQueued: 0, StateRunning: 0, StateDone: 0, StateFailed: 0, StateCancelled: 0}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, job := range q.jobs {
+		counts[job.State]++
+	}
+	return counts
+}
+
+// Cancel cancela o trabalho. Um trabalho na fila é cancelado na hora; um em execução é
+// interrompido e fica StateCancelled quando o Runner retornar.
+func (q *Queue) Cancel(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	switch job.State {
+	case StateQueued:
+		for i, pendingID := range q.pending {
+			if pendingID == id {
+				q.pending = append(q.pending[:i], q.pending[i+1:]...)
+				break
+			}
+		}
+		now := time.Now()
+		job.State, job.Finished = StateCancelled, &now
+		if err := saveJob(q.jobDir(id), *job); err != nil {
+			return *job, err
+		}
+		q.logger.Info("trabalho cancelado", "job", id)
+	case StateRunning:
+		q.cancelled[id] = true
+		q.cancels[id]()
+	default:
+		return *job, ErrFinished
+	}
+	return *job, nil
+}
+
+// File retorna o caminho de um arquivo do trabalho concluído: o resultado (name vazio) ou um dos relatórios.
+func (q *Queue) File(id, name string) (string, error) {
+	job, err := q.Get(id)
+	if err != nil {
+		return "", err
+	}
+	if job.State != StateDone {
+		return "", fmt.Errorf("trabalho %s está %s", id, job.State)
+	}
+
+	var path string
+	switch name {
+	case "":
+		path = job.Config.Output.Path
+	case ReportStatsJSON:
+		path = job.Config.Reports.StatsJSON
+	case ReportStatsCSV:
+		path = job.Config.Reports.StatsCSV
+	case ReportDecisionsCSV:
+		if job.Config.Reports.DecisionsDir != "" {
+			path = filepath.Join(job.Config.Reports.DecisionsDir, ReportDecisionsCSV)
+		}
+	case ReportVisualization:
+		path = job.Config.Reports.VisualizeOutput
+	}
+	if path == "" {
+		return "", ErrNotFound
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", ErrNotFound
+	}
+	return path, nil
+}
+
+// worker executa os trabalhos da fila, um de cada vez, até ctx ser cancelado.
+func (q *Queue) worker(ctx context.Context) {
+	for {
+		id, jobCtx, ok := q.next(ctx)
+		if !ok {
+			return
+		}
+		job, _ := q.Get(id)
+		q.logger.Info("trabalho iniciado", "job", id)
+		err := q.run(jobCtx, job.Config, func(done, total int) { q.setProgress(id, done, total) })
+		q.finish(ctx, id, err)
+	}
+}
+
+// next espera um trabalho na fila e o marca como em execução.
+func (q *Queue) next(ctx context.Context) (string, context.Context, bool) {
+	for ctx.Err() == nil {
+		q.mu.Lock()
+		if len(q.pending) > 0 {
+			id := q.pending[0]
+			q.pending = q.pending[1:]
+			job := q.jobs[id]
+			now := time.Now()
+			job.State, job.Started, job.Progress = StateRunning, &now, Progress{}
+			if err := saveJob(q.jobDir(id), *job); err != nil {
+				q.logger.Error("erro ao gravar o estado do trabalho", "job", id, "err", err)
+			}
+			jobCtx, cancel := context.WithCancel(ctx)
+			q.cancels[id] = cancel
+			if len(q.pending) > 0 {
+				q.signal()
+			}
+			q.mu.Unlock()
+			return id, jobCtx, true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+		case <-q.wake:
+		}
+	}
+	return "", nil, false
+}
+
+// setProgress atualiza o progresso em memória; ele só é gravado em disco com o estado final.
+func (q *Queue) setProgress(id string, done, total int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if job, ok := q.jobs[id]; ok && done > job.Progress.Done {
+		job.Progress = Progress{Done: done, Total: total}
+	}
+}
+
+// finish registra o resultado do Runner. Trabalhos interrompidos pelo desligamento do servidor
+// voltam para a fila, para serem retomados no próximo Open.
+func (q *Queue) finish(ctx context.Context, id string, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.cancels[id]()
+	delete(q.cancels, id)
+	cancelled := q.cancelled[id]
+	delete(q.cancelled, id)
+
+	job := q.jobs[id]
+	now := time.Now()
+	switch {
+	case err == nil:
+		job.State, job.Finished = StateDone, &now
+		q.logger.Info("trabalho concluído", "job", id, "elapsed", now.Sub(*job.Started))
+	case cancelled:
+		job.State, job.Finished = StateCancelled, &now
+		q.logger.Info("trabalho cancelado", "job", id)
+	case ctx.Err() != nil && errors.Is(err, context.Canceled):
+		job.State, job.Started, job.Progress = StateQueued, nil, Progress{}
+		q.logger.Info("trabalho interrompido, volta para a fila", "job", id)
+	default:
+		job.State, job.Finished, job.Error = StateFailed, &now, err.Error()
+		q.logger.Error("trabalho falhou", "job", id, "err", err)
+	}
+	if err := saveJob(q.jobDir(id), *job); err != nil {
+		q.logger.Error("erro ao gravar o estado do trabalho", "job", id, "err", err)
+	}
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"video-processor/internal"
+)
+
+// Helper function to create an input file under root and return a job config body that reads it
+func createJobBody(t *testing.T, root, filters string) (string, string) {
+	t.Helper()
+	input := filepath.Join(root, "clip.y4m")
+	if err := os.WriteFile(input, []byte("clip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	body := `{"input": "` + input + `", "output": {"path": "out.y4m"}, "filters": ` + filters + `}`
+	return input, body
+}
+
+// Helper function for a runner that writes the output and the stats report
+func writingRunner(ctx context.Context, config internal.JobConfig, progress func(done, total int)) error {
+	progress(1, 2)
+	progress(2, 2)
+	if err := os.WriteFile(config.Output.Path, []byte("video"), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(config.Reports.StatsJSON, []byte(`{"stages": []}`), 0o644)
+}
+
+// Helper function for a runner that fails while writing the output, leaving a truncated file
+func failingWriteRunner(ctx context.Context, config internal.JobConfig, progress func(done, total int)) error {
+	progress(2, 2)
+	if err := os.WriteFile(config.Output.Path, []byte("vi"), 0o644); err != nil {
+		return err
+	}
+	return errors.New("gravando o frame 1: disco cheio")
+}
+
+// Helper function for a runner that blocks until the job is cancelled
+func blockingRunner(started chan<- string) Runner {
+	return func(ctx context.Context, config internal.JobConfig, progress func(done, total int)) error {
+		started <- config.Input
+		<-ctx.Done()
+		return ctx.Err()
+	}
+}
+
+// Helper function to wait until the job reaches the expected state
+func waitForState(t *testing.T, q *Queue, id string, expected State) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := q.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.State == expected {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	job, _ := q.Get(id)
+	t.Fatalf("job %s is %s, expected %s", id, job.State, expected)
+	return job
+}
+
+// Helper function to submit a job through the HTTP API
+func postJob(t *testing.T, server *httptest.Server, body string) (Job, int) {
+	t.Helper()
+	resp, err := http.Post(server.URL+"/jobs", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var job Job
+	json.NewDecoder(resp.Body).Decode(&job)
+	return job, resp.StatusCode
+}
+
+func TestService_SubmitRunAndDownload(t *testing.T) {
+	root := t.TempDir()
+	q, err := Open(t.TempDir(), writingRunner, Options{InputRoot: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Start(ctx)
+	server := httptest.NewServer(NewHandler(q))
+	defer server.Close()
+
+	_, body := createJobBody(t, root, `[{"filter": "adaptive", "passes": 3, "params": {"radius": 2}}]`)
+	job, status := postJob(t, server, body)
+	if status != http.StatusCreated {
+		t.Fatalf("POST /jobs status = %d, expected 201", status)
+	}
+	if filepath.Ext(job.Config.Output.Path) != ".y4m" || !strings.HasPrefix(job.Config.Output.Path, q.jobDir(job.ID)) {
+		t.Errorf("output path %q not redirected to the job directory", job.Config.Output.Path)
+	}
+
+	done := waitForState(t, q, job.ID, StateDone)
+	if done.Progress != (Progress{Done: 2, Total: 2}) {
+		t.Errorf("progress = %+v, expected 2/2", done.Progress)
+	}
+
+	resp, err := http.Get(server.URL + "/jobs/" + job.ID + "/result")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(data) != "video" {
+		t.Errorf("GET result = %d %q", resp.StatusCode, data)
+	}
+
+	resp, err = http.Get(server.URL + "/jobs/" + job.ID + "/reports/" + ReportStatsJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET stats.json status = %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + "/jobs/" + job.ID + "/reports/" + ReportVisualization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET missing report status = %d, expected 404", resp.StatusCode)
+	}
+}
+
+func TestService_WriteFailureFailsTheJob(t *testing.T) {
+	root := t.TempDir()
+	q, err := Open(t.TempDir(), failingWriteRunner, Options{InputRoot: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Start(ctx)
+	server := httptest.NewServer(NewHandler(q))
+	defer server.Close()
+
+	_, body := createJobBody(t, root, `[{"filter": "gamma"}]`)
+	job, status := postJob(t, server, body)
+	if status != http.StatusCreated {
+		t.Fatalf("POST /jobs status = %d, expected 201", status)
+	}
+	failed := waitForState(t, q, job.ID, StateFailed)
+	if !strings.Contains(failed.Error, "disco cheio") {
+		t.Errorf("error = %q, expected the write error", failed.Error)
+	}
+
+	resp, err := http.Get(server.URL + "/jobs/" + job.ID + "/result")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		t.Error("the truncated output of a failed job was offered for download")
+	}
+}
+
+func TestService_RejectsInvalidConfig(t *testing.T) {
+	root := t.TempDir()
+	q, err := Open(t.TempDir(), writingRunner, Options{InputRoot: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(NewHandler(q))
+	defer server.Close()
+
+	for _, filters := range []string{
+		`[{"filter": "sharpen"}]`,
+		`[{"filter": "adaptive", "params": {"radius": 0}}]`,
+		`[{"filter": "adaptive", "params": {"unknown": 1}}]`,
+	} {
+		_, body := createJobBody(t, root, filters)
+		if _, status := postJob(t, server, body); status != http.StatusBadRequest {
+			t.Errorf("filters %s: status = %d, expected 400", filters, status)
+		}
+	}
+	if len(q.List()) != 0 {
+		t.Errorf("rejected jobs were queued: %+v", q.List())
+	}
+}
+
+func TestService_CancelRunningAndQueued(t *testing.T) {
+	root := t.TempDir()
+	started := make(chan string, 2)
+	q, err := Open(t.TempDir(), blockingRunner(started), Options{InputRoot: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Start(ctx)
+
+	_, body := createJobBody(t, root, `[{"filter": "gamma"}]`)
+	config, err := internal.DecodeJobConfig(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	running, err := q.Submit(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	queued, err := q.Submit(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := q.Cancel(queued.ID); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, q, queued.ID, StateCancelled)
+	if _, err := q.Cancel(running.ID); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, q, running.ID, StateCancelled)
+
+	if _, err := q.Cancel(running.ID); err != ErrFinished {
+		t.Errorf("second cancel err = %v, expected ErrFinished", err)
+	}
+	if _, err := q.File(running.ID, ""); err == nil {
+		t.Error("expected no result for a cancelled job")
+	}
+}
+
+func TestService_PersistsQueueAcrossRestarts(t *testing.T) {
+	root := t.TempDir()
+	dir := t.TempDir()
+	started := make(chan string, 1)
+	q, err := Open(dir, blockingRunner(started), Options{InputRoot: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q.Start(ctx)
+
+	_, body := createJobBody(t, root, `[{"filter": "gamma"}]`)
+	config, err := internal.DecodeJobConfig(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	interrupted, err := q.Submit(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	waiting, err := q.Submit(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Desligar o servidor interrompe o trabalho em execução, que volta para a fila.
+	cancel()
+	q.Wait()
+
+	reopened, err := Open(dir, writingRunner, Options{InputRoot: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reopened.pending) != 2 || reopened.pending[0] != interrupted.ID || reopened.pending[1] != waiting.ID {
+		t.Fatalf("pending after restart = %v, expected [%s %s]", reopened.pending, interrupted.ID, waiting.ID)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	reopened.Start(ctx)
+	waitForState(t, reopened, interrupted.ID, StateDone)
+	waitForState(t, reopened, waiting.ID, StateDone)
+}
+
+func TestService_InputRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "clip.y4m"), []byte("clip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outside, _ := createJobBody(t, t.TempDir(), `[]`)
+	if err := os.Symlink(outside, filepath.Join(root, "link.y4m")); err != nil {
+		t.Fatal(err)
+	}
+	q, err := Open(t.TempDir(), writingRunner, Options{InputRoot: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := internal.DefaultJobConfig()
+	config.Filters = []internal.FilterConfig{{Filter: internal.FilterNameGamma}}
+	config.Input = "clip.y4m"
+	job, err := q.Submit(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Config.Input != filepath.Join(q.options.InputRoot, "clip.y4m") {
+		t.Errorf("input = %q, expected it resolved under the root", job.Config.Input)
+	}
+
+	for _, input := range []string{"../clip.y4m", outside, "link.y4m"} {
+		config.Input = input
+		if _, err := q.Submit(config); err == nil {
+			t.Errorf("input %q: expected an error for a path outside the root", input)
+		}
+	}
+
+	if _, err := Open(t.TempDir(), writingRunner, Options{}); err == nil {
+		t.Error("expected Open to require an input root")
+	}
+}
@@ -0,0 +1,106 @@
+package internal
+
+import "fmt"
+
+// SpatialMode identifica qual filtro espacial é aplicado a cada quadro.
+type SpatialMode int
+
+const (
+	// SpatialAdaptive usa ApplyAdaptiveFilter (mediana, média ou mistura suave conforme a região).
+	SpatialAdaptive SpatialMode = iota
+	// SpatialBilateral usa o filtro bilateral, que pondera os vizinhos pela similaridade de intensidade.
+	SpatialBilateral
+	// SpatialNLMeans usa o non-local means, que busca patches semelhantes numa janela maior.
+	SpatialNLMeans
+)
+
+// String retorna o nome do modo.
+func (m SpatialMode) String() string {
+	switch m {
+	case SpatialAdaptive:
+		return "adaptive"
+	case SpatialBilateral:
+		return "bilateral"
+	case SpatialNLMeans:
+		return "nlmeans"
+	default:
+		return fmt.Sprintf("SpatialMode(%d)", int(m))
+	}
+}
+
+// ParseSpatialMode converte um nome de modo ("adaptive", "bilateral", "nlmeans") no SpatialMode correspondente.
+func ParseSpatialMode(name string) (SpatialMode, error) {
+	switch name {
+	case "adaptive":
+		return SpatialAdaptive, nil
+	case "bilateral":
+		return SpatialBilateral, nil
+	case "nlmeans":
+		return SpatialNLMeans, nil
+	default:
+		return 0, fmt.Errorf("modo espacial desconhecido: %q", name)
+	}
+}
+
+// SpatialParams define qual filtro espacial usar e seus parâmetros.
+type SpatialParams struct {
+	Mode      SpatialMode
+	Radius    int // Raio da vizinhança do filtro adaptativo.
+	Adaptive  AdaptiveParams
+	Bilateral BilateralParams
+	NLMeans   NLMeansParams
+}
+
+// DefaultSpatialParams retorna os parâmetros usados até hoje pelo pipeline: filtro adaptativo com raio 1.
+func DefaultSpatialParams() SpatialParams {
+	return SpatialParams{
+		Mode:      SpatialAdaptive,
+		Radius:    1,
+		Adaptive:  DefaultAdaptiveParams(),
+		Bilateral: DefaultBilateralParams(),
+		NLMeans:   DefaultNLMeansParams(),
+	}
+}
+
+// Validate verifica os parâmetros do modo selecionado.
+func (p SpatialParams) Validate() error {
+	switch p.Mode {
+	case SpatialAdaptive:
+		if p.Radius < 1 {
+			return fmt.Errorf("raio do filtro adaptativo deve ser >= 1, recebido %d", p.Radius)
+		}
+		return p.Adaptive.Validate()
+	case SpatialBilateral:
+		return p.Bilateral.Validate()
+	case SpatialNLMeans:
+		return p.NLMeans.Validate()
+	default:
+		return fmt.Errorf("modo espacial desconhecido: %v", p.Mode)
+	}
+}
+
+// NewSpatialFilter valida os parâmetros e retorna uma função que aplica o filtro espacial a um quadro.
+// O estado caro de construir (como as tabelas do filtro bilateral) é criado uma única vez.
+func NewSpatialFilter(params SpatialParams) (func(Frame) Frame, error) {
+	filter, err := params.filter()
+	if err != nil {
+		return nil, err
+	}
+	return filter.ApplyFrame, nil
+}
+
+// filter valida os parâmetros e retorna o filtro do modo selecionado.
+func (p SpatialParams) filter() (SpatialFilter, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch p.Mode {
+	case SpatialBilateral:
+		return NewBilateralFilter(p.Bilateral)
+	case SpatialNLMeans:
+		return &NLMeansFilter{Params: p.NLMeans}, nil
+	default:
+		return &AdaptiveFilter{Radius: p.Radius, Params: p.Adaptive}, nil
+	}
+}
@@ -0,0 +1,78 @@
+package internal
+
+import "fmt"
+
+// SpatioTemporalParams define os parâmetros do denoiser espaço-temporal conjunto (non-local means em vídeo).
+type SpatioTemporalParams struct {
+	PatchRadius    int     `json:"patch_radius"`    // Raio do patch comparado.
+	SearchRadius   int     `json:"search_radius"`   // Raio da janela de busca em cada quadro.
+	TemporalRadius int     `json:"temporal_radius"` // Número de quadros vizinhos pesquisados antes e depois do quadro atual.
+	H              float64 `json:"h"`               // Intensidade da filtragem.
+}
+
+// DefaultSpatioTemporalParams retorna parâmetros adequados para câmera estática: a janela espacial é menor
+// que a do non-local means espacial, pois os melhores candidatos estão na mesma posição dos quadros vizinhos.
+func DefaultSpatioTemporalParams() SpatioTemporalParams {
+	return SpatioTemporalParams{
+		PatchRadius:    1,
+		SearchRadius:   3,
+		TemporalRadius: 2,
+		H:              10.0,
+	}
+}
+
+// Validate verifica se os parâmetros estão dentro de intervalos válidos.
+func (p SpatioTemporalParams) Validate() error {
+	if p.PatchRadius < 0 {
+		return fmt.Errorf("raio do patch espaço-temporal deve ser >= 0, recebido %d", p.PatchRadius)
+	}
+	if p.SearchRadius < 0 {
+		return fmt.Errorf("raio de busca espaço-temporal deve ser >= 0, recebido %d", p.SearchRadius)
+	}
+	if p.TemporalRadius < 0 {
+		return fmt.Errorf("raio temporal deve ser >= 0, recebido %d", p.TemporalRadius)
+	}
+	if p.H <= 0 {
+		return fmt.Errorf("parâmetro h espaço-temporal deve ser > 0, recebido %f", p.H)
+	}
+	return nil
+}
+
+// weight converte a distância entre dois patches no peso do candidato, com a mesma fórmula do
+// non-local means espacial.
+func (p SpatioTemporalParams) weight(distance int64) float64 {
+	return NLMeansParams{PatchRadius: p.PatchRadius, H: p.H}.weight(distance)
+}
+
+// ApplySpatioTemporalNLMeans filtra o quadro currentFrame buscando patches semelhantes no próprio quadro
+// e nos TemporalRadius quadros anteriores e posteriores, agregando todos numa única média ponderada.
+// Substitui os estágios espacial e temporal em sequência: um pixel ruidoso de um quadro é corrigido
+// pelos patches equivalentes dos vizinhos, sem depender de limiares de borda, blur ou ruído.
+// Os quadros de entrada não são modificados; o resultado é um novo quadro.
+func ApplySpatioTemporalNLMeans(videoFrames VideoFrames, currentFrame int, params SpatioTemporalParams) Frame {
+	frame := videoFrames[currentFrame]
+	result := acquireFrameLike(frame)
+	if len(frame) == 0 {
+		return result
+	}
+
+	first := max(0, currentFrame-params.TemporalRadius)
+	last := min(len(videoFrames)-1, currentFrame+params.TemporalRadius)
+	candidates := videoFrames[first : last+1]
+
+	forEachTile(splitTiles(len(frame), len(frame[0]), defaultTileSize), func(t tile) {
+		nlMeansFastTile(frame, candidates, result, t, params.PatchRadius, params.SearchRadius, params.weight)
+	})
+
+	return result
+}
+
+// ApplySpatioTemporalNLMeansVideo aplica o denoiser espaço-temporal a todos os quadros do vídeo.
+// Cada quadro é filtrado a partir dos quadros originais, então o resultado não depende da ordem.
+func ApplySpatioTemporalNLMeansVideo(videoFrames VideoFrames, params SpatioTemporalParams) VideoFrames {
+	result := make(VideoFrames, len(videoFrames))
+	for i := range videoFrames {
+		result[i] = ApplySpatioTemporalNLMeans(videoFrames, i, params)
+	}
+	return result
+}
@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Helper function to create a static scene (same content every frame) with independent noise per frame
+func createStaticNoisyVideo(frames, height, width int, amplitude int) (VideoFrames, Frame) {
+	clean := createPatternFrame(height, width)
+	video := make(VideoFrames, frames)
+	for i := range video {
+		noise := createRandomNoiseFrame(height, width, 128, amplitude, int64(100+i))
+		video[i] = make(Frame, height)
+		for y := range clean {
+			video[i][y] = make([]uint8, width)
+			for x := range clean[y] {
+				v := int(clean[y][x]) + int(noise[y][x]) - 128
+				video[i][y][x] = uint8(max(0, min(255, v)))
+			}
+		}
+	}
+	return video, clean
+}
+
+// Helper function for the mean absolute error between two frames
+func meanAbsoluteError(a, b Frame) float64 {
+	var sum, count float64
+	for y := range a {
+		for x := range a[y] {
+			sum += float64(abs(int(a[y][x]) - int(b[y][x])))
+			count++
+		}
+	}
+	return sum / count
+}
+
+func TestSpatioTemporalParams_Validate(t *testing.T) {
+	if err := DefaultSpatioTemporalParams().Validate(); err != nil {
+		t.Errorf("default params should be valid: %v", err)
+	}
+
+	invalid := []SpatioTemporalParams{
+		{PatchRadius: -1, SearchRadius: 1, TemporalRadius: 1, H: 10},
+		{PatchRadius: 1, SearchRadius: -1, TemporalRadius: 1, H: 10},
+		{PatchRadius: 1, SearchRadius: 1, TemporalRadius: -1, H: 10},
+		{PatchRadius: 1, SearchRadius: 1, TemporalRadius: 1, H: 0},
+	}
+	for _, params := range invalid {
+		if err := params.Validate(); err == nil {
+			t.Errorf("Validate(%+v) should fail", params)
+		}
+	}
+}
+
+func TestApplySpatioTemporalNLMeans_ZeroTemporalRadiusMatchesSpatial(t *testing.T) {
+	video, _ := createStaticNoisyVideo(3, 20, 24, 10)
+	params := SpatioTemporalParams{PatchRadius: 1, SearchRadius: 2, TemporalRadius: 0, H: 10}
+
+	joint := ApplySpatioTemporalNLMeans(video, 1, params)
+	spatial := ApplyNLMeans(video[1], NLMeansParams{PatchRadius: 1, SearchRadius: 2, H: 10, Fast: true})
+
+	if !reflect.DeepEqual(joint, spatial) {
+		t.Error("with TemporalRadius 0 the joint filter should match the spatial NL-means")
+	}
+}
+
+func TestApplySpatioTemporalNLMeans_StaticCamera(t *testing.T) {
+	video, clean := createStaticNoisyVideo(5, 24, 24, 20)
+	current := 2
+
+	spatialOnly := ApplyNLMeans(video[current], NLMeansParams{PatchRadius: 1, SearchRadius: 3, H: 10, Fast: true})
+	joint := ApplySpatioTemporalNLMeans(video, current, DefaultSpatioTemporalParams())
+
+	noisyError := meanAbsoluteError(video[current], clean)
+	spatialError := meanAbsoluteError(spatialOnly, clean)
+	jointError := meanAbsoluteError(joint, clean)
+
+	if jointError >= spatialError || jointError >= noisyError {
+		t.Errorf("joint error %.2f should beat spatial-only %.2f and noisy input %.2f",
+			jointError, spatialError, noisyError)
+	}
+}
+
+func TestApplySpatioTemporalNLMeansVideo(t *testing.T) {
+	video, _ := createStaticNoisyVideo(4, 10, 10, 5)
+	original := make(VideoFrames, len(video))
+	for i := range video {
+		original[i] = make(Frame, len(video[i]))
+		for y := range video[i] {
+			original[i][y] = append([]uint8(nil), video[i][y]...)
+		}
+	}
+
+	result := ApplySpatioTemporalNLMeansVideo(video, DefaultSpatioTemporalParams())
+
+	if len(result) != len(video) {
+		t.Fatalf("returned %d frames, expected %d", len(result), len(video))
+	}
+	if !reflect.DeepEqual(video, original) {
+		t.Error("input frames should not be modified")
+	}
+}
+
+func BenchmarkApplySpatioTemporalNLMeans(b *testing.B) {
+	video, _ := createStaticNoisyVideo(5, 100, 100, 15)
+	params := DefaultSpatioTemporalParams()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ApplySpatioTemporalNLMeans(video, 2, params)
+	}
+}
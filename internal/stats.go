@@ -0,0 +1,237 @@
+package internal
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// AdaptiveClass identifica em qual caso do filtro adaptativo um pixel foi enquadrado.
+type AdaptiveClass uint8
+
+const (
+	// AdaptiveUnfiltered marca pixels sem vizinhos, que não são alterados.
+	AdaptiveUnfiltered AdaptiveClass = iota
+	// AdaptiveEdge marca pixels de borda.
+	AdaptiveEdge
+	// AdaptiveNoise marca pixels de ruído, substituídos pela mediana dos vizinhos.
+	AdaptiveNoise
+	// AdaptiveSmooth marca pixels de regiões de baixa variância.
+	AdaptiveSmooth
+	// AdaptiveMid marca pixels de regiões de variância média.
+	AdaptiveMid
+	// AdaptiveTexture marca pixels de regiões texturizadas.
+	AdaptiveTexture
+)
+
+// String retorna o nome da classe.
+func (c AdaptiveClass) String() string {
+	switch c {
+	case AdaptiveUnfiltered:
+		return "unfiltered"
+	case AdaptiveEdge:
+		return "edge"
+	case AdaptiveNoise:
+		return "noise"
+	case AdaptiveSmooth:
+		return "smooth"
+	case AdaptiveMid:
+		return "mid"
+	case AdaptiveTexture:
+		return "texture"
+	default:
+		return fmt.Sprintf("AdaptiveClass(%d)", int(c))
+	}
+}
+
+// AdaptiveCounts conta os pixels classificados pelo filtro adaptativo.
+type AdaptiveCounts struct {
+	Edge    int `json:"edge"`
+	Noise   int `json:"noise"`
+	Smooth  int `json:"smooth"`
+	Mid     int `json:"mid"`
+	Texture int `json:"texture"`
+}
+
+// add conta um pixel na classe informada; pixels não filtrados não são contados.
+func (c *AdaptiveCounts) add(class AdaptiveClass) {
+	switch class {
+	case AdaptiveEdge:
+		c.Edge++
+	case AdaptiveNoise:
+		c.Noise++
+	case AdaptiveSmooth:
+		c.Smooth++
+	case AdaptiveMid:
+		c.Mid++
+	case AdaptiveTexture:
+		c.Texture++
+	}
+}
+
+// Add soma outra contagem a esta.
+func (c *AdaptiveCounts) Add(other AdaptiveCounts) {
+	c.Edge += other.Edge
+	c.Noise += other.Noise
+	c.Smooth += other.Smooth
+	c.Mid += other.Mid
+	c.Texture += other.Texture
+}
+
+// Add soma outra contagem a esta.
+func (c *DecisionCounts) Add(other DecisionCounts) {
+	for d := range c {
+		c[d] += other[d]
+	}
+}
+
+// MarshalJSON escreve as contagens como um objeto indexado pelo nome de cada decisão.
+func (c DecisionCounts) MarshalJSON() ([]byte, error) {
+	named := make(map[string]int, numDecisions)
+	for d, count := range c {
+		named[TemporalDecision(d).String()] = count
+	}
+	return json.Marshal(named)
+}
+
+// FrameStats reúne as estatísticas de um quadro numa execução do pipeline.
+type FrameStats struct {
+	Frame          int     `json:"frame"`
+	InputMean      float64 `json:"input_mean"`
+	InputVariance  float64 `json:"input_variance"`
+	OutputMean     float64 `json:"output_mean"`
+	OutputVariance float64 `json:"output_variance"`
+	// Adaptive soma as classificações de todas as passadas do filtro adaptativo;
+	// fica zerado com outros filtros espaciais.
+	Adaptive AdaptiveCounts `json:"adaptive"`
+	// Temporal soma as decisões dos estágios TimeTravaler.
+	Temporal DecisionCounts `json:"temporal"`
+	// StageTime é o tempo gasto em cada estágio, indexado pelo nome do estágio.
+	StageTime map[string]time.Duration `json:"stage_ns"`
+}
+
+// StageInfo descreve um estágio executado.
+type StageInfo struct {
+	Name   string `json:"name"`
+	Filter string `json:"filter"`
+	Kind   string `json:"kind"`
+	Passes int    `json:"passes"`
+	Config any    `json:"config"`
+}
+
+// StatsReport é o relatório de estatísticas de uma execução.
+type StatsReport struct {
+	Stages   []StageInfo   `json:"stages"`
+	Noise    NoiseEstimate `json:"noise"`
+	PerFrame []FrameStats  `json:"per_frame"`
+}
+
+// Report monta o relatório de estatísticas do resultado.
+func (r PipelineResult) Report() StatsReport {
+	return StatsReport{Stages: describeStages(r.Stages), Noise: r.Noise, PerFrame: r.Stats}
+}
+
+// describeStages descreve os estágios para relatórios.
+func describeStages(stages []Stage) []StageInfo {
+	infos := make([]StageInfo, len(stages))
+	for i, stage := range stages {
+		infos[i] = StageInfo{
+			Name:   stage.label(),
+			Filter: stage.Filter.Name(),
+			Kind:   stage.Filter.Kind().String(),
+			Passes: stage.Passes,
+			Config: stage.Filter.Config(),
+		}
+	}
+	return infos
+}
+
+// stageNames retorna os nomes distintos dos estágios, na ordem em que aparecem.
+func (r StatsReport) stageNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, stage := range r.Stages {
+		if !seen[stage.Name] {
+			seen[stage.Name] = true
+			names = append(names, stage.Name)
+		}
+	}
+	return names
+}
+
+// WriteCSV escreve as estatísticas por quadro em CSV, com cabeçalho. Os tempos são em milissegundos,
+// numa coluna por estágio.
+func (r StatsReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"frame", "input_mean", "input_variance", "output_mean", "output_variance",
+		"adaptive_edge", "adaptive_noise", "adaptive_smooth", "adaptive_mid", "adaptive_texture",
+	}
+	for d := 0; d < numDecisions; d++ {
+		header = append(header, "temporal_"+TemporalDecision(d).String())
+	}
+	stages := r.stageNames()
+	for _, name := range stages {
+		header = append(header, name+"_ms")
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, s := range r.PerFrame {
+		record := []string{
+			strconv.Itoa(s.Frame),
+			strconv.FormatFloat(s.InputMean, 'f', 4, 64),
+			strconv.FormatFloat(s.InputVariance, 'f', 4, 64),
+			strconv.FormatFloat(s.OutputMean, 'f', 4, 64),
+			strconv.FormatFloat(s.OutputVariance, 'f', 4, 64),
+			strconv.Itoa(s.Adaptive.Edge),
+			strconv.Itoa(s.Adaptive.Noise),
+			strconv.Itoa(s.Adaptive.Smooth),
+			strconv.Itoa(s.Adaptive.Mid),
+			strconv.Itoa(s.Adaptive.Texture),
+		}
+		for _, count := range s.Temporal {
+			record = append(record, strconv.Itoa(count))
+		}
+		for _, name := range stages {
+			d := s.StageTime[name]
+			record = append(record, strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON escreve o relatório completo em JSON indentado.
+func (r StatsReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// frameMeanVariance calcula a média e a variância dos pixels de um quadro.
+func frameMeanVariance(frame Frame) (mean, variance float64) {
+	var sum, sumSq float64
+	count := 0
+	for _, row := range frame {
+		for _, v := range row {
+			val := float64(v)
+			sum += val
+			sumSq += val * val
+		}
+		count += len(row)
+	}
+	if count == 0 {
+		return 0, 0
+	}
+
+	mean = sum / float64(count)
+	return mean, sumSq/float64(count) - mean*mean
+}
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+)
+
+func TestApplyAdaptiveFilterFrameWithStats(t *testing.T) {
+	frame := createRampWithEdgeFrame(12, 16)
+	frame[3][3] = 255 // pixel de sal numa região suave
+
+	result, counts := ApplyAdaptiveFilterFrameWithStats(frame, 1, DefaultAdaptiveParams())
+	expected := ApplyAdaptiveFilterFrame(frame, 1)
+
+	for y := range expected {
+		if !bytes.Equal(result[y], expected[y]) {
+			t.Fatalf("row %d differs from ApplyAdaptiveFilterFrame", y)
+		}
+	}
+
+	total := counts.Edge + counts.Noise + counts.Smooth + counts.Mid + counts.Texture
+	if total != 12*16 {
+		t.Errorf("classified %d pixels, expected %d", total, 12*16)
+	}
+	if counts.Edge == 0 || counts.Smooth == 0 {
+		t.Errorf("counts = %+v, expected edge and smooth pixels", counts)
+	}
+}
+
+func TestPipelineStats(t *testing.T) {
+	input := createMovingSquareClip(8, 16, 24)
+	params := DefaultPipelineParams()
+	params.SpatialPasses = 2
+
+	pipeline, err := NewPipeline(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := pipeline.Run(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Stats) != len(input) {
+		t.Fatalf("%d stats entries, expected %d", len(result.Stats), len(input))
+	}
+	pixels := 16 * 24
+	for i, s := range result.Stats {
+		if s.Frame != i {
+			t.Errorf("stats[%d].Frame = %d", i, s.Frame)
+		}
+		adaptive := s.Adaptive.Edge + s.Adaptive.Noise + s.Adaptive.Smooth + s.Adaptive.Mid + s.Adaptive.Texture
+		if adaptive != params.SpatialPasses*pixels {
+			t.Errorf("frame %d: %d adaptive classifications, expected %d", i, adaptive, params.SpatialPasses*pixels)
+		}
+		temporal := 0
+		for _, count := range s.Temporal {
+			temporal += count
+		}
+		if temporal != pixels {
+			t.Errorf("frame %d: %d temporal decisions, expected %d", i, temporal, pixels)
+		}
+		if s.InputMean == 0 || s.OutputMean == 0 {
+			t.Errorf("frame %d: means not filled: %+v", i, s)
+		}
+	}
+	if result.Stats[0].Temporal[DecisionSkipped] != pixels {
+		t.Error("first frame has no history and should be all skipped")
+	}
+
+	var jsonOut bytes.Buffer
+	if err := result.Report().WriteJSON(&jsonOut); err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		PerFrame []struct {
+			Temporal map[string]int `json:"temporal"`
+		} `json:"per_frame"`
+	}
+	if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.PerFrame[0].Temporal["skipped"] != pixels {
+		t.Errorf("json temporal counts = %v", decoded.PerFrame[0].Temporal)
+	}
+
+	var csvOut bytes.Buffer
+	if err := result.Report().WriteCSV(&csvOut); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&csvOut).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(input)+1 {
+		t.Errorf("csv has %d records, expected %d", len(records), len(input)+1)
+	}
+}
+
+func TestFrameMeanVariance(t *testing.T) {
+	mean, variance := frameMeanVariance(Frame{{0, 10}, {10, 20}})
+	if mean != 10 || variance != 50 {
+		t.Errorf("mean, variance = %f, %f; expected 10, 50", mean, variance)
+	}
+	if mean, variance := frameMeanVariance(Frame{}); mean != 0 || variance != 0 {
+		t.Errorf("empty frame = %f, %f", mean, variance)
+	}
+}
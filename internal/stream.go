@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// StreamState é o estado de uma execução quadro a quadro (Pipeline.RunStream) ao fim de um bloco:
+// tudo o que é preciso para continuá-la depois de uma interrupção.
+type StreamState struct {
+	Next    int           // Primeiro quadro ainda não entregue; os anteriores já passaram por emit.
+	Noise   NoiseEstimate // Estimativa de ruído do vídeo inteiro, calculada no início.
+	Stats   []FrameStats  // Estatísticas dos quadros já entregues.
+	History []VideoFrames // Por estágio, os últimos quadros filtrados dos estágios temporais.
+}
+
+// Streamable verifica se os estágios podem rodar quadro a quadro com RunStream: filtros espaciais,
+// pontuais e temporais causais como o TimeTravaler. Filtros que precisam de quadros futuros, como
+// o espaço-temporal conjunto, só rodam com o vídeo inteiro.
+func (p *Pipeline) Streamable() error {
+	for _, stage := range p.Stages {
+		if err := stage.validate(); err != nil {
+			return err
+		}
+		if _, ok := stage.Filter.(historyFilter); stage.Filter.Kind() == FilterTemporal && !ok {
+			return fmt.Errorf("estágio %s: o filtro %s precisa do vídeo inteiro e não pode rodar quadro a quadro",
+				stage.label(), stage.Filter.Name())
+		}
+	}
+	return nil
+}
+
+// RunStream processa os quadros a partir de state.Next em blocos de quadros consecutivos, cada bloco
+// passando por todos os estágios antes do próximo. Ao fim de cada bloco, state é atualizado e emit
+// recebe os quadros filtrados do bloco, em ordem; se emit retornar erro, o processamento para.
+// Um state guardado durante emit permite retomar a execução com o mesmo resultado de uma execução
+// contínua, igual ao de RunContext. Com state zerado, começa do primeiro quadro.
+//
+// O resultado traz a estimativa de ruído, os estágios e as estatísticas de todos os quadros, mas
+// não os quadros, que só são entregues a emit.
+func (p *Pipeline) RunStream(ctx context.Context, videoFrames VideoFrames, state *StreamState, emit func(frames VideoFrames, state *StreamState) error) (PipelineResult, error) {
+	if err := p.Streamable(); err != nil {
+		return PipelineResult{}, err
+	}
+	switch {
+	case state.Next == 0:
+		*state = StreamState{History: make([]VideoFrames, len(p.Stages))}
+		if p.AutoStrength && len(videoFrames) > 0 && anyNoiseScalable(p.Stages) {
+			state.Noise = EstimateClipNoise(videoFrames)
+		}
+	case state.Next < 0 || state.Next > len(videoFrames):
+		return PipelineResult{}, fmt.Errorf("estado no quadro %d, mas o vídeo tem %d quadros", state.Next, len(videoFrames))
+	case len(state.History) != len(p.Stages) || len(state.Stats) != state.Next:
+		return PipelineResult{}, errors.New("estado gravado com outros estágios")
+	}
+	result := PipelineResult{Noise: state.Noise, Stages: scaledStages(p.Stages, state.Noise)}
+
+	// As sequências dos filtros temporais atravessam os blocos; a primeira chamada monta o estado a
+	// partir do histórico.
+	appliers := make([]temporalApplyFunc, len(result.Stages))
+	for s, stage := range result.Stages {
+		if filter, ok := stage.Filter.(TemporalFilter); ok {
+			appliers[s] = temporalApplier(filter)
+		}
+	}
+
+	// Os quadros de cada bloco deixam de ser contados quando o bloco é entregue a emit; os que ficam
+	// no histórico dos estágios temporais também.
+	meter := newBufferMeter(p.Observer)
+	defer meter.settle()
+
+	size := p.streamBlock()
+	for first := state.Next; first < len(videoFrames); first += size {
+		block := videoFrames[first:min(first+size, len(videoFrames))]
+		frames := make(VideoFrames, len(block))
+		stats := make([]FrameStats, len(block))
+		for i, frame := range block {
+			frames[i] = append(Frame(nil), frame...)
+			stats[i].Frame = first + i
+			stats[i].InputMean, stats[i].InputVariance = frameMeanVariance(frame)
+			stats[i].StageTime = make(map[string]time.Duration, len(result.Stages))
+		}
+
+		// O histórico só é trocado no estado quando o bloco termina, para que um bloco
+		// interrompido não deixe o estado pela metade.
+		history := append([]VideoFrames(nil), state.History...)
+		pooled := make([]bool, len(frames))
+		for s, stage := range result.Stages {
+			previous := append(VideoFrames(nil), frames...)
+			var err error
+			filter, temporal := stage.Filter.(TemporalFilter)
+			if temporal {
+				history[s], err = p.streamTemporal(ctx, frames, first, stage.label(), filter, appliers[s], history[s], stats, meter)
+			} else {
+				err = p.runFrames(ctx, frames, first, stage, stats, meter)
+			}
+			if err != nil {
+				result.Stats = state.Stats
+				return result, err
+			}
+			releaseReplaced(previous, pooled, stage, meter)
+			if temporal {
+				// A saída também fica no histórico, usado pelos próximos blocos.
+				clear(pooled)
+			}
+		}
+
+		for i, frame := range frames {
+			stats[i].OutputMean, stats[i].OutputVariance = frameMeanVariance(frame)
+		}
+		state.Stats = append(state.Stats, stats...)
+		state.History = history
+		state.Next = first + len(frames)
+		meter.settle()
+		if err := emit(frames, state); err != nil {
+			result.Stats = state.Stats
+			return result, err
+		}
+	}
+
+	result.Stats = state.Stats
+	return result, nil
+}
+
+// streamBlock retorna quantos quadros RunStream processa por bloco: um por CPU do orçamento, ou
+// um por CPU da máquina sem orçamento.
+func (p *Pipeline) streamBlock() int {
+	if p.CPU != nil {
+		return cap(p.CPU.tokens)
+	}
+	return runtime.NumCPU()
+}
+
+// streamTemporal aplica um filtro temporal causal aos quadros de um bloco, em ordem. Cada quadro é
+// filtrado sobre o histórico seguido dele mesmo; retorna o histórico atualizado, sem modificar o
+// recebido. Saídas do pool são contadas em meter.
+func (p *Pipeline) streamTemporal(ctx context.Context, frames VideoFrames, first int, label string, filter TemporalFilter, apply temporalApplyFunc, history VideoFrames, stats []FrameStats, meter *bufferMeter) (VideoFrames, error) {
+	keep := filter.(historyFilter).history()
+	_, pooled := filter.(pooledFilter)
+
+	for i, frame := range frames {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		p.observeQueue(label, len(frames)-i-1)
+		if err := p.CPU.acquire(ctx); err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		window := append(history[:len(history):len(history)], frame)
+		current := len(history)
+		var decisions DecisionMap
+		frames[i], decisions = apply(window, window, current)
+		elapsed := time.Since(start)
+		p.CPU.release()
+		if pooled {
+			meter.acquired(frames[i])
+		}
+
+		window[current] = frames[i]
+		history = window[max(len(window)-keep, 0):]
+		if err := p.temporalDone(label, first+i, elapsed, decisions, &stats[i]); err != nil {
+			return nil, err
+		}
+	}
+	return history, nil
+}
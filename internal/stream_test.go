@@ -0,0 +1,201 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"video-processor/internal/degradation"
+)
+
+var errStopStream = errors.New("stop")
+
+// Helper function to create a noisy clip with motion, so that every TimeTravaler branch is exercised
+func createNoisyStreamClip(t *testing.T) VideoFrames {
+	t.Helper()
+	clip, err := degradation.Apply(createMovingSquareClip(11, 24, 32), degradation.Config{Seed: 3, GaussianSigma: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clip
+}
+
+// Helper function to build the streamable pipeline used by the tests, with blocks of two frames
+func createStreamPipeline(t *testing.T, window int) *Pipeline {
+	t.Helper()
+	adaptive, _ := NewFilter(FilterNameAdaptive, nil)
+	gamma, _ := NewFilter(FilterNameGamma, jsonDecoder(`{"gamma": 1.2}`))
+	temporal := &TimeTravalerFilter{Window: window, Params: DefaultTemporalParams()}
+	pipeline, err := NewPipelineFromStages([]Stage{
+		{Filter: adaptive, Passes: 2},
+		{Filter: temporal, Passes: 1},
+		{Filter: gamma, Passes: 1},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline.CPU = NewCPUBudget(2)
+	return pipeline
+}
+
+// Helper function to fail the test when two clips differ
+func assertSameFrames(t *testing.T, expected, got VideoFrames) {
+	t.Helper()
+	if len(expected) != len(got) {
+		t.Fatalf("got %d frames, expected %d", len(got), len(expected))
+	}
+	for i := range expected {
+		for y := range expected[i] {
+			if !bytes.Equal(expected[i][y], got[i][y]) {
+				t.Fatalf("frame %d row %d differs", i, y)
+			}
+		}
+	}
+}
+
+func TestRunStream_MatchesRun(t *testing.T) {
+	video := createNoisyStreamClip(t)
+	for _, window := range []int{2, 7} {
+		pipeline := createStreamPipeline(t, window)
+		expected, err := pipeline.Run(video)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got VideoFrames
+		var state StreamState
+		result, err := pipeline.RunStream(context.Background(), video, &state, func(frames VideoFrames, _ *StreamState) error {
+			got = append(got, frames...)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertSameFrames(t, expected.Frames, got)
+		if result.Noise.Clip != expected.Noise.Clip || len(result.Stats) != len(video) {
+			t.Errorf("window %d: noise %v, %d stats", window, result.Noise.Clip, len(result.Stats))
+		}
+		for i := range expected.Stats {
+			if result.Stats[i].Temporal != expected.Stats[i].Temporal || result.Stats[i].OutputMean != expected.Stats[i].OutputMean {
+				t.Errorf("window %d: stats of frame %d differ", window, i)
+			}
+		}
+	}
+}
+
+func TestRunStream_ResumeFromCheckpoint(t *testing.T) {
+	video := createNoisyStreamClip(t)
+	expected, err := createStreamPipeline(t, 7).Run(video)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "job.checkpoint")
+
+	// The first run saves a checkpoint after every block and dies after frame 6.
+	var first VideoFrames
+	var state StreamState
+	_, err = createStreamPipeline(t, 7).RunStream(context.Background(), video, &state, func(frames VideoFrames, state *StreamState) error {
+		first = append(first, frames...)
+		if err := SaveCheckpoint(path, "hash", *state); err != nil {
+			return err
+		}
+		if state.Next >= 6 {
+			return errStopStream
+		}
+		return nil
+	})
+	if !errors.Is(err, errStopStream) {
+		t.Fatalf("err = %v", err)
+	}
+
+	resumed, err := LoadCheckpoint(path, "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Next != 6 {
+		t.Fatalf("checkpoint at frame %d, expected 6", resumed.Next)
+	}
+	rest := append(VideoFrames(nil), first[:resumed.Next]...)
+	result, err := createStreamPipeline(t, 7).RunStream(context.Background(), video, &resumed, func(frames VideoFrames, _ *StreamState) error {
+		rest = append(rest, frames...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameFrames(t, expected.Frames, rest)
+	if len(result.Stats) != len(video) || result.Stats[10].Frame != 10 {
+		t.Errorf("stats after resume = %d frames", len(result.Stats))
+	}
+
+	if _, err := LoadCheckpoint(path, "other"); !errors.Is(err, ErrCheckpointMismatch) {
+		t.Errorf("checkpoint with another hash: err = %v", err)
+	}
+}
+
+func TestPipeline_Streamable(t *testing.T) {
+	joint, _ := NewFilter(FilterNameSpatioTemporal, nil)
+	for _, stages := range [][]Stage{
+		{{Filter: joint, Passes: 1}},
+		{{Filter: previousFrameFilter{}, Passes: 1}},
+	} {
+		pipeline, err := NewPipelineFromStages(stages, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pipeline.Streamable(); err == nil {
+			t.Errorf("%s should not be streamable", stages[0].Filter.Name())
+		}
+		if _, err := pipeline.RunStream(context.Background(), nil, &StreamState{}, nil); err == nil {
+			t.Errorf("RunStream accepted %s", stages[0].Filter.Name())
+		}
+	}
+}
+
+func TestFrameSpool_ResumeDropsFramesAfterCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool.y4m")
+	frames := VideoFrames{createTestFrame(3, 4, 10), createTestFrame(3, 4, 20), createTestFrame(3, 4, 30)}
+
+	spool, err := CreateFrameSpool(path, 4, 3, 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, frame := range frames {
+		if err := spool.WriteFrame(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := spool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Simulates a crash in the middle of the next frame.
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	file.WriteString("FRAME\n\x01\x02")
+	file.Close()
+
+	spool, err = ResumeFrameSpool(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := spool.WriteFrame(createTestFrame(3, 4, 40)); err != nil {
+		t.Fatal(err)
+	}
+	if spool.Frames() != 3 {
+		t.Errorf("Frames() = %d, expected 3", spool.Frames())
+	}
+	if err := spool.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _, err := ReadY4MFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameFrames(t, VideoFrames{frames[0], frames[1], createTestFrame(3, 4, 40)}, got)
+
+	if _, err := ResumeFrameSpool(path, 4); err == nil {
+		t.Error("resume past the end of the spool should fail")
+	}
+}
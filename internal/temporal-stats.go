@@ -0,0 +1,148 @@
+package internal
+
+import "slices"
+
+// temporalStats são as estatísticas dos valores de um pixel nos frames anteriores. São calculadas
+// uma vez por pixel e compartilhadas pelos testes de blur, flare, ruído e movimento e pelo filtro
+// adaptativo, que antes ordenavam cada um a sua cópia da janela.
+type temporalStats struct {
+	sorted    []uint8 // Valores em ordem crescente.
+	median    uint8   // sorted[len(sorted)/2].
+	variance  float64 // Variância populacional, como em calculateVariance.
+	stability float64 // Fração dos pares de valores com diferença <= SimilarityThreshold.
+}
+
+// insertionSortMax é o maior tamanho de janela ordenado por inserção; janelas maiores usam slices.Sort.
+const insertionSortMax = 32
+
+// windowStats calcula as estatísticas de values usando sorted, do mesmo tamanho, como buffer da cópia
+// ordenada. values não é modificado.
+func (p TemporalParams) windowStats(values, sorted []uint8) temporalStats {
+	n := len(values)
+	stats := temporalStats{sorted: sorted[:n]}
+	if n == 0 {
+		return stats
+	}
+
+	// Ordenação por inserção enquanto copia: para as janelas usuais, de poucos frames, é mais rápida
+	// que um sort genérico e não aloca. Soma e soma dos quadrados são inteiras, então exatas.
+	var sum, sumSq int
+	for i, v := range values {
+		sum += int(v)
+		sumSq += int(v) * int(v)
+		if n > insertionSortMax {
+			stats.sorted[i] = v
+			continue
+		}
+		j := i
+		for ; j > 0 && stats.sorted[j-1] > v; j-- {
+			stats.sorted[j] = stats.sorted[j-1]
+		}
+		stats.sorted[j] = v
+	}
+	if n > insertionSortMax {
+		slices.Sort(stats.sorted)
+	}
+	stats.median = stats.sorted[n/2]
+
+	if n > 1 {
+		stats.variance = varianceFromSums(int64(sum), int64(sumSq), n)
+		stats.stability = float64(similarPairs(stats.sorted, p.SimilarityThreshold)) / float64(n*(n-1)/2)
+	}
+	return stats
+}
+
+// similarPairs conta os pares de valores com diferença <= threshold. Com os valores ordenados, os
+// parceiros anteriores de cada valor formam um intervalo contíguo, então a contagem é linear em vez
+// de comparar todos os pares.
+func similarPairs(sorted []uint8, threshold float64) int {
+	count, lo := 0, 0
+	for j, v := range sorted {
+		for lo < j && float64(v-sorted[lo]) > threshold {
+			lo++
+		}
+		count += j - lo
+	}
+	return count
+}
+
+// isBlur verifica blur: a mediana está bem acima do valor atual, que é baixo.
+func (s *temporalStats) isBlur(p TemporalParams, current uint8) bool {
+	if len(s.sorted) < 3 {
+		return false
+	}
+	diff := int(s.median) - int(current)
+	return float64(diff) > p.BlurDiff && float64(current) < p.BlurMaxValue
+}
+
+// isFlare verifica flare: o valor atual, alto, está bem acima da mediana.
+func (s *temporalStats) isFlare(p TemporalParams, current uint8) bool {
+	if len(s.sorted) < 3 {
+		return false
+	}
+	diff := int(current) - int(s.median)
+	return float64(diff) > p.FlareDiff && float64(current) > p.FlareMinValue
+}
+
+// isNoise verifica ruído: a maioria dos valores anteriores é estável e o atual destoa da mediana.
+func (s *temporalStats) isNoise(p TemporalParams, current uint8) bool {
+	if len(s.sorted) < 3 || s.stability <= p.StabilityRatio {
+		return false
+	}
+	currentDiff := int(current) - int(s.median)
+	if currentDiff < 0 {
+		currentDiff = -currentDiff
+	}
+	return float64(currentDiff) > p.NoiseDiff
+}
+
+// hasMovement verifica se a variância dos valores anteriores indica movimento.
+func (s *temporalStats) hasMovement(p TemporalParams) bool {
+	return len(s.sorted) >= 3 && s.variance > p.MovementVariance
+}
+
+// blurCorrection retorna a média da mediana e do próximo valor ordenado, usada na correção de blur.
+func (s *temporalStats) blurCorrection() uint8 {
+	medianIdx := len(s.sorted) / 2
+	if medianIdx < len(s.sorted)-1 {
+		return uint8((int(s.sorted[medianIdx]) + int(s.sorted[medianIdx+1])) / 2)
+	}
+	return s.sorted[medianIdx]
+}
+
+// temporalMixes são os pesos de mistura do TimeTravaler, convertidos uma vez para os kernels.
+type temporalMixes struct {
+	blur, noise, strong, medium, weak mix
+}
+
+// mixes converte os alfas dos parâmetros.
+func (p TemporalParams) mixes() temporalMixes {
+	return temporalMixes{
+		blur:   newMix(p.BlurAlpha),
+		noise:  newMix(p.NoiseAlpha),
+		strong: newMix(p.StrongAlpha),
+		medium: newMix(p.MediumAlpha),
+		weak:   newMix(p.WeakAlpha),
+	}
+}
+
+// adaptiveMix escolhe o peso da mediana no filtro temporal adaptativo: quanto menor a variância,
+// maior o peso da mediana dos frames anteriores.
+func (s *temporalStats) adaptiveMix(p TemporalParams, mixes *temporalMixes) mix {
+	if s.variance < p.StrongVariance {
+		return mixes.strong
+	} else if s.variance < p.MediumVariance {
+		return mixes.medium
+	}
+	return mixes.weak
+}
+
+// adaptiveFilter aplica o filtro temporal adaptativo: mistura a mediana com o valor atual, com
+// peso maior para a mediana quanto menor a variância.
+func (s *temporalStats) adaptiveFilter(p TemporalParams, current uint8) uint8 {
+	if len(s.sorted) == 0 {
+		return current
+	}
+	mixes := p.mixes()
+	return blend(s.median, current, s.adaptiveMix(p, &mixes))
+}
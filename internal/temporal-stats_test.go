@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// Helper function to count the similar pairs by comparing every pair, as the filter used to
+func countSimilarPairsNaive(values []uint8, threshold float64) int {
+	count := 0
+	for i := 0; i < len(values)-1; i++ {
+		for j := i + 1; j < len(values); j++ {
+			diff := int(values[i]) - int(values[j])
+			if diff < 0 {
+				diff = -diff
+			}
+			if float64(diff) <= threshold {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func TestWindowStats_MatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	params := DefaultTemporalParams()
+	for _, n := range []int{1, 2, 3, 7, 15, insertionSortMax, insertionSortMax + 1, 40} {
+		for trial := range 200 {
+			values := make([]uint8, n)
+			base := rng.Intn(256)
+			for i := range values {
+				// Narrow windows around a base value exercise the similarity threshold.
+				values[i] = uint8(min(max(base+rng.Intn(41)-20, 0), 255))
+			}
+			original := append([]uint8(nil), values...)
+
+			stats := params.windowStats(values, make([]uint8, n))
+
+			expected := append([]uint8(nil), values...)
+			sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
+			if string(stats.sorted) != string(expected) {
+				t.Fatalf("n=%d trial %d: sorted %v, expected %v", n, trial, stats.sorted, expected)
+			}
+			if stats.median != expected[n/2] {
+				t.Errorf("n=%d trial %d: median %d, expected %d", n, trial, stats.median, expected[n/2])
+			}
+			if variance := calculateVariance(values); stats.variance != variance {
+				t.Errorf("n=%d trial %d: variance %v, expected %v", n, trial, stats.variance, variance)
+			}
+			if n > 1 {
+				stability := float64(countSimilarPairsNaive(values, params.SimilarityThreshold)) / float64(n*(n-1)/2)
+				if stats.stability != stability {
+					t.Errorf("n=%d trial %d: stability %v, expected %v", n, trial, stats.stability, stability)
+				}
+			}
+			if string(values) != string(original) {
+				t.Fatalf("n=%d trial %d: values were modified", n, trial)
+			}
+		}
+	}
+}
+
+func TestSimilarPairs_NegativeThreshold(t *testing.T) {
+	if got := similarPairs([]uint8{1, 1, 2}, -1); got != 0 {
+		t.Errorf("similarPairs with a negative threshold = %d, expected 0", got)
+	}
+}
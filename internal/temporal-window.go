@@ -0,0 +1,143 @@
+package internal
+
+// temporalWindow é a janela deslizante do TimeTravaler: para cada pixel, os valores dos últimos size
+// quadros filtrados, num anel em ordem de chegada e numa cópia ordenada, com a soma, a soma dos
+// quadrados e o número de pares semelhantes atualizados a cada quadro. Deslizar a janela custa uma
+// remoção e uma inserção no vetor ordenado de cada pixel, em vez de coletar e ordenar de novo os
+// size valores, então o custo por quadro praticamente não depende do tamanho da janela.
+//
+// Os pixels são independentes: linhas diferentes podem ser filtradas e empurradas ao mesmo tempo,
+// desde que slide só seja chamado depois de todos os pixels do quadro.
+type temporalWindow struct {
+	size  int // Número máximo de quadros na janela.
+	width int
+	count int // Quadros na janela, até size.
+	next  int // Posição do anel onde entra o próximo quadro.
+	// similarity é a maior diferença entre dois valores semelhantes; -1 se nenhum par é semelhante.
+	similarity int
+
+	ring    []uint8 // Valores de cada pixel em ordem de chegada: ring[pixel*size+slot].
+	sorted  []uint8 // Os count valores de cada pixel em ordem crescente: sorted[pixel*size+k].
+	sum     []int32
+	sumSq   []uint32 // Cabe em 32 bits para janelas de até 66051 quadros.
+	similar []int32  // Pares de valores da janela com diferença <= similarity.
+}
+
+// newTemporalWindow cria uma janela vazia de size quadros de height x width pixels, com pares de
+// valores semelhantes definidos por threshold, como TemporalParams.SimilarityThreshold.
+func newTemporalWindow(height, width, size int, threshold float64) *temporalWindow {
+	pixels := height * width
+	similarity := -1
+	if threshold >= 0 {
+		similarity = int(min(threshold, 255))
+	}
+	return &temporalWindow{
+		size:       size,
+		width:      width,
+		similarity: similarity,
+		ring:       make([]uint8, pixels*size),
+		sorted:     make([]uint8, pixels*size),
+		sum:        make([]int32, pixels),
+		sumSq:      make([]uint32, pixels),
+		similar:    make([]int32, pixels),
+	}
+}
+
+// seed empurra os quadros, do mais antigo ao mais recente, como se cada um tivesse acabado de ser filtrado.
+func (w *temporalWindow) seed(frames VideoFrames) {
+	for _, frame := range frames {
+		for y, row := range frame {
+			w.pushRow(y, row)
+		}
+		w.slide()
+	}
+}
+
+// pushRow empurra os valores de uma linha do quadro atual.
+func (w *temporalWindow) pushRow(line int, row []uint8) {
+	base := line * w.width
+	for i, v := range row {
+		w.push(base+i, v)
+	}
+}
+
+// push coloca o valor do quadro atual na janela do pixel, tirando o mais antigo se ela estiver cheia.
+// Uma janela de tamanho 0 fica sempre vazia.
+func (w *temporalWindow) push(pixel int, v uint8) {
+	if w.size == 0 {
+		return
+	}
+	base := pixel * w.size
+	sorted := w.sorted[base : base+w.count]
+	slot := base + w.next
+
+	if w.count == w.size {
+		old := w.ring[slot]
+		if w.similarity >= 0 {
+			// Os semelhantes ao valor removido incluem ele mesmo.
+			w.similar[pixel] -= int32(w.similarTo(sorted, old) - 1)
+		}
+		w.sum[pixel] -= int32(old)
+		w.sumSq[pixel] -= uint32(old) * uint32(old)
+		idx := lowerBound(sorted, int(old))
+		copy(sorted[idx:], sorted[idx+1:])
+		sorted = sorted[:len(sorted)-1]
+	}
+
+	w.similar[pixel] += int32(w.similarTo(sorted, v))
+	w.sum[pixel] += int32(v)
+	w.sumSq[pixel] += uint32(v) * uint32(v)
+	idx := lowerBound(sorted, int(v))
+	sorted = sorted[:len(sorted)+1]
+	copy(sorted[idx+1:], sorted[idx:])
+	sorted[idx] = v
+	w.ring[slot] = v
+}
+
+// slide avança a janela depois que todos os pixels do quadro atual foram empurrados.
+func (w *temporalWindow) slide() {
+	if w.size == 0 {
+		return
+	}
+	w.next = (w.next + 1) % w.size
+	w.count = min(w.count+1, w.size)
+}
+
+// similarTo conta os valores de sorted com diferença até w.similarity para v; nenhum se similarity < 0.
+func (w *temporalWindow) similarTo(sorted []uint8, v uint8) int {
+	if w.similarity < 0 {
+		return 0
+	}
+	return lowerBound(sorted, int(v)+w.similarity+1) - lowerBound(sorted, int(v)-w.similarity)
+}
+
+// stats retorna as estatísticas da janela do pixel, iguais às de TemporalParams.windowStats sobre os
+// mesmos valores. O vetor ordenado é o da janela e muda no próximo push do pixel.
+func (w *temporalWindow) stats(pixel int) temporalStats {
+	n := w.count
+	base := pixel * w.size
+	stats := temporalStats{sorted: w.sorted[base : base+n]}
+	if n == 0 {
+		return stats
+	}
+	stats.median = stats.sorted[n/2]
+	if n > 1 {
+		stats.variance = varianceFromSums(int64(w.sum[pixel]), int64(w.sumSq[pixel]), n)
+		stats.stability = float64(w.similar[pixel]) / float64(n*(n-1)/2)
+	}
+	return stats
+}
+
+// lowerBound retorna o índice do primeiro valor de sorted maior ou igual a v.
+func lowerBound(sorted []uint8, v int) int {
+	lo, hi := 0, len(sorted)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if int(sorted[mid]) < v {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+	"video-processor/internal/degradation"
+)
+
+func TestTemporalWindow_MatchesWindowStats(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	params := DefaultTemporalParams()
+	const pixels = 16
+	for _, size := range []int{1, 3, 7, 30} {
+		window := newTemporalWindow(1, pixels, size, params.SimilarityThreshold)
+		var history [][]uint8
+		for frame := range 80 {
+			row := make([]uint8, pixels)
+			base := rng.Intn(256)
+			for i := range row {
+				row[i] = uint8(min(max(base+rng.Intn(31)-15, 0), 255))
+			}
+			window.pushRow(0, row)
+			window.slide()
+			history = append(history, row)
+
+			last := history[max(len(history)-size, 0):]
+			for pixel := range pixels {
+				values := make([]uint8, len(last))
+				for i, r := range last {
+					values[i] = r[pixel]
+				}
+				expected := params.windowStats(values, make([]uint8, len(values)))
+				if got := window.stats(pixel); !reflect.DeepEqual(got, expected) {
+					t.Fatalf("size %d, frame %d, pixel %d: stats %+v, expected %+v", size, frame, pixel, got, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestTemporalWindow_NegativeThreshold(t *testing.T) {
+	window := newTemporalWindow(1, 1, 3, -1)
+	for _, v := range []uint8{10, 10, 10, 10, 10} {
+		window.pushRow(0, []uint8{v})
+		window.slide()
+	}
+	if got := window.stats(0).stability; got != 0 {
+		t.Errorf("stability with a negative threshold = %v, expected 0", got)
+	}
+}
+
+func TestTimeTravalerSequence_MatchesApplyTemporal(t *testing.T) {
+	clip, err := degradation.Apply(createMovingSquareClip(40, 16, 24), degradation.Config{Seed: 4, GaussianSigma: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, window := range []int{2, 30} {
+		t.Run(fmt.Sprintf("window=%d", window), func(t *testing.T) {
+			filter := &TimeTravalerFilter{Window: window, Params: DefaultTemporalParams()}
+
+			expected := append(VideoFrames(nil), clip...)
+			for i := range expected {
+				expected[i] = filter.ApplyTemporal(clip, expected, i)
+			}
+
+			got := append(VideoFrames(nil), clip...)
+			sequence := filter.sequence()
+			for i := range got {
+				got[i], _ = sequence.apply(clip, got, i)
+			}
+			assertSameFrames(t, expected, got)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultTileSize é o lado, em pixels, dos blocos em que um quadro é dividido para processamento paralelo.
+const defaultTileSize = 64
+
+// tile representa um bloco retangular de um quadro: linhas [Y0, Y1) e colunas [X0, X1).
+type tile struct {
+	Y0, Y1, X0, X1 int
+}
+
+// splitTiles divide um quadro de altura x largura em blocos de no máximo size x size pixels.
+func splitTiles(height, width, size int) []tile {
+	var tiles []tile
+	for y := 0; y < height; y += size {
+		for x := 0; x < width; x += size {
+			tiles = append(tiles, tile{
+				Y0: y,
+				Y1: min(y+size, height),
+				X0: x,
+				X1: min(x+size, width),
+			})
+		}
+	}
+	return tiles
+}
+
+// temporalBandRows é a altura das faixas de linhas inteiras em que o TimeTravaler divide um quadro.
+const temporalBandRows = 16
+
+// splitRows divide um quadro de altura x largura em faixas de no máximo rows linhas inteiras.
+func splitRows(height, width, rows int) []tile {
+	var tiles []tile
+	for y := 0; y < height; y += rows {
+		tiles = append(tiles, tile{Y0: y, Y1: min(y+rows, height), X0: 0, X1: width})
+	}
+	return tiles
+}
+
+// tilePool é o pool de workers compartilhado por todos os quadros em processamento, para que
+// vários quadros divididos em blocos ao mesmo tempo não criem um worker por CPU cada um.
+var tilePool struct {
+	once sync.Once
+	work chan func()
+}
+
+// submitTile entrega work a um worker livre do pool, sem esperar. Retorna false se nenhum
+// worker estiver livre.
+func submitTile(work func()) bool {
+	tilePool.once.Do(func() {
+		tilePool.work = make(chan func())
+		for range runtime.NumCPU() {
+			go func() {
+				for work := range tilePool.work {
+					work()
+				}
+			}()
+		}
+	})
+	select {
+	case tilePool.work <- work:
+		return true
+	default:
+		return false
+	}
+}
+
+// forEachTile processa os blocos em paralelo no pool compartilhado. A goroutine que chama também
+// processa blocos, então o quadro avança mesmo com todos os workers ocupados por outros quadros.
+// Cada bloco é entregue a exatamente uma goroutine.
+func forEachTile(tiles []tile, process func(t tile)) {
+	var next atomic.Int64
+	work := func() {
+		for i := int(next.Add(1)) - 1; i < len(tiles); i = int(next.Add(1)) - 1 {
+			process(tiles[i])
+		}
+	}
+
+	var wg sync.WaitGroup
+	for range min(runtime.NumCPU(), len(tiles)) - 1 {
+		wg.Add(1)
+		if !submitTile(func() { defer wg.Done(); work() }) {
+			wg.Done()
+			break
+		}
+	}
+	work()
+	wg.Wait()
+}
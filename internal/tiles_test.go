@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"bytes"
+	"sync/atomic"
+	"testing"
+	"video-processor/internal/degradation"
+)
+
+// Helper function to create a noisy frame larger than one tile, with partial tiles at the borders
+func createLargeNoisyFrame(t testing.TB) Frame {
+	t.Helper()
+	clip, err := degradation.Apply(VideoFrames{createRampWithEdgeFrame(150, 130)},
+		degradation.Config{Seed: 5, GaussianSigma: 6, SaltPepperDensity: 0.02})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clip[0]
+}
+
+// Helper function to fail the test when two frames differ
+func assertSameFrame(t *testing.T, expected, got Frame) {
+	t.Helper()
+	for y := range expected {
+		if !bytes.Equal(expected[y], got[y]) {
+			t.Fatalf("row %d differs", y)
+		}
+	}
+}
+
+func TestAdaptiveTiled_MatchesSingleTile(t *testing.T) {
+	frame := createLargeNoisyFrame(t)
+	params := DefaultAdaptiveParams()
+	for _, radius := range []int{1, 3} {
+		expected, expectedCounts := applyAdaptiveTiled(frame, radius, params, len(frame))
+		for _, size := range []int{7, defaultTileSize} {
+			got, counts := applyAdaptiveTiled(frame, radius, params, size)
+			assertSameFrame(t, expected, got)
+			if counts != expectedCounts {
+				t.Errorf("radius %d, tiles of %d: counts %+v, expected %+v", radius, size, counts, expectedCounts)
+			}
+		}
+	}
+}
+
+func TestAdaptiveTiled_MatchesPerPixelFilter(t *testing.T) {
+	frame := createLargeNoisyFrame(t)
+	params := DefaultAdaptiveParams()
+	for _, radius := range []int{1, 3} {
+		got, _ := applyAdaptiveTiled(frame, radius, params, defaultTileSize)
+		for y := range frame {
+			for x := range frame[y] {
+				pixelRadius := GetPixelRadius(frame, y, x, radius)
+				pixelRadius.ApplyAdaptiveFilterWithParams(params, IsEdge(frame, y, x, DefaultEdgeOperator, params.EdgeThreshold))
+				if expected := pixelRadius.Pixels[pixelRadius.CenterY][pixelRadius.CenterX]; got[y][x] != expected {
+					t.Fatalf("radius %d, pixel (%d, %d) = %d, expected %d", radius, y, x, got[y][x], expected)
+				}
+			}
+		}
+	}
+}
+
+func TestBilateralTiled_MatchesSingleTile(t *testing.T) {
+	frame := createLargeNoisyFrame(t)
+	filter, err := NewBilateralFilter(DefaultBilateralParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := filter.applyTiled(frame, len(frame))
+	for _, size := range []int{5, defaultTileSize} {
+		assertSameFrame(t, expected, filter.applyTiled(frame, size))
+	}
+}
+
+func TestSplitRows_CoversEveryRowOnce(t *testing.T) {
+	bands := splitRows(37, 20, temporalBandRows)
+	next := 0
+	for _, band := range bands {
+		if band.Y0 != next || band.X0 != 0 || band.X1 != 20 || band.Y1-band.Y0 > temporalBandRows {
+			t.Fatalf("band %+v does not continue at row %d with the full width", band, next)
+		}
+		next = band.Y1
+	}
+	if next != 37 {
+		t.Errorf("bands end at row %d, expected 37", next)
+	}
+}
+
+func TestForEachTile_NestedCallsShareThePool(t *testing.T) {
+	var processed atomic.Int64
+	outer := splitTiles(64, 64, 8)
+	forEachTile(outer, func(tile) {
+		// Um bloco que divide o trabalho de novo não pode travar com todos os workers ocupados.
+		forEachTile(splitTiles(16, 16, 4), func(tile) {
+			processed.Add(1)
+		})
+	})
+	if got, expected := processed.Load(), int64(len(outer)*16); got != expected {
+		t.Errorf("processed %d tiles, expected %d", got, expected)
+	}
+}
+
+func BenchmarkAdaptiveFrame_Tiled(b *testing.B) {
+	frame := createLargeNoisyFrame(b)
+	params := DefaultAdaptiveParams()
+	b.ResetTimer()
+	for range b.N {
+		applyAdaptiveTiled(frame, 1, params, defaultTileSize)
+	}
+}
+
+func BenchmarkAdaptiveFrame_SingleTile(b *testing.B) {
+	frame := createLargeNoisyFrame(b)
+	params := DefaultAdaptiveParams()
+	b.ResetTimer()
+	for range b.N {
+		applyAdaptiveTiled(frame, 1, params, len(frame))
+	}
+}
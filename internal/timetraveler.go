@@ -1,177 +1,79 @@
 package internal
 
-import (
-	"math"
-	"runtime"
-	"sort"
-	"sync"
-)
+import "slices"
 
 // median calcula a mediana de um slice de uint8.
 func median(values []uint8) uint8 {
-	// Cria uma cópia para não modificar o slice original.
-	sortedValues := make([]uint8, len(values))
-	copy(sortedValues, values)
-	sort.Slice(sortedValues, func(i, j int) bool {
-		return sortedValues[i] < sortedValues[j]
-	})
+	// Ordena uma cópia para não modificar o slice original; janelas de até 32 valores usam uma
+	// cópia na pilha, sem alocar.
+	var stack [32]uint8
+	sortedValues := append(stack[:0], values...)
+	slices.Sort(sortedValues)
 	mid := len(sortedValues) / 2
 	return sortedValues[mid]
 }
 
 // isEdgePixel verifica se um pixel é uma borda usando o operador Sobel.
 func isEdgePixel(videoFrames VideoFrames, currentFrame, line, pixel int) bool {
-	frame := videoFrames[currentFrame]
-	// Verifica se o pixel está nas bordas do frame.
-	if line == 0 || line >= len(frame)-1 || pixel == 0 || pixel >= len(frame[line])-1 {
-		return true
-	}
-
-	// Operador Sobel para detecção de bordas.
-	gx := float64(-int(frame[line-1][pixel-1]) + int(frame[line-1][pixel+1]) +
-		-2*int(frame[line][pixel-1]) + 2*int(frame[line][pixel+1]) +
-		-int(frame[line+1][pixel-1]) + int(frame[line+1][pixel+1]))
-
-	gy := float64(-int(frame[line-1][pixel-1]) - 2*int(frame[line-1][pixel]) - int(frame[line-1][pixel+1]) +
-		int(frame[line+1][pixel-1]) + 2*int(frame[line+1][pixel]) + int(frame[line+1][pixel+1]))
-
-	gradient := math.Sqrt(gx*gx + gy*gy)
-	// Define um limiar para considerar como borda.
-	return gradient > 25
+	return IsEdge(videoFrames[currentFrame], line, pixel, DefaultEdgeOperator, DefaultEdgeThreshold)
 }
 
 // isBlur verifica se o pixel atual está borrado em comparação com os valores anteriores.
 func isBlur(values []uint8, current uint8) bool {
-	if len(values) < 3 {
-		return false
-	}
-
-	sorted := make([]uint8, len(values))
-	copy(sorted, values)
-	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i] < sorted[j]
-	})
-	median := sorted[len(sorted)/2]
+	return DefaultTemporalParams().isBlur(values, current)
+}
 
-	diff := int(median) - int(current)
-	// Condições para identificar blur: diferença significativa e valor atual baixo.
-	return diff > 40 && current < 60
+// isBlur verifica blur usando os limiares dos parâmetros.
+func (p TemporalParams) isBlur(values []uint8, current uint8) bool {
+	stats := p.windowStats(values, make([]uint8, len(values)))
+	return stats.isBlur(p, current)
 }
 
 // isFlare verifica se o pixel atual é um reflexo (flare) em comparação com os valores anteriores.
 func isFlare(values []uint8, current uint8) bool {
-	if len(values) < 3 {
-		return false
-	}
-
-	sorted := make([]uint8, len(values))
-	copy(sorted, values)
-	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i] < sorted[j]
-	})
-	median := sorted[len(sorted)/2]
+	return DefaultTemporalParams().isFlare(values, current)
+}
 
-	diff := int(current) - int(median)
-	// Condições para identificar flare: diferença significativa e valor atual alto.
-	return diff > 50 && current > 180
+// isFlare verifica flare usando os limiares dos parâmetros.
+func (p TemporalParams) isFlare(values []uint8, current uint8) bool {
+	stats := p.windowStats(values, make([]uint8, len(values)))
+	return stats.isFlare(p, current)
 }
 
 // isNoise verifica se o pixel atual é ruído em comparação com os valores anteriores.
 func isNoise(values []uint8, current uint8, variance float64) bool {
-	if len(values) < 3 {
-		return false
-	}
-
-	similarCount := 0
-	threshold := uint8(5) // Limiar para considerar pixels como similares.
-
-	// Conta pares de pixels com valores próximos.
-	for i := 0; i < len(values)-1; i++ {
-		for j := i + 1; j < len(values); j++ {
-			diff := int(values[i]) - int(values[j])
-			if diff < 0 {
-				diff = -diff
-			}
-			if uint8(diff) <= threshold {
-				similarCount++
-			}
-		}
-	}
-
-	totalPairs := len(values) * (len(values) - 1) / 2
-	stabilityRatio := float64(similarCount) / float64(totalPairs)
-
-	// Se a maioria dos pixels anteriores forem estáveis (similares),
-	// verifica se o pixel atual destoa muito da mediana.
-	if stabilityRatio > 0.6 {
-		// Cria uma cópia para não modificar o slice original.
-		sortedValues := make([]uint8, len(values))
-		copy(sortedValues, values)
-		sort.Slice(sortedValues, func(i, j int) bool {
-			return sortedValues[i] < sortedValues[j]
-		})
-		median := sortedValues[len(sortedValues)/2]
-
-		currentDiff := int(current) - int(median)
-		if currentDiff < 0 {
-			currentDiff = -currentDiff
-		}
-
-		return uint8(currentDiff) > 12 // Limiar para considerar como ruído.
-	}
+	return DefaultTemporalParams().isNoise(values, current, variance)
+}
 
-	return false
+// isNoise verifica ruído usando os limiares dos parâmetros. A variância não é usada: a estabilidade
+// vem da fração de pares de valores próximos.
+func (p TemporalParams) isNoise(values []uint8, current uint8, variance float64) bool {
+	stats := p.windowStats(values, make([]uint8, len(values)))
+	return stats.isNoise(p, current)
 }
 
 // hasMovement verifica se há movimento significativo nos valores dos pixels anteriores.
 func hasMovement(values []uint8) bool {
-	if len(values) < 3 {
-		return false
-	}
+	return DefaultTemporalParams().hasMovement(values)
+}
 
-	variance := calculateVariance(values)
-	// Limiar de variância para detectar movimento.
-	return variance > 30
+// hasMovement verifica movimento usando o limiar de variância dos parâmetros.
+func (p TemporalParams) hasMovement(values []uint8) bool {
+	stats := p.windowStats(values, make([]uint8, len(values)))
+	return stats.hasMovement(p)
 }
 
 // adaptiveTemporalFilter aplica um filtro temporal adaptativo.
 // A intensidade do filtro (alpha) depende da variância dos pixels anteriores.
 func adaptiveTemporalFilter(values []uint8, current uint8, variance float64) uint8 {
-	if len(values) == 0 {
-		return current
-	}
-
-	// Cria uma cópia para não modificar o slice original.
-	sorted := make([]uint8, len(values))
-	copy(sorted, values)
-	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i] < sorted[j]
-	})
-
-	median := sorted[len(sorted)/2]
-
-	var alpha float64
-	// Ajusta o peso (alpha) com base na variância.
-	// Menor variância = maior peso para a mediana dos frames anteriores.
-	if variance < 10 {
-		alpha = 0.6
-	} else if variance < 25 {
-		alpha = 0.4
-	} else {
-		alpha = 0.2
-	}
-
-	result := alpha*float64(median) + (1-alpha)*float64(current)
-
-	// Garante que o resultado esteja no intervalo [0, 255].
-	if result < 0 {
-		return 0
-	}
-	if result > 255 {
-		return 255
-	}
+	return DefaultTemporalParams().adaptiveTemporalFilter(values, current, variance)
+}
 
-	return uint8(result)
+// adaptiveTemporalFilter aplica o filtro temporal adaptativo com os limiares e alfas dos parâmetros.
+func (p TemporalParams) adaptiveTemporalFilter(values []uint8, current uint8, variance float64) uint8 {
+	stats := p.windowStats(values, make([]uint8, len(values)))
+	stats.variance = variance
+	return stats.adaptiveFilter(p, current)
 }
 
 // TimeTravalerProcessLine processa uma única linha de um frame de vídeo.
@@ -182,62 +84,81 @@ func TimeTravalerProcessLine(videoFrames VideoFrames, currentFrame int, previous
 		return videoFrames[currentFrame][line]
 	}
 
-	lineWidth := len(videoFrames[currentFrame][line])
-	nLine := make([]uint8, lineWidth)           // Linha processada.
-	tempValues := make([]uint8, previousFrames) // Valores do pixel atual nos frames anteriores.
-	frameStart := currentFrame - previousFrames
+	edges := make([]bool, len(videoFrames[currentFrame][line]))
+	for i := range edges {
+		edges[i] = isEdgePixel(videoFrames, currentFrame, line, i)
+	}
 
-	for i := 0; i < lineWidth; i++ {
-		current := videoFrames[currentFrame][line][i] // Pixel atual.
+	// Janela de uma linha só, com a mesma linha dos frames anteriores.
+	params := DefaultTemporalParams()
+	window := newTemporalWindow(1, len(edges), previousFrames, params.SimilarityThreshold)
+	for _, frame := range videoFrames[max(currentFrame-previousFrames, 0):currentFrame] {
+		window.pushRow(0, frame[line])
+		window.slide()
+	}
 
-		// Se for um pixel de borda, mantém o valor original.
-		if isEdgePixel(videoFrames, currentFrame, line, i) {
-			nLine[i] = current
-			continue
-		}
+	nLine := make([]uint8, len(edges))
+	window.filterLine(nLine, videoFrames[currentFrame][line], 0, edges, params, nil, newLineScratch(len(edges), params))
+	return nLine
+}
 
-		// Coleta os valores do pixel atual nos frames anteriores.
-		for j := 0; j < previousFrames; j++ {
-			tempValues[j] = videoFrames[frameStart+j][line][i]
+// lineScratch são os buffers de um worker do TimeTravaler para misturar uma linha inteira de uma vez,
+// com os pesos dos parâmetros já convertidos.
+type lineScratch struct {
+	mixes   temporalMixes
+	targets []uint8 // Valor com que cada pixel é misturado.
+	weights []mix   // Peso de targets em cada pixel; zero mantém o pixel.
+}
+
+// newLineScratch cria os buffers para linhas de até width pixels.
+func newLineScratch(width int, params TemporalParams) *lineScratch {
+	return &lineScratch{mixes: params.mixes(), targets: make([]uint8, width), weights: make([]mix, width)}
+}
+
+// filterLine processa uma linha do quadro atual com as estatísticas da janela, gravando o resultado
+// em dst, e empurra o resultado na janela. edges é a linha correspondente de um mapa de bordas já
+// calculado. Se decisions não for nil, registra nele o ramo escolhido para cada pixel.
+//
+// Primeiro cada pixel escolhe o ramo, o valor com que será misturado e o peso; depois a linha inteira
+// é misturada de uma vez com blendRow.
+func (w *temporalWindow) filterLine(dst, row []uint8, line int, edges []bool, params TemporalParams, decisions []TemporalDecision, scratch *lineScratch) {
+	base := line * w.width
+	targets, weights := scratch.targets[:len(row)], scratch.weights[:len(row)]
+	for i, current := range row {
+		// Por padrão mantém o valor original, inclusive nos pixels de borda.
+		targets[i], weights[i] = current, mix{}
+		if edges[i] {
+			recordDecision(decisions, i, DecisionEdge)
+			continue
 		}
 
-		variance := calculateVariance(tempValues) // Calcula a variância dos pixels anteriores.
+		// Mediana, variância e estabilidade dos valores do pixel nos frames anteriores.
+		stats := w.stats(base + i)
 
 		// Aplica diferentes filtros com base nas características detectadas.
-		if isBlur(tempValues, current) {
+		if stats.isBlur(params, current) {
 			// Correção para blur: usa a média da mediana e do próximo valor ordenado.
-			sorted := make([]uint8, len(tempValues))
-			copy(sorted, tempValues)
-			sort.Slice(sorted, func(i, j int) bool {
-				return sorted[i] < sorted[j]
-			})
-
-			medianIdx := len(sorted) / 2
-			correctedValue := sorted[medianIdx]
-			if medianIdx < len(sorted)-1 {
-				correctedValue = uint8((int(sorted[medianIdx]) + int(sorted[medianIdx+1])) / 2)
-			}
-
-			alpha := 0.8 // Peso para a correção.
-			nLine[i] = uint8(alpha*float64(correctedValue) + (1-alpha)*float64(current))
+			targets[i], weights[i] = stats.blurCorrection(), scratch.mixes.blur
+			recordDecision(decisions, i, DecisionBlur)
 
-		} else if isNoise(tempValues, current, variance) {
+		} else if stats.isNoise(params, current) {
 			// Correção para ruído: usa a mediana dos frames anteriores.
-			medianVal := median(tempValues)
-			alpha := 0.7 // Peso para a correção.
-			nLine[i] = uint8(alpha*float64(medianVal) + (1-alpha)*float64(current))
+			targets[i], weights[i] = stats.median, scratch.mixes.noise
+			recordDecision(decisions, i, DecisionNoise)
 
-		} else if variance < 20 && !hasMovement(tempValues) {
+		} else if stats.variance < params.LowVariance && !stats.hasMovement(params) {
 			// Se há baixa variância e pouco movimento, aplica filtro temporal adaptativo.
-			nLine[i] = adaptiveTemporalFilter(tempValues, current, variance)
+			targets[i], weights[i] = stats.median, stats.adaptiveMix(params, &scratch.mixes)
+			recordDecision(decisions, i, DecisionAdaptive)
 
 		} else {
 			// Caso contrário, mantém o pixel original.
-			nLine[i] = current
+			recordDecision(decisions, i, DecisionPassthrough)
 		}
 	}
 
-	return nLine
+	blendRow(dst, targets, row, weights)
+	w.pushRow(line, dst)
 }
 
 // calculateVariance calcula a variância de um slice de uint8.
@@ -246,53 +167,85 @@ func calculateVariance(values []uint8) float64 {
 		return 0
 	}
 
-	var sum, sumSq float64
+	var sum, sumSq int64
 	for _, v := range values {
-		val := float64(v)
-		sum += val
-		sumSq += val * val
+		sum += int64(v)
+		sumSq += int64(v) * int64(v)
 	}
 
-	mean := sum / float64(len(values))
 	// Fórmula da variância: E[X^2] - (E[X])^2
-	return (sumSq / float64(len(values))) - (mean * mean)
+	return varianceFromSums(sum, sumSq, len(values))
 }
 
 // TimeTravaler processa um frame de vídeo completo, aplicando o filtro temporal em paralelo por linha.
 func TimeTravaler(videoFrames VideoFrames, currentFrame int, previousFrames int) {
+	TimeTravalerWithParams(videoFrames, currentFrame, previousFrames, DefaultTemporalParams())
+}
+
+// TimeTravalerWithParams é o TimeTravaler com limiares e alfas informados,
+// por exemplo os de DefaultTemporalParams escalados pelo ruído estimado do vídeo.
+func TimeTravalerWithParams(videoFrames VideoFrames, currentFrame int, previousFrames int, params TemporalParams) {
+	timeTravaler(videoFrames, currentFrame, previousFrames, params, nil)
+}
+
+// TimeTravalerWithDecisions é o TimeTravalerWithParams que também retorna qual ramo do filtro
+// foi escolhido para cada pixel. Quadros sem histórico suficiente ficam com DecisionSkipped.
+func TimeTravalerWithDecisions(videoFrames VideoFrames, currentFrame int, previousFrames int, params TemporalParams) DecisionMap {
+	frame := videoFrames[currentFrame]
+	decisions := NewDecisionMap(len(frame), frameWidth(frame))
+	timeTravaler(videoFrames, currentFrame, previousFrames, params, decisions)
+	return decisions
+}
+
+// timeTravaler é a implementação comum, que substitui as linhas do quadro currentFrame por linhas
+// novas; decisions pode ser nil.
+func timeTravaler(videoFrames VideoFrames, currentFrame int, previousFrames int, params TemporalParams, decisions DecisionMap) {
 	// Não processa se não houver frames anteriores suficientes.
 	if currentFrame <= previousFrames-1 {
 		return
 	}
 
 	frame := videoFrames[currentFrame]
-	totalLines := len(frame)
-
-	numWorkers := runtime.NumCPU() // Usa o número de CPUs disponíveis como workers.
+	filtered := NewFrame(len(frame), frameWidth(frame))
+	timeTravalerInto(filtered, videoFrames, currentFrame, previousFrames, params, decisions)
+	copy(frame, filtered)
+}
 
-	var wg sync.WaitGroup
-	lineChan := make(chan int, totalLines) // Canal para distribuir as linhas entre os workers.
+// timeTravalerInto filtra o quadro currentFrame gravando todas as linhas em dst, do mesmo tamanho,
+// sem modificar videoFrames. A janela é montada com os previousFrames quadros anteriores.
+func timeTravalerInto(dst Frame, videoFrames VideoFrames, currentFrame int, previousFrames int, params TemporalParams, decisions DecisionMap) {
+	frame := videoFrames[currentFrame]
+	window := newTemporalWindow(len(frame), frameWidth(frame), previousFrames, params.SimilarityThreshold)
+	window.seed(videoFrames[max(currentFrame-previousFrames, 0):currentFrame])
+	timeTravalerStep(dst, frame, currentFrame, window, params, decisions)
+}
 
-	// Goroutine para popular o canal com os índices das linhas.
-	go func() {
-		for i := 0; i < totalLines; i++ {
-			lineChan <- i
+// timeTravalerStep filtra frame, o quadro número currentFrame, com a janela dos quadros anteriores
+// já filtrados, grava o resultado em dst e desliza a janela para incluí-lo. Sem frames anteriores
+// suficientes, o quadro é copiado sem mudança.
+func timeTravalerStep(dst, frame Frame, currentFrame int, window *temporalWindow, params TemporalParams, decisions DecisionMap) {
+	defer window.slide()
+	if currentFrame <= window.size-1 || currentFrame <= 2 {
+		for y, row := range frame {
+			copy(dst[y], row)
+			window.pushRow(y, row)
 		}
-		close(lineChan)
-	}()
-
-	// Inicia os workers.
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			// Cada worker processa linhas do canal até que o canal seja fechado.
-			for lineIdx := range lineChan {
-				processedLine := TimeTravalerProcessLine(videoFrames, currentFrame, previousFrames, lineIdx)
-				frame[lineIdx] = processedLine // Atualiza a linha no frame original.
-			}
-		}()
+		return
 	}
 
-	wg.Wait() // Espera todos os workers terminarem.
+	totalLines := len(frame)
+	edges := ComputeEdgeMap(frame, DefaultEdgeOperator, params.EdgeThreshold)
+
+	// As faixas de linhas rodam no pool compartilhado com os estágios espaciais. Cada pixel tem a
+	// sua janela, então as linhas são independentes.
+	forEachTile(splitRows(totalLines, window.width, temporalBandRows), func(t tile) {
+		scratch := newLineScratch(window.width, params)
+		for lineIdx := t.Y0; lineIdx < t.Y1; lineIdx++ {
+			var lineDecisions []TemporalDecision
+			if decisions != nil {
+				lineDecisions = decisions[lineIdx]
+			}
+			window.filterLine(dst[lineIdx], frame[lineIdx], lineIdx, edges[lineIdx], params, lineDecisions, scratch)
+		}
+	})
 }
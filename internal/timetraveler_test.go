@@ -1,9 +1,11 @@
 package internal
 
 import (
+	"fmt"
 	"math"
 	"reflect"
 	"testing"
+	"video-processor/internal/degradation"
 )
 
 func TestMedian(t *testing.T) {
@@ -571,3 +573,33 @@ func BenchmarkTimeTravalerProcessLine(b *testing.B) {
 		TimeTravalerProcessLine(videoFrames, 5, 3, 50)
 	}
 }
+
+// Benchmark for filtering one frame with a sliding window that is already full, by window size
+func BenchmarkTimeTravalerProcessLine_Noisy(b *testing.B) {
+	videoFrames, err := degradation.Apply(createMovingSquareClip(16, 64, 256), degradation.Config{Seed: 3, GaussianSigma: 6})
+	if err != nil {
+		b.Fatal(err)
+	}
+	frame := videoFrames[len(videoFrames)-1]
+	params := DefaultTemporalParams()
+	edges := ComputeEdgeMap(frame, DefaultEdgeOperator, params.EdgeThreshold)
+	dst := NewFrame(len(frame), len(frame[0]))
+
+	for _, window := range []int{3, 7, 15, 30, 60} {
+		b.Run(fmt.Sprintf("window=%d", window), func(b *testing.B) {
+			state := newTemporalWindow(len(frame), len(frame[0]), window, params.SimilarityThreshold)
+			scratch := newLineScratch(len(frame[0]), params)
+			for i := range window {
+				state.seed(videoFrames[i%len(videoFrames) : i%len(videoFrames)+1])
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				for line := range frame {
+					state.filterLine(dst[line], frame[line], line, edges[line], params, nil, scratch)
+				}
+				state.slide()
+			}
+		})
+	}
+}
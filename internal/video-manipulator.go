@@ -2,7 +2,8 @@ package internal
 
 import (
 	"math"
-	"sort"
+	"slices"
+	"sync"
 )
 
 // Frame representa um único quadro em um vídeo, como uma fatia 2D de uint8 (pixels).
@@ -32,24 +33,7 @@ type PixelsRadius struct {
 // Recebe o quadro, as coordenadas centrais (y, x) e o tamanho do raio.
 // Retorna uma estrutura PixelsRadius contendo os pixels extraídos e informações relacionadas.
 func GetPixelRadius(frame Frame, y int, x int, radius int) PixelsRadius {
-	yMin := y - radius
-	yMax := y + radius
-
-	if yMin < 0 {
-		yMin = 0
-	}
-	if frameSizeY := len(frame); yMax >= frameSizeY {
-		yMax = frameSizeY - 1
-	}
-
-	xMin := x - radius
-	xMax := x + radius
-	if xMin < 0 {
-		xMin = 0
-	}
-	if frameSizeX := len(frame[0]); xMax >= frameSizeX {
-		xMax = frameSizeX - 1
-	}
+	yMin, yMax, xMin, xMax := pixelRadiusBounds(frame, y, x, radius)
 
 	// Calcula as dimensões da região de pixels.
 	regionHeight := yMax - yMin + 1
@@ -76,37 +60,66 @@ func GetPixelRadius(frame Frame, y int, x int, radius int) PixelsRadius {
 	}
 }
 
-// IsEdgePixel determina se o pixel central do PixelsRadius é um pixel de borda.
-// Utiliza um cálculo de gradiente simples (semelhante ao Sobel) e um limiar.
-// Retorna verdadeiro se o gradiente estiver acima do limiar, indicando uma borda.
-func (p PixelsRadius) IsEdgePixel(threshold float64) bool {
-	// Se a região de pixels for muito pequena para ter vizinhos, considera-se uma borda.
-	if len(p.Pixels) < 3 || len(p.Pixels[0]) < 3 {
-		return true
-	}
+// pixelRadiusBounds calcula a caixa delimitadora da região de raio radius em torno de (y, x),
+// cortada nas bordas do quadro.
+func pixelRadiusBounds(frame Frame, y, x, radius int) (yMin, yMax, xMin, xMax int) {
+	yMin, yMax = max(y-radius, 0), min(y+radius, len(frame)-1)
+	xMin, xMax = max(x-radius, 0), min(x+radius, len(frame[0])-1)
+	return yMin, yMax, xMin, xMax
+}
 
-	centerY, centerX := p.CenterY, p.CenterX
+// regionScratch são os buffers reaproveitados entre os pixels de um bloco: as linhas da região e os
+// vizinhos do pixel central, que também são ordenados no lugar para a mediana.
+type regionScratch struct {
+	rows      [][]uint8
+	neighbors []uint8
+}
 
-	// Se o pixel central estiver na borda da fatia Pixels, considera-se uma borda.
-	if centerY == 0 || centerY >= len(p.Pixels)-1 ||
-		centerX == 0 || centerX >= len(p.Pixels[0])-1 {
-		return true
+// newRegionScratch cria os buffers para regiões de raio radius.
+func newRegionScratch(radius int) *regionScratch {
+	side := 2*radius + 1
+	return &regionScratch{
+		rows:      make([][]uint8, 0, side),
+		neighbors: make([]uint8, 0, side*side),
 	}
+}
 
-	// Obtém os valores dos pixels vizinhos.
-	top := float64(p.Pixels[centerY-1][centerX])
-	bottom := float64(p.Pixels[centerY+1][centerX])
-	left := float64(p.Pixels[centerY][centerX-1])
-	right := float64(p.Pixels[centerY][centerX+1])
+// view é o GetPixelRadius sem cópia: as linhas da região apontam para o próprio quadro. A região
+// só vale até a próxima chamada e não pode ser modificada.
+func (s *regionScratch) view(frame Frame, y, x, radius int) PixelsRadius {
+	yMin, yMax, xMin, xMax := pixelRadiusBounds(frame, y, x, radius)
+	s.rows = s.rows[:0]
+	for row := yMin; row <= yMax; row++ {
+		s.rows = append(s.rows, frame[row][xMin:xMax+1])
+	}
 
-	// Calcula o gradiente nas direções X e Y.
-	gradientX := math.Abs(right-left) / 2.0
-	gradientY := math.Abs(bottom-top) / 2.0
-	// Calcula a magnitude do gradiente.
-	gradient := math.Sqrt(gradientX*gradientX + gradientY*gradientY)
+	return PixelsRadius{
+		CenterX:   x - xMin,
+		CenterY:   y - yMin,
+		OriginalX: x,
+		OriginalY: y,
+		Pixels:    s.rows,
+		YMin:      yMin,
+		YMax:      yMax,
+		XMin:      xMin,
+		XMax:      xMax,
+	}
+}
 
-	// Compara a magnitude do gradiente com o limiar.
-	return gradient > threshold
+// IsEdgePixel determina se o pixel central do PixelsRadius é um pixel de borda.
+// Utiliza o operador de Sobel (o mesmo do estágio temporal) e compara a magnitude com o limiar.
+// Retorna verdadeiro se o gradiente estiver acima do limiar, indicando uma borda.
+func (p PixelsRadius) IsEdgePixel(threshold float64) bool {
+	return p.IsEdgePixelWith(DefaultEdgeOperator, threshold)
+}
+
+// IsEdgePixelWith determina se o pixel central do PixelsRadius é uma borda usando o operador informado.
+// Se o pixel central não tiver os oito vizinhos dentro da região, considera-se uma borda.
+func (p PixelsRadius) IsEdgePixelWith(op GradientOperator, threshold float64) bool {
+	if len(p.Pixels) == 0 {
+		return true
+	}
+	return IsEdge(p.Pixels, p.CenterY, p.CenterX, op, threshold)
 }
 
 // CalculateVariance calcula a variância dos valores dos pixels dentro do PixelsRadius.
@@ -116,21 +129,27 @@ func (p PixelsRadius) CalculateVariance() float64 {
 		return 0
 	}
 
-	var sum, count float64
+	var sum, sumSq int64
+	var n int
 	// Calcula a soma de todos os valores de pixels e a contagem total de pixels.
 	for _, row := range p.Pixels {
 		for _, pixel := range row {
-			sum += float64(pixel)
-			count++
+			sum += int64(pixel)
+			sumSq += int64(pixel) * int64(pixel)
+			n++
 		}
 	}
 
-	if count == 0 {
+	if n == 0 {
 		return 0
 	}
+	if fixedPoint {
+		return varianceFixed(sum, sumSq, n)
+	}
 
 	// Calcula o valor médio (média) do pixel.
-	mean := sum / count
+	count := float64(n)
+	mean := float64(sum) / count
 	var variance float64
 
 	// Calcula a soma das diferenças quadradas da média.
@@ -149,12 +168,17 @@ func (p PixelsRadius) CalculateVariance() float64 {
 // Verifica a similaridade do pixel central com seus vizinhos.
 // Se a razão de similaridade estiver abaixo de um limiar, é considerado ruído.
 func (p PixelsRadius) IsNoisePixel() bool {
+	defaults := DefaultAdaptiveParams()
+	return p.IsNoisePixelWith(defaults.NoiseThreshold, defaults.NoiseRatio)
+}
+
+// IsNoisePixelWith é o IsNoisePixel com limiar de similaridade e razão mínima informados.
+func (p PixelsRadius) IsNoisePixelWith(threshold, minRatio float64) bool {
 	centerPixel := p.Pixels[p.CenterY][p.CenterX]
 	centerValue := float64(centerPixel)
 
-	similar := 0      // Contagem de vizinhos semelhantes ao pixel central.
-	total := 0        // Número total de vizinhos.
-	threshold := 15.0 // Limiar para determinar similaridade.
+	similar := 0 // Contagem de vizinhos semelhantes ao pixel central.
+	total := 0   // Número total de vizinhos.
 
 	// Itera sobre todos os pixels no raio.
 	for y, row := range p.Pixels {
@@ -179,7 +203,7 @@ func (p PixelsRadius) IsNoisePixel() bool {
 	// Calcula a razão de vizinhos similares para o total de vizinhos.
 	similarityRatio := float64(similar) / float64(total)
 	// Se a razão for baixa, o pixel é considerado ruído.
-	return similarityRatio < 0.3
+	return similarityRatio < minRatio
 }
 
 // ApplyAdaptiveFilter aplica um filtro ao pixel central do PixelsRadius.
@@ -189,15 +213,81 @@ func (p PixelsRadius) ApplyAdaptiveFilter() {
 		return
 	}
 
+	p.ApplyAdaptiveFilterWithEdge(p.IsEdgePixel(DefaultEdgeThreshold))
+}
+
+// ApplyAdaptiveFilterWithEdge aplica o filtro adaptativo usando uma classificação de borda já conhecida,
+// normalmente vinda de um EdgeMap calculado uma única vez para o quadro inteiro.
+func (p PixelsRadius) ApplyAdaptiveFilterWithEdge(isEdge bool) {
+	p.ApplyAdaptiveFilterWithParams(DefaultAdaptiveParams(), isEdge)
+}
+
+// ApplyAdaptiveFilterWithParams aplica o filtro adaptativo com limiares e alfas informados,
+// por exemplo os de DefaultAdaptiveParams escalados pelo ruído estimado do vídeo.
+func (p PixelsRadius) ApplyAdaptiveFilterWithParams(params AdaptiveParams, isEdge bool) {
+	p.applyAdaptiveFilter(params, isEdge)
+}
+
+// applyAdaptiveFilter aplica o filtro adaptativo e retorna a classe em que o pixel foi enquadrado.
+func (p PixelsRadius) applyAdaptiveFilter(params AdaptiveParams, isEdge bool) AdaptiveClass {
+	if len(p.Pixels) == 0 {
+		return AdaptiveUnfiltered
+	}
+	mixes := params.mixes()
+	target := p.adaptiveTarget(params, &mixes, isEdge, &regionScratch{})
+	p.Pixels[p.CenterY][p.CenterX] = target.apply(p.Pixels[p.CenterY][p.CenterX])
+	return target.class
+}
+
+// adaptiveMixes são os alfas do filtro adaptativo, convertidos uma vez para os kernels de mistura.
+type adaptiveMixes struct {
+	edge, smooth, mid, texture mix
+}
+
+// mixes converte os alfas dos parâmetros.
+func (p AdaptiveParams) mixes() adaptiveMixes {
+	return adaptiveMixes{
+		edge:    newMix(p.EdgeAlpha),
+		smooth:  newMix(p.SmoothAlpha),
+		mid:     newMix(p.MidAlpha),
+		texture: newMix(p.TextureAlpha),
+	}
+}
+
+// fullMix é o peso que troca o pixel pelo alvo, usado pelo filtro de mediana.
+var fullMix = newMix(1)
+
+// adaptiveTarget é a decisão do filtro adaptativo para um pixel: a classe e o alvo sum/count com
+// que o pixel é misturado pelo peso weight. O alvo é a mediana dos vizinhos, com count 1, ou a
+// média deles, com count igual ao número de vizinhos.
+type adaptiveTarget struct {
+	class  AdaptiveClass
+	sum    int
+	count  int
+	weight mix
+}
+
+// apply mistura current com o alvo. Pixels sem filtro ficam como estão.
+func (t adaptiveTarget) apply(current uint8) uint8 {
+	switch {
+	case t.count == 0:
+		return current
+	case t.count == 1:
+		return blend(uint8(t.sum), current, t.weight)
+	}
+	return blendMean(t.sum, t.count, current, t.weight)
+}
+
+// adaptiveTarget classifica o pixel central e escolhe o alvo com que ele é filtrado, sem modificar p.
+// O tipo de filtro depende se o pixel é uma borda, ruído ou da variância da vizinhança. Os vizinhos
+// são coletados e ordenados em scratch.neighbors.
+func (p PixelsRadius) adaptiveTarget(params AdaptiveParams, mixes *adaptiveMixes, isEdge bool, scratch *regionScratch) adaptiveTarget {
 	// Calcula as propriedades da região do pixel.
 	variance := p.CalculateVariance()
-	isEdge := p.IsEdgePixel(25.0) // Limiar para detecção de borda.
-	isNoise := p.IsNoisePixel()
-
-	centerPixel := p.Pixels[p.CenterY][p.CenterX]
+	isNoise := p.IsNoisePixelWith(params.NoiseThreshold, params.NoiseRatio)
 
 	// Coleta todos os pixels vizinhos.
-	var neighbors []uint8
+	neighbors := scratch.neighbors[:0]
 	for y, row := range p.Pixels {
 		for x, pixel := range row {
 			if y != p.CenterY || x != p.CenterX { // Exclui o pixel central.
@@ -206,32 +296,160 @@ func (p PixelsRadius) ApplyAdaptiveFilter() {
 		}
 	}
 
+	scratch.neighbors = neighbors
 	if len(neighbors) == 0 {
 		// Sem vizinhos, nada para filtrar.
-		return
+		return adaptiveTarget{class: AdaptiveUnfiltered}
 	}
 
-	// Aplica diferentes filtros com base nas características do pixel.
+	// Escolhe o filtro com base nas características do pixel.
+	var target adaptiveTarget
 	switch {
 	case isEdge:
-		// Para pixels de borda, aplica um filtro suave com um alfa pequeno para preservar as bordas.
-		p.Pixels[p.CenterY][p.CenterX] = p.applySoftFilter(neighbors, centerPixel, 0.1)
+		// Para pixels de borda, um filtro suave com um alfa pequeno para preservar as bordas.
+		target = adaptiveTarget{class: AdaptiveEdge, weight: mixes.edge}
 
 	case isNoise:
-		// Para pixels de ruído, aplica um filtro de mediana para remover o ruído.
-		p.Pixels[p.CenterY][p.CenterX] = p.applyMedianFilter(neighbors)
-
-	case variance < 50:
-		// Para regiões de baixa variância (áreas suaves), aplica um filtro de média com um alfa maior para suavização mais forte.
-		p.Pixels[p.CenterY][p.CenterX] = p.applyMeanFilter(neighbors, centerPixel, 0.7)
+		// Para pixels de ruído, um filtro de mediana para remover o ruído.
+		target = adaptiveTarget{class: AdaptiveNoise, weight: fullMix}
+
+	case variance < params.LowVariance:
+		// Para regiões de baixa variância (áreas suaves), um filtro de média com um alfa maior para suavização mais forte.
+		sum := 0
+		for _, pixel := range neighbors {
+			sum += int(pixel)
+		}
+		return adaptiveTarget{class: AdaptiveSmooth, sum: sum, count: len(neighbors), weight: mixes.smooth}
 
-	case variance < 200:
-		// Para regiões de média variância, aplica um filtro suave com um alfa moderado.
-		p.Pixels[p.CenterY][p.CenterX] = p.applySoftFilter(neighbors, centerPixel, 0.3)
+	case variance < params.MidVariance:
+		// Para regiões de média variância, um filtro suave com um alfa moderado.
+		target = adaptiveTarget{class: AdaptiveMid, weight: mixes.mid}
 
 	default:
-		// Para regiões de alta variância (áreas texturizadas), aplica um filtro suave com um alfa muito pequeno para preservar os detalhes.
-		p.Pixels[p.CenterY][p.CenterX] = p.applySoftFilter(neighbors, centerPixel, 0.05)
+		// Para regiões de alta variância (áreas texturizadas), um filtro suave com um alfa muito pequeno para preservar os detalhes.
+		target = adaptiveTarget{class: AdaptiveTexture, weight: mixes.texture}
+	}
+	// Os demais filtros misturam o pixel com a mediana dos vizinhos, que já são uma cópia.
+	target.sum, target.count = int(sortedMedian(neighbors)), 1
+	return target
+}
+
+// ApplyAdaptiveFilterFrame aplica o filtro adaptativo a todos os pixels de um quadro e retorna um novo quadro.
+// O mapa de bordas é calculado uma vez sobre o quadro original, e cada pixel é filtrado a partir
+// da sua vizinhança no quadro original, de modo que o resultado não depende da ordem de processamento.
+func ApplyAdaptiveFilterFrame(frame Frame, radius int) Frame {
+	return ApplyAdaptiveFilterFrameWithParams(frame, radius, DefaultAdaptiveParams())
+}
+
+// ApplyAdaptiveFilterFrameWithParams é o ApplyAdaptiveFilterFrame com limiares e alfas informados.
+func ApplyAdaptiveFilterFrameWithParams(frame Frame, radius int, params AdaptiveParams) Frame {
+	result, _ := ApplyAdaptiveFilterFrameWithStats(frame, radius, params)
+	return result
+}
+
+// ApplyAdaptiveFilterFrameWithStats é o ApplyAdaptiveFilterFrameWithParams que também conta
+// quantos pixels caíram em cada classe do filtro. O quadro é dividido em blocos processados em paralelo.
+func ApplyAdaptiveFilterFrameWithStats(frame Frame, radius int, params AdaptiveParams) (Frame, AdaptiveCounts) {
+	return applyAdaptiveTiled(frame, radius, params, defaultTileSize)
+}
+
+// applyAdaptiveTiled divide o quadro em blocos de size x size pixels processados no pool compartilhado.
+// Cada bloco lê a sua vizinhança, até radius pixels além das suas bordas (o halo), direto do quadro
+// de entrada, que não é modificado, e grava só os próprios pixels no resultado; por isso o resultado
+// é o mesmo com qualquer tamanho de bloco.
+//
+// Cada linha do bloco é filtrada em duas etapas: primeiro o alvo e o peso de cada pixel, depois as
+// misturas da linha inteira de uma vez, com blendRow para os alvos que são medianas e blendMeanRow
+// para os que são médias.
+func applyAdaptiveTiled(frame Frame, radius int, params AdaptiveParams, size int) (Frame, AdaptiveCounts) {
+	result := acquireFrameLike(frame)
+	var counts AdaptiveCounts
+	if len(frame) == 0 {
+		return result, counts
+	}
+
+	mixes := params.mixes()
+	var mu sync.Mutex
+	forEachTile(splitTiles(len(frame), len(frame[0]), size), func(t tile) {
+		var tileCounts AdaptiveCounts
+		scratch := newAdaptiveScratch(t.X1 - t.X0)
+		region := newRegionScratch(radius)
+		for y := t.Y0; y < t.Y1; y++ {
+			scratch.reset()
+			for x := t.X0; x < t.X1; x++ {
+				// A borda é avaliada no quadro original, como no mapa de bordas do quadro inteiro.
+				isEdge := IsEdge(frame, y, x, DefaultEdgeOperator, params.EdgeThreshold)
+				target := region.view(frame, y, x, radius).adaptiveTarget(params, &mixes, isEdge, region)
+				tileCounts.add(target.class)
+				scratch.add(x-t.X0, frame[y][x], target)
+			}
+			scratch.blend(result[y][t.X0:t.X1], frame[y][t.X0:t.X1])
+		}
+		mu.Lock()
+		counts.Add(tileCounts)
+		mu.Unlock()
+	})
+
+	return result, counts
+}
+
+// adaptiveScratch são os alvos e pesos de uma linha de um bloco do filtro adaptativo. Os alvos
+// inteiros (medianas) ficam alinhados com a linha; as médias ficam à parte, com a sua posição.
+type adaptiveScratch struct {
+	targets []uint8
+	weights []mix
+
+	meanAt      []int // Posição na linha de cada média.
+	meanSums    []int32
+	meanCounts  []int32
+	meanCurrent []uint8
+	meanWeights []mix
+	meanOut     []uint8
+}
+
+// newAdaptiveScratch cria os buffers para linhas de width pixels.
+func newAdaptiveScratch(width int) *adaptiveScratch {
+	return &adaptiveScratch{
+		targets:     make([]uint8, width),
+		weights:     make([]mix, width),
+		meanAt:      make([]int, 0, width),
+		meanSums:    make([]int32, 0, width),
+		meanCounts:  make([]int32, 0, width),
+		meanCurrent: make([]uint8, 0, width),
+		meanWeights: make([]mix, 0, width),
+		meanOut:     make([]uint8, width),
+	}
+}
+
+// reset esvazia as médias para a próxima linha.
+func (s *adaptiveScratch) reset() {
+	s.meanAt, s.meanSums, s.meanCounts = s.meanAt[:0], s.meanSums[:0], s.meanCounts[:0]
+	s.meanCurrent, s.meanWeights = s.meanCurrent[:0], s.meanWeights[:0]
+}
+
+// add guarda o alvo do pixel i da linha, cujo valor atual é current. Pixels sem filtro e pixels
+// misturados com uma média ficam com peso zero na mistura da linha.
+func (s *adaptiveScratch) add(i int, current uint8, target adaptiveTarget) {
+	s.targets[i], s.weights[i] = current, mix{}
+	switch {
+	case target.count == 1:
+		s.targets[i], s.weights[i] = uint8(target.sum), target.weight
+	case target.count > 1:
+		s.meanAt = append(s.meanAt, i)
+		s.meanSums = append(s.meanSums, int32(target.sum))
+		s.meanCounts = append(s.meanCounts, int32(target.count))
+		s.meanCurrent = append(s.meanCurrent, current)
+		s.meanWeights = append(s.meanWeights, target.weight)
+	}
+}
+
+// blend grava em dst a linha filtrada, a partir dos valores atuais current.
+func (s *adaptiveScratch) blend(dst, current []uint8) {
+	blendRow(dst, s.targets[:len(dst)], current, s.weights[:len(dst)])
+	out := s.meanOut[:len(s.meanAt)]
+	blendMeanRow(out, s.meanSums, s.meanCounts, s.meanCurrent, s.meanWeights)
+	for k, i := range s.meanAt {
+		dst[i] = out[k]
 	}
 }
 
@@ -242,16 +460,14 @@ func (p PixelsRadius) applyMedianFilter(neighbors []uint8) uint8 {
 		return p.Pixels[p.CenterY][p.CenterX] // Retorna o pixel atual se não houver vizinhos.
 	}
 
-	// Cria uma cópia dos vizinhos para ordenar.
-	sorted := make([]uint8, len(neighbors))
-	copy(sorted, neighbors)
-	// Ordena os vizinhos.
-	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i] < sorted[j]
-	})
+	// Ordena uma cópia dos vizinhos.
+	return sortedMedian(slices.Clone(neighbors))
+}
 
-	// Retorna o valor mediano.
-	return sorted[len(sorted)/2]
+// sortedMedian ordena values no lugar e retorna o valor mediano. values não pode estar vazio.
+func sortedMedian(values []uint8) uint8 {
+	slices.Sort(values)
+	return values[len(values)/2]
 }
 
 // applyMeanFilter substitui o pixel central por uma média ponderada de si mesmo e da média de seus vizinhos.
@@ -267,20 +483,8 @@ func (p PixelsRadius) applyMeanFilter(neighbors []uint8, current uint8, alpha fl
 		sum += int(pixel)
 	}
 
-	// Calcula a média dos valores dos pixels vizinhos.
-	mean := float64(sum) / float64(len(neighbors))
-	// Calcula o novo valor do pixel como uma média ponderada.
-	result := alpha*mean + (1-alpha)*float64(current)
-
-	// Limita o resultado ao intervalo de pixel válido [0, 255].
-	if result < 0 {
-		return 0
-	}
-	if result > 255 {
-		return 255
-	}
-
-	return uint8(result)
+	// Calcula o novo valor do pixel como uma média ponderada da média dos vizinhos e do pixel atual.
+	return adaptiveTarget{sum: sum, count: len(neighbors), weight: newMix(alpha)}.apply(current)
 }
 
 // applySoftFilter substitui o pixel central por uma média ponderada de si mesmo e da mediana de seus vizinhos.
@@ -290,26 +494,7 @@ func (p PixelsRadius) applySoftFilter(neighbors []uint8, current uint8, alpha fl
 		return current // Retorna o pixel atual se não houver vizinhos.
 	}
 
-	// Cria uma cópia dos vizinhos para ordenar.
-	sorted := make([]uint8, len(neighbors))
-	copy(sorted, neighbors)
-	// Ordena os vizinhos.
-	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i] < sorted[j]
-	})
-
-	// Obtém a mediana dos vizinhos.
-	median := sorted[len(sorted)/2]
-	// Calcula o novo valor do pixel como uma média ponderada.
-	result := alpha*float64(median) + (1-alpha)*float64(current)
-
-	// Limita o resultado ao intervalo de pixel válido [0, 255].
-	if result < 0 {
-		return 0
-	}
-	if result > 255 {
-		return 255
-	}
-
-	return uint8(result)
+	// Calcula o novo valor do pixel como uma média ponderada com a mediana dos vizinhos.
+	median := p.applyMedianFilter(neighbors)
+	return adaptiveTarget{sum: int(median), count: 1, weight: newMix(alpha)}.apply(current)
 }
@@ -0,0 +1,206 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// VisualMode identifica como a saída de revisão combina o vídeo original e o processado.
+type VisualMode int
+
+const (
+	// VisualNone desliga a saída de revisão.
+	VisualNone VisualMode = iota
+	// VisualSideBySide coloca o original à esquerda e o processado à direita.
+	VisualSideBySide
+	// VisualDiff mostra a diferença absoluta amplificada entre original e processado.
+	VisualDiff
+)
+
+// DefaultDiffGain é o ganho padrão da imagem de diferença: diferenças de 8 níveis ficam em 32.
+const DefaultDiffGain = 4.0
+
+// String retorna o nome do modo.
+func (m VisualMode) String() string {
+	switch m {
+	case VisualNone:
+		return "none"
+	case VisualSideBySide:
+		return "side-by-side"
+	case VisualDiff:
+		return "diff"
+	default:
+		return fmt.Sprintf("VisualMode(%d)", int(m))
+	}
+}
+
+// ParseVisualMode converte um nome de modo ("none", "side-by-side", "diff") no VisualMode correspondente.
+func ParseVisualMode(name string) (VisualMode, error) {
+	switch name {
+	case "none", "":
+		return VisualNone, nil
+	case "side-by-side":
+		return VisualSideBySide, nil
+	case "diff":
+		return VisualDiff, nil
+	default:
+		return 0, fmt.Errorf("modo de visualização desconhecido: %q", name)
+	}
+}
+
+// sideBySideSeparator é a largura, em pixels, da faixa branca entre os dois lados.
+const sideBySideSeparator = 2
+
+// SideBySide monta um quadro com o original à esquerda e o processado à direita,
+// separados por uma faixa branca.
+func SideBySide(original, processed Frame) (Frame, error) {
+	if err := checkSameFrameSize(original, processed); err != nil {
+		return nil, err
+	}
+
+	width := frameWidth(original)
+	result := make(Frame, len(original))
+	for y := range original {
+		row := make([]uint8, 2*width+sideBySideSeparator)
+		copy(row, original[y])
+		for x := width; x < width+sideBySideSeparator; x++ {
+			row[x] = 255
+		}
+		copy(row[width+sideBySideSeparator:], processed[y])
+		result[y] = row
+	}
+	return result, nil
+}
+
+// AbsDiff retorna |original - processado| multiplicado por gain, saturado em 255.
+// Pixels pretos são os que a filtragem não alterou.
+func AbsDiff(original, processed Frame, gain float64) (Frame, error) {
+	if err := checkSameFrameSize(original, processed); err != nil {
+		return nil, err
+	}
+	if gain <= 0 {
+		return nil, fmt.Errorf("ganho da diferença deve ser > 0, recebido %f", gain)
+	}
+
+	result := NewFrame(len(original), frameWidth(original))
+	for y := range original {
+		for x := range original[y] {
+			diff := math.Abs(float64(original[y][x]) - float64(processed[y][x]))
+			result[y][x] = uint8(math.Min(255, math.Round(diff*gain)))
+		}
+	}
+	return result, nil
+}
+
+// VisualizeVideo gera o vídeo de revisão no modo pedido, com o número de cada quadro
+// desenhado no canto superior esquerdo.
+func VisualizeVideo(original, processed VideoFrames, mode VisualMode, gain float64) (VideoFrames, error) {
+	if len(original) != len(processed) {
+		return nil, fmt.Errorf("número de quadros diferente: %d e %d", len(original), len(processed))
+	}
+
+	result := make(VideoFrames, len(original))
+	for i := range original {
+		var (
+			frame Frame
+			err   error
+		)
+		switch mode {
+		case VisualSideBySide:
+			frame, err = SideBySide(original[i], processed[i])
+		case VisualDiff:
+			frame, err = AbsDiff(original[i], processed[i], gain)
+		default:
+			return nil, fmt.Errorf("modo de visualização sem saída: %s", mode)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("quadro %d: %w", i, err)
+		}
+		DrawFrameNumber(frame, i)
+		result[i] = frame
+	}
+	return result, nil
+}
+
+// digitGlyphs é uma fonte 3x5 para os dígitos 0-9; cada linha é uma máscara de 3 bits.
+var digitGlyphs = [10][5]uint8{
+	{7, 5, 5, 5, 7}, // 0
+	{2, 6, 2, 2, 7}, // 1
+	{7, 1, 7, 4, 7}, // 2
+	{7, 1, 7, 1, 7}, // 3
+	{5, 5, 7, 1, 1}, // 4
+	{7, 4, 7, 1, 7}, // 5
+	{7, 4, 7, 5, 7}, // 6
+	{7, 1, 1, 1, 1}, // 7
+	{7, 5, 7, 5, 7}, // 8
+	{7, 5, 7, 1, 7}, // 9
+}
+
+// minusGlyph é o sinal de menos na mesma fonte.
+var minusGlyph = [5]uint8{0, 0, 7, 0, 0}
+
+const (
+	glyphWidth  = 3
+	glyphHeight = 5
+	glyphScale  = 2 // Cada pixel da fonte vira um bloco glyphScale x glyphScale.
+	glyphMargin = 1 // Borda preta ao redor do texto, em pixels da fonte.
+)
+
+// DrawFrameNumber desenha o número do quadro em branco sobre fundo preto no canto
+// superior esquerdo, com sinal de menos se for negativo. O que não couber no quadro é cortado.
+func DrawFrameNumber(frame Frame, number int) {
+	text := strconv.Itoa(number)
+	cell := (glyphWidth + 1) * glyphScale
+	boxHeight := (glyphHeight + 2*glyphMargin) * glyphScale
+	boxWidth := len(text)*cell + (2*glyphMargin-1)*glyphScale
+
+	for y := 0; y < boxHeight && y < len(frame); y++ {
+		for x := 0; x < boxWidth && x < len(frame[y]); x++ {
+			frame[y][x] = 0
+		}
+	}
+
+	for i, char := range text {
+		glyph, ok := glyphFor(char)
+		if !ok {
+			continue
+		}
+		left := glyphMargin*glyphScale + i*cell
+		for gy := 0; gy < glyphHeight; gy++ {
+			for gx := 0; gx < glyphWidth; gx++ {
+				if glyph[gy]&(1<<(glyphWidth-1-gx)) == 0 {
+					continue
+				}
+				for sy := 0; sy < glyphScale; sy++ {
+					y := (glyphMargin+gy)*glyphScale + sy
+					for sx := 0; sx < glyphScale; sx++ {
+						x := left + gx*glyphScale + sx
+						if y < len(frame) && x < len(frame[y]) {
+							frame[y][x] = 255
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// glyphFor retorna o glifo de um caractere do número: um dígito ou o sinal de menos.
+func glyphFor(char rune) ([glyphHeight]uint8, bool) {
+	switch {
+	case char == '-':
+		return minusGlyph, true
+	case char >= '0' && char <= '9':
+		return digitGlyphs[char-'0'], true
+	}
+	return [glyphHeight]uint8{}, false
+}
+
+// checkSameFrameSize retorna erro se os dois quadros não tiverem as mesmas dimensões.
+func checkSameFrameSize(a, b Frame) error {
+	if len(a) != len(b) || frameWidth(a) != frameWidth(b) {
+		return fmt.Errorf("dimensões diferentes: %dx%d e %dx%d", frameWidth(a), len(a), frameWidth(b), len(b))
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package internal
+
+import "testing"
+
+func TestSideBySide(t *testing.T) {
+	original := createTestFrame(4, 3, 10)
+	processed := createTestFrame(4, 3, 20)
+
+	result, err := SideBySide(original, processed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 4 || len(result[0]) != 2*3+sideBySideSeparator {
+		t.Fatalf("result is %dx%d", len(result[0]), len(result))
+	}
+	if result[2][0] != 10 || result[2][3] != 255 || result[2][len(result[2])-1] != 20 {
+		t.Errorf("row = %v, expected original | separator | processed", result[2])
+	}
+
+	if _, err := SideBySide(original, createTestFrame(3, 3, 0)); err == nil {
+		t.Error("expected error for mismatched sizes")
+	}
+}
+
+func TestAbsDiff(t *testing.T) {
+	original := createTestFrame(2, 2, 100)
+	processed := createTestFrame(2, 2, 100)
+	processed[0][0] = 90
+	processed[1][1] = 0
+
+	result, err := AbsDiff(original, processed, DefaultDiffGain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result[0][0] != 40 || result[0][1] != 0 || result[1][1] != 255 {
+		t.Errorf("diff = %v, expected [[40 0] [0 255]]", result)
+	}
+
+	if _, err := AbsDiff(original, processed, 0); err == nil {
+		t.Error("expected error for zero gain")
+	}
+}
+
+func TestDrawFrameNumber(t *testing.T) {
+	frame := createTestFrame(20, 40, 128)
+	DrawFrameNumber(frame, 10)
+
+	// O canto superior esquerdo é a borda preta do texto.
+	if frame[0][0] != 0 {
+		t.Errorf("frame[0][0] = %d, expected black background", frame[0][0])
+	}
+	white := 0
+	for y := range frame {
+		for x := range frame[y] {
+			if frame[y][x] == 255 {
+				white++
+			}
+		}
+	}
+	// "1" tem 8 pixels acesos e "0" tem 12, cada um ampliado glyphScale².
+	if expected := (8 + 12) * glyphScale * glyphScale; white != expected {
+		t.Errorf("white pixels = %d, expected %d", white, expected)
+	}
+	if frame[19][39] != 128 {
+		t.Error("pixels outside the overlay should be untouched")
+	}
+
+	// Quadros menores que o texto não podem causar pânico.
+	DrawFrameNumber(createTestFrame(3, 3, 0), 12345)
+}
+
+func TestDrawFrameNumber_Negative(t *testing.T) {
+	frame := createTestFrame(20, 40, 128)
+	DrawFrameNumber(frame, -7)
+
+	white := 0
+	for y := range frame {
+		for x := range frame[y] {
+			if frame[y][x] == 255 {
+				white++
+			}
+		}
+	}
+	// "-" tem 3 pixels acesos e "7" tem 7.
+	if expected := (3 + 7) * glyphScale * glyphScale; white != expected {
+		t.Errorf("white pixels = %d, expected %d", white, expected)
+	}
+}
+
+func TestVisualizeVideo(t *testing.T) {
+	original := VideoFrames{createTestFrame(12, 30, 50), createTestFrame(12, 30, 50)}
+	processed := VideoFrames{createTestFrame(12, 30, 50), createTestFrame(12, 30, 60)}
+
+	diff, err := VisualizeVideo(original, processed, VisualDiff, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff[0][11][29] != 0 || diff[1][11][29] != 10 {
+		t.Errorf("diff corners = %d, %d; expected 0, 10", diff[0][11][29], diff[1][11][29])
+	}
+	if original[1][0][0] != 50 {
+		t.Error("VisualizeVideo must not draw on the input frames")
+	}
+
+	if _, err := VisualizeVideo(original, processed[:1], VisualSideBySide, 1); err == nil {
+		t.Error("expected error for different frame counts")
+	}
+	if _, err := VisualizeVideo(original, processed, VisualNone, 1); err == nil {
+		t.Error("expected error for VisualNone")
+	}
+}
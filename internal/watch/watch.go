@@ -0,0 +1,262 @@
+// Package watch implementa o modo de pasta monitorada: vídeos que chegam na caixa de entrada são
+// processados quando param de crescer, o resultado vai para a caixa de saída e o original para o
+// arquivo, ou para a pasta de falhas junto com o erro.
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"video-processor/internal/batch"
+)
+
+// Dirs são as pastas usadas pelo Watcher.
+type Dirs struct {
+	Inbox   string // Onde os vídeos chegam.
+	Outbox  string // Resultados processados.
+	Archive string // Originais processados com sucesso.
+	Failed  string // Originais que falharam, cada um com um <nome>.error ao lado.
+}
+
+// Options configura o Watcher.
+type Options struct {
+	// Pattern filtra os nomes dos arquivos (sintaxe de filepath.Match); vazio aceita todos.
+	Pattern string
+	// Ext, se definido, substitui a extensão das saídas, como .y4m.
+	Ext string
+	// Interval é o tempo entre duas varreduras da caixa de entrada; 0 usa DefaultInterval.
+	Interval time.Duration
+	// StableFor é quanto tempo o tamanho de um arquivo precisa ficar sem mudar para que ele seja
+	// considerado completo. Um arquivo só é processado depois de visto em duas varreduras.
+	StableFor time.Duration
+	// Logger recebe os eventos; nil usa slog.Default().
+	Logger *slog.Logger
+}
+
+// DefaultInterval é o intervalo padrão entre varreduras.
+const DefaultInterval = 5 * time.Second
+
+// errorSuffix é a extensão do arquivo com o erro de um original que falhou.
+const errorSuffix = ".error"
+
+// Watcher monitora a caixa de entrada e processa os arquivos novos, um por vez.
+type Watcher struct {
+	dirs    Dirs
+	options Options
+	run     batch.Runner
+	logger  *slog.Logger
+	now     func() time.Time
+	seen    map[string]fileState
+}
+
+// fileState é o que foi visto de um arquivo nas varreduras anteriores.
+type fileState struct {
+	size    int64
+	modTime time.Time
+	since   time.Time // Quando o tamanho e a data foram vistos pela primeira vez com esses valores.
+}
+
+// New cria as pastas, se necessário, e o Watcher. run recebe a saída final na caixa de saída em
+// task.Output e grava num caminho temporário, renomeado quando termina sem erro.
+func New(dirs Dirs, run batch.Runner, options Options) (*Watcher, error) {
+	if _, err := filepath.Match(options.Pattern, ""); err != nil {
+		return nil, fmt.Errorf("padrão inválido %q: %w", options.Pattern, err)
+	}
+	for _, dir := range []string{dirs.Inbox, dirs.Outbox, dirs.Archive, dirs.Failed} {
+		if dir == "" {
+			return nil, errors.New("as pastas de entrada, saída, arquivo e falhas são obrigatórias")
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	if options.Interval <= 0 {
+		options.Interval = DefaultInterval
+	}
+	logger := options.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Watcher{dirs: dirs, options: options, run: run, logger: logger, now: time.Now, seen: make(map[string]fileState)}, nil
+}
+
+// Run varre a caixa de entrada a cada Interval até ctx ser cancelado. Um arquivo interrompido pelo
+// cancelamento fica na caixa de entrada e é processado de novo na próxima execução.
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.options.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.Poll(ctx); err != nil {
+			w.logger.Error("erro ao varrer a caixa de entrada", "dir", w.dirs.Inbox, "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll faz uma varredura: registra o tamanho dos arquivos novos e processa, em ordem de nome, os
+// que estão estáveis. Retorna o resultado de cada arquivo processado.
+func (w *Watcher) Poll(ctx context.Context) ([]batch.Result, error) {
+	ready, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+	var results []batch.Result
+	for _, name := range ready {
+		if ctx.Err() != nil {
+			break
+		}
+		results = append(results, w.process(ctx, name))
+	}
+	return results, nil
+}
+
+// scan lista a caixa de entrada, atualiza o estado visto de cada arquivo e retorna os estáveis.
+// Arquivos ocultos, como cópias em andamento, e os que não casam com o padrão são ignorados.
+func (w *Watcher) scan() ([]string, error) {
+	entries, err := os.ReadDir(w.dirs.Inbox)
+	if err != nil {
+		return nil, err
+	}
+	now := w.now()
+	current := make(map[string]fileState, len(entries))
+	var ready []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if ok, _ := filepath.Match(w.options.Pattern, name); w.options.Pattern != "" && !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removido entre a listagem e o Stat.
+		}
+
+		state := fileState{size: info.Size(), modTime: info.ModTime(), since: now}
+		previous, seen := w.seen[name]
+		if seen && previous.size == state.size && previous.modTime.Equal(state.modTime) {
+			state.since = previous.since
+			if now.Sub(state.since) >= w.options.StableFor {
+				ready = append(ready, name)
+			}
+		}
+		current[name] = state
+	}
+	w.seen = current
+	sort.Strings(ready)
+	return ready, nil
+}
+
+// process executa o pipeline num arquivo estável e move o original para o arquivo ou para as falhas.
+func (w *Watcher) process(ctx context.Context, name string) batch.Result {
+	output := filepath.Join(w.dirs.Outbox, name)
+	if w.options.Ext != "" {
+		output = strings.TrimSuffix(output, filepath.Ext(output)) + w.options.Ext
+	}
+	task := batch.Task{Rel: name, Input: filepath.Join(w.dirs.Inbox, name), Output: uniquePath(output)}
+	result := batch.Result{Task: task}
+	logger := w.logger.With("file", name)
+	logger.Info("processando", "output", task.Output)
+
+	start := time.Now()
+	partial := task.Partial()
+	err := w.run(ctx, task, partial)
+	if err == nil {
+		err = os.Rename(partial, task.Output)
+	}
+	result.Elapsed = time.Since(start)
+	delete(w.seen, name)
+
+	if err != nil {
+		os.Remove(partial)
+		result.Status, result.Err = batch.StatusFailed, err
+		if ctx.Err() != nil {
+			logger.Warn("interrompido; o arquivo fica na caixa de entrada", "err", err)
+			return result
+		}
+		logger.Error("falhou", "err", err)
+		if errMove := w.fail(task.Input, name, err); errMove != nil {
+			logger.Error("erro ao mover para as falhas", "err", errMove)
+		}
+		return result
+	}
+
+	result.Status = batch.StatusOK
+	if err := moveFile(task.Input, uniquePath(filepath.Join(w.dirs.Archive, name))); err != nil {
+		// O resultado já está na caixa de saída; o original fica na entrada e seria processado de
+		// novo, então o erro é reportado como falha.
+		result.Status, result.Err = batch.StatusFailed, fmt.Errorf("arquivando o original: %w", err)
+		logger.Error("erro ao arquivar o original", "err", err)
+		return result
+	}
+	logger.Info("concluído", "output", task.Output, "elapsed", result.Elapsed.Round(time.Millisecond))
+	return result
+}
+
+// fail move o original para a pasta de falhas e grava o erro ao lado dele.
+func (w *Watcher) fail(input, name string, cause error) error {
+	target := uniquePath(filepath.Join(w.dirs.Failed, name))
+	if err := moveFile(input, target); err != nil {
+		return err
+	}
+	message := fmt.Sprintf("%s\n%s\n", w.now().Format(time.RFC3339), cause)
+	return os.WriteFile(target+errorSuffix, []byte(message), 0o644)
+}
+
+// uniquePath retorna path, ou path com um sufixo numérico antes da extensão se ele já existir.
+func uniquePath(path string) string {
+	if _, err := os.Lstat(path); errors.Is(err, os.ErrNotExist) {
+		return path
+	}
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := base + "-" + strconv.Itoa(i) + ext
+		if _, err := os.Lstat(candidate); errors.Is(err, os.ErrNotExist) {
+			return candidate
+		}
+	}
+}
+
+// moveFile renomeia o arquivo ou, se as pastas estão em sistemas de arquivos diferentes, copia
+// e remove o original.
+func moveFile(from, to string) error {
+	err := os.Rename(from, to)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	source, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	target, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(target, source)
+	if errClose := target.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		os.Remove(to)
+		return err
+	}
+	return os.Remove(from)
+}
@@ -0,0 +1,190 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"video-processor/internal/batch"
+)
+
+// Helper function to create the watcher folders under a temporary directory
+func createDirs(t *testing.T) Dirs {
+	t.Helper()
+	root := t.TempDir()
+	return Dirs{
+		Inbox:   filepath.Join(root, "inbox"),
+		Outbox:  filepath.Join(root, "outbox"),
+		Archive: filepath.Join(root, "archive"),
+		Failed:  filepath.Join(root, "failed"),
+	}
+}
+
+// Helper function to write a file with the given contents
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Helper function for a runner that copies the input to the output
+func copyRunner(ctx context.Context, task batch.Task, output string) error {
+	data, err := os.ReadFile(task.Input)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(output, data, 0o644)
+}
+
+// Helper function to list the names in a directory
+func listDir(t *testing.T, dir string) string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func TestWatcher_ProcessesStableFiles(t *testing.T) {
+	dirs := createDirs(t)
+	w, err := New(dirs, copyRunner, Options{Pattern: "*.mp4", Ext: ".y4m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dirs.Inbox, "a.mp4"), "video a")
+	writeFile(t, filepath.Join(dirs.Inbox, ".b.mp4"), "uploading")
+	writeFile(t, filepath.Join(dirs.Inbox, "notes.txt"), "ignored")
+
+	results, err := w.Poll(context.Background())
+	if err != nil || len(results) != 0 {
+		t.Fatalf("first poll processed %d files (err %v); files must be seen twice", len(results), err)
+	}
+	results, err = w.Poll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Status != batch.StatusOK {
+		t.Fatalf("results = %+v", results)
+	}
+
+	if got := listDir(t, dirs.Outbox); got != "a.y4m" {
+		t.Errorf("outbox = %q", got)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dirs.Outbox, "a.y4m")); string(data) != "video a" {
+		t.Errorf("output = %q", data)
+	}
+	if got := listDir(t, dirs.Archive); got != "a.mp4" {
+		t.Errorf("archive = %q", got)
+	}
+	if got := listDir(t, dirs.Inbox); got != ".b.mp4,notes.txt" {
+		t.Errorf("inbox = %q", got)
+	}
+}
+
+func TestWatcher_WaitsUntilSizeIsStable(t *testing.T) {
+	dirs := createDirs(t)
+	w, err := New(dirs, copyRunner, Options{StableFor: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return clock }
+	path := filepath.Join(dirs.Inbox, "a.mp4")
+	writeFile(t, path, "part")
+
+	w.Poll(context.Background())
+	clock = clock.Add(2 * time.Minute)
+	writeFile(t, path, "part plus more")
+	if results, _ := w.Poll(context.Background()); len(results) != 0 {
+		t.Fatal("processed a file whose size just changed")
+	}
+	clock = clock.Add(30 * time.Second)
+	if results, _ := w.Poll(context.Background()); len(results) != 0 {
+		t.Fatal("processed a file before StableFor")
+	}
+	clock = clock.Add(30 * time.Second)
+	if results, _ := w.Poll(context.Background()); len(results) != 1 {
+		t.Fatal("stable file was not processed")
+	}
+}
+
+func TestWatcher_FailureMovesOriginalWithError(t *testing.T) {
+	dirs := createDirs(t)
+	failing := func(ctx context.Context, task batch.Task, output string) error {
+		writeFile(t, output, "half")
+		return errors.New("vídeo corrompido")
+	}
+	w, err := New(dirs, failing, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dirs.Inbox, "a.mp4"), "video a")
+	w.Poll(context.Background())
+	results, _ := w.Poll(context.Background())
+	if len(results) != 1 || results[0].Status != batch.StatusFailed {
+		t.Fatalf("results = %+v", results)
+	}
+
+	if got := listDir(t, dirs.Failed); got != "a.mp4,a.mp4.error" {
+		t.Errorf("failed = %q", got)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dirs.Failed, "a.mp4.error")); !strings.Contains(string(data), "vídeo corrompido") {
+		t.Errorf("error file = %q", data)
+	}
+	if got := listDir(t, dirs.Outbox); got != "" {
+		t.Errorf("outbox should be empty, got %q", got)
+	}
+}
+
+func TestWatcher_CancelledFileStaysInInbox(t *testing.T) {
+	dirs := createDirs(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupted := func(ctx context.Context, task batch.Task, output string) error {
+		cancel()
+		return ctx.Err()
+	}
+	w, err := New(dirs, interrupted, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dirs.Inbox, "a.mp4"), "video a")
+	w.Poll(ctx)
+	w.Poll(ctx)
+
+	if got := listDir(t, dirs.Inbox); got != "a.mp4" {
+		t.Errorf("inbox = %q", got)
+	}
+	if got := listDir(t, dirs.Failed); got != "" {
+		t.Errorf("cancelled file moved to failed: %q", got)
+	}
+}
+
+func TestWatcher_DoesNotOverwriteExistingNames(t *testing.T) {
+	dirs := createDirs(t)
+	w, err := New(dirs, copyRunner, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dirs.Outbox, "a.mp4"), "old result")
+	writeFile(t, filepath.Join(dirs.Archive, "a.mp4"), "old original")
+	writeFile(t, filepath.Join(dirs.Inbox, "a.mp4"), "new")
+	w.Poll(context.Background())
+	w.Poll(context.Background())
+
+	if got := listDir(t, dirs.Outbox); got != "a-1.mp4,a.mp4" {
+		t.Errorf("outbox = %q", got)
+	}
+	if got := listDir(t, dirs.Archive); got != "a-1.mp4,a.mp4" {
+		t.Errorf("archive = %q", got)
+	}
+}
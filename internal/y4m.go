@@ -0,0 +1,251 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// y4mSignature é o início obrigatório do cabeçalho de um arquivo YUV4MPEG2.
+const y4mSignature = "YUV4MPEG2"
+
+// Y4MHeader descreve o cabeçalho de um arquivo Y4M.
+type Y4MHeader struct {
+	Width, Height  int
+	FPSNum, FPSDen int    // Taxa de quadros como fração (24:1, 30000:1001...).
+	Colorspace     string // "mono", "420jpeg", "420"... Vazio equivale a 420.
+}
+
+// FPS retorna a taxa de quadros como número.
+func (h Y4MHeader) FPS() float64 {
+	if h.FPSDen == 0 {
+		return 0
+	}
+	return float64(h.FPSNum) / float64(h.FPSDen)
+}
+
+// chromaSize retorna quantos bytes de crominância seguem o plano Y em cada quadro.
+func (h Y4MHeader) chromaSize() (int, error) {
+	chromaW, chromaH := (h.Width+1)/2, (h.Height+1)/2
+	switch {
+	case h.Colorspace == "mono":
+		return 0, nil
+	case h.Colorspace == "" || strings.HasPrefix(h.Colorspace, "420"):
+		return 2 * chromaW * chromaH, nil
+	case h.Colorspace == "422":
+		return 2 * chromaW * h.Height, nil
+	case h.Colorspace == "444":
+		return 2 * h.Width * h.Height, nil
+	default:
+		return 0, fmt.Errorf("espaço de cor Y4M não suportado: %q", h.Colorspace)
+	}
+}
+
+// Y4MReader lê quadros de um fluxo Y4M, um por vez. Só o plano de luminância (Y) é mantido,
+// pois todo o pipeline trabalha em escala de cinza.
+type Y4MReader struct {
+	reader *bufio.Reader
+	header Y4MHeader
+	chroma int
+}
+
+// NewY4MReader lê e valida o cabeçalho do fluxo.
+func NewY4MReader(r io.Reader) (*Y4MReader, error) {
+	reader := bufio.NewReader(r)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("cabeçalho Y4M incompleto: %w", err)
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != y4mSignature {
+		return nil, errors.New("arquivo não é YUV4MPEG2")
+	}
+
+	header := Y4MHeader{FPSNum: 25, FPSDen: 1}
+	for _, field := range fields[1:] {
+		value := field[1:]
+		switch field[0] {
+		case 'W':
+			header.Width, err = strconv.Atoi(value)
+		case 'H':
+			header.Height, err = strconv.Atoi(value)
+		case 'F':
+			num, den, ok := strings.Cut(value, ":")
+			if !ok {
+				return nil, fmt.Errorf("taxa de quadros Y4M inválida: %q", value)
+			}
+			if header.FPSNum, err = strconv.Atoi(num); err == nil {
+				header.FPSDen, err = strconv.Atoi(den)
+			}
+		case 'C':
+			header.Colorspace = value
+		}
+		if err != nil {
+			return nil, fmt.Errorf("campo Y4M inválido %q: %w", field, err)
+		}
+	}
+
+	if header.Width <= 0 || header.Height <= 0 {
+		return nil, fmt.Errorf("dimensões Y4M inválidas: %dx%d", header.Width, header.Height)
+	}
+	chroma, err := header.chromaSize()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Y4MReader{reader: reader, header: header, chroma: chroma}, nil
+}
+
+// Header retorna o cabeçalho lido.
+func (r *Y4MReader) Header() Y4MHeader {
+	return r.header
+}
+
+// ReadFrame lê o próximo quadro. Retorna io.EOF quando não há mais quadros.
+func (r *Y4MReader) ReadFrame() (Frame, error) {
+	line, err := r.reader.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("marcador FRAME incompleto: %w", err)
+	}
+	if !strings.HasPrefix(line, "FRAME") {
+		return nil, fmt.Errorf("marcador FRAME esperado, encontrado %q", strings.TrimSpace(line))
+	}
+
+	frame := NewFrame(r.header.Height, r.header.Width)
+	for y := range frame {
+		if _, err := io.ReadFull(r.reader, frame[y]); err != nil {
+			return nil, fmt.Errorf("quadro Y4M truncado: %w", err)
+		}
+	}
+	if _, err := r.reader.Discard(r.chroma); err != nil {
+		return nil, fmt.Errorf("crominância Y4M truncada: %w", err)
+	}
+
+	return frame, nil
+}
+
+// ReadAll lê todos os quadros restantes.
+func (r *Y4MReader) ReadAll() (VideoFrames, error) {
+	var frames VideoFrames
+	for {
+		frame, err := r.ReadFrame()
+		if err == io.EOF {
+			return frames, nil
+		}
+		if err != nil {
+			return frames, err
+		}
+		frames = append(frames, frame)
+	}
+}
+
+// Y4MWriter grava quadros em escala de cinza num fluxo Y4M monocromático.
+type Y4MWriter struct {
+	writer *bufio.Writer
+	header Y4MHeader
+}
+
+// NewY4MWriter grava o cabeçalho e retorna o escritor. O fps é convertido numa fração exata
+// quando inteiro, ou em base 1001 (29,97 vira 30000:1001).
+func NewY4MWriter(w io.Writer, width, height int, fps float64) (*Y4MWriter, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("dimensões Y4M inválidas: %dx%d", width, height)
+	}
+
+	header := Y4MHeader{Width: width, Height: height, Colorspace: "mono"}
+	if fps == math.Trunc(fps) {
+		header.FPSNum, header.FPSDen = int(fps), 1
+	} else {
+		header.FPSNum, header.FPSDen = int(math.Round(fps*1001)), 1001
+	}
+
+	writer := bufio.NewWriter(w)
+	_, err := fmt.Fprintf(writer, "%s W%d H%d F%d:%d Ip A1:1 C%s\n",
+		y4mSignature, header.Width, header.Height, header.FPSNum, header.FPSDen, header.Colorspace)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Y4MWriter{writer: writer, header: header}, nil
+}
+
+// WriteFrame grava um quadro; as dimensões precisam ser as do cabeçalho.
+func (w *Y4MWriter) WriteFrame(frame Frame) error {
+	if len(frame) != w.header.Height || (len(frame) > 0 && len(frame[0]) != w.header.Width) {
+		return fmt.Errorf("quadro %dx%d não corresponde ao cabeçalho Y4M %dx%d",
+			frameWidth(frame), len(frame), w.header.Width, w.header.Height)
+	}
+	if _, err := w.writer.WriteString("FRAME\n"); err != nil {
+		return err
+	}
+	for _, row := range frame {
+		if _, err := w.writer.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush descarrega os dados pendentes no fluxo de saída.
+func (w *Y4MWriter) Flush() error {
+	return w.writer.Flush()
+}
+
+// ReadY4MFile lê todos os quadros de um arquivo Y4M.
+func ReadY4MFile(path string) (VideoFrames, Y4MHeader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, Y4MHeader{}, err
+	}
+	defer file.Close()
+
+	reader, err := NewY4MReader(file)
+	if err != nil {
+		return nil, Y4MHeader{}, fmt.Errorf("%s: %w", path, err)
+	}
+	frames, err := reader.ReadAll()
+	if err != nil {
+		return nil, Y4MHeader{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return frames, reader.Header(), nil
+}
+
+// WriteY4MFile grava todos os quadros num arquivo Y4M monocromático.
+func WriteY4MFile(path string, frames VideoFrames, fps float64) error {
+	if len(frames) == 0 {
+		return errors.New("nenhum quadro para gravar")
+	}
+
+	var buffer bytes.Buffer
+	writer, err := NewY4MWriter(&buffer, frameWidth(frames[0]), len(frames[0]), fps)
+	if err != nil {
+		return err
+	}
+	for _, frame := range frames {
+		if err := writer.WriteFrame(frame); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buffer.Bytes(), 0o644)
+}
+
+// frameWidth retorna a largura de um quadro, ou zero para um quadro vazio.
+func frameWidth(frame Frame) int {
+	if len(frame) == 0 {
+		return 0
+	}
+	return len(frame[0])
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestY4M_RoundTrip(t *testing.T) {
+	frames := VideoFrames{createTestFrame(3, 5, 10), createRampWithEdgeFrame(3, 5)}
+
+	var buffer bytes.Buffer
+	writer, err := NewY4MWriter(&buffer, 5, 3, 29.97)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, frame := range frames {
+		if err := writer.WriteFrame(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewY4MReader(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := reader.Header()
+	if header.Width != 5 || header.Height != 3 || header.FPSNum != 30000 || header.FPSDen != 1001 {
+		t.Errorf("header = %+v", header)
+	}
+
+	read, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(frames) {
+		t.Fatalf("read %d frames, expected %d", len(read), len(frames))
+	}
+	for i := range frames {
+		for y := range frames[i] {
+			if !bytes.Equal(read[i][y], frames[i][y]) {
+				t.Errorf("frame %d row %d = %v, expected %v", i, y, read[i][y], frames[i][y])
+			}
+		}
+	}
+}
+
+func TestY4M_SkipsChroma(t *testing.T) {
+	// 4x2 em 4:2:0: 8 bytes de Y seguidos de 2x1 de U e de V.
+	data := "YUV4MPEG2 W4 H2 F25:1 C420jpeg\nFRAME\n" +
+		"\x01\x02\x03\x04\x05\x06\x07\x08" + "\xAA\xAA\xBB\xBB" +
+		"FRAME\n" + "\x09\x09\x09\x09\x09\x09\x09\x09" + "\xAA\xAA\xBB\xBB"
+
+	reader, err := NewY4MReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := reader.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first[1][3] != 8 {
+		t.Errorf("first[1][3] = %d, expected 8", first[1][3])
+	}
+	second, err := reader.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second[0][0] != 9 {
+		t.Errorf("second[0][0] = %d, expected 9", second[0][0])
+	}
+	if _, err := reader.ReadFrame(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestY4M_InvalidInput(t *testing.T) {
+	inputs := []string{
+		"",
+		"RIFF W4 H2\n",
+		"YUV4MPEG2 W0 H2\n",
+		"YUV4MPEG2 W4 H2 C411\n",
+		"YUV4MPEG2 W4 H2 Fabc\n",
+	}
+	for _, input := range inputs {
+		if _, err := NewY4MReader(strings.NewReader(input)); err == nil {
+			t.Errorf("NewY4MReader(%q) expected error", input)
+		}
+	}
+
+	reader, err := NewY4MReader(strings.NewReader("YUV4MPEG2 W4 H2 Cmono\nFRAME\n\x01\x02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.ReadFrame(); err == nil || err == io.EOF {
+		t.Errorf("truncated frame: expected error, got %v", err)
+	}
+}
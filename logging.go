@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// opcoesLog são as flags de log comuns a todos os comandos.
+type opcoesLog struct {
+	nivel       string
+	formatoJSON bool
+	silencioso  bool
+}
+
+// registrarFlagsLog registra as flags de log no FlagSet.
+func registrarFlagsLog(fs *flag.FlagSet) *opcoesLog {
+	opcoes := &opcoesLog{}
+	fs.StringVar(&opcoes.nivel, "log-level", "info", "nível mínimo de log: debug, info, warn ou error")
+	fs.BoolVar(&opcoes.formatoJSON, "log-json", false, "grava os logs em JSON, uma linha por registro")
+	fs.BoolVar(&opcoes.silencioso, "quiet", false, "mostra só erros (equivale a -log-level error)")
+	return opcoes
+}
+
+// configurar cria o logger em stderr conforme as flags e o define como padrão do slog.
+func (o *opcoesLog) configurar() error {
+	var nivel slog.Level
+	switch strings.ToLower(o.nivel) {
+	case "debug":
+		nivel = slog.LevelDebug
+	case "info":
+		nivel = slog.LevelInfo
+	case "warn", "warning":
+		nivel = slog.LevelWarn
+	case "error":
+		nivel = slog.LevelError
+	default:
+		return fmt.Errorf("nível de log desconhecido: %q", o.nivel)
+	}
+	if o.silencioso {
+		nivel = slog.LevelError
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: nivel}
+	var handler slog.Handler
+	if o.formatoJSON {
+		handler = slog.NewJSONHandler(os.Stderr, handlerOpts)
+	} else {
+		handler = slog.NewTextHandler(os.Stderr, handlerOpts)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
@@ -1,16 +1,32 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"video-processor/internal"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"video-processor/internal/monitor"
+	"video-processor/pkg/denoise"
 
 	"gocv.io/x/gocv"
 )
 
 func carregarVideo(caminho string) [][][]uint8 {
+	if ehY4M(caminho) {
+		frames, header, err := denoise.ReadY4MFile(caminho)
+		if err != nil {
+			slog.Error("erro ao ler Y4M", "path", caminho, "err", err)
+			return nil
+		}
+		slog.Info("vídeo lido", "path", caminho, "width", header.Width, "height", header.Height, "frames", len(frames))
+		return frames
+	}
+
 	captura, err := gocv.VideoCaptureFile(caminho)
 	if err != nil || !captura.IsOpened() {
-		fmt.Println("Vídeo está sendo processado por outra aplicação")
+		slog.Error("não foi possível abrir o vídeo; pode estar em uso por outra aplicação", "path", caminho, "err", err)
 		return nil
 	}
 	defer captura.Close()
@@ -34,41 +50,55 @@ func carregarVideo(caminho string) [][][]uint8 {
 		gocv.CvtColor(matRGB, &matCinza, gocv.ColorBGRToGray)
 
 		// Copiar os pixels
-		pixels := make([][]uint8, altura)
+		pixels := denoise.NewFrame(altura, largura)
 		for y := 0; y < altura; y++ {
 			row := matCinza.RowRange(y, y+1)
-			pixels[y] = make([]uint8, largura)
 			copy(pixels[y], row.ToBytes())
 			row.Close()
 		}
 		frames = append(frames, pixels)
 	}
 
-	fmt.Printf("%d x %d, %d frames\n", largura, altura, len(frames))
+	slog.Info("vídeo lido", "path", caminho, "width", largura, "height", altura, "frames", len(frames))
 	return frames
 }
 
-func gravarVideo(frames [][][]uint8, caminho string, fps float64) {
+// gravarVideo grava os quadros em caminho, como Y4M ou com o codec informado. Retorna erro se o
+// arquivo não puder ser aberto ou se algum quadro não for gravado, para que uma saída incompleta
+// não seja tratada como concluída.
+func gravarVideo(frames [][][]uint8, caminho, codec string, fps float64) (err error) {
 	if len(frames) == 0 {
-		fmt.Println("Nenhum frame para gravar")
-		return
+		return fmt.Errorf("nenhum frame para gravar em %s", caminho)
+	}
+
+	if ehY4M(caminho) {
+		if err := denoise.WriteY4MFile(caminho, frames, fps); err != nil {
+			return fmt.Errorf("gravando Y4M %s: %w", caminho, err)
+		}
+		return nil
 	}
 
 	altura := len(frames[0])
 	largura := len(frames[0][0])
 	numBytes := largura * altura * 3
 
-	writer, err := gocv.VideoWriterFile(caminho, "avc1", fps, largura, altura, true)
+	writer, err := gocv.VideoWriterFile(caminho, codec, fps, largura, altura, true)
 	if err != nil {
-		fmt.Println("Erro ao abrir escritor de vídeo:", err)
-		return
+		return fmt.Errorf("abrindo escritor de vídeo %s: %w", caminho, err)
+	}
+	defer func() {
+		if errFechar := writer.Close(); errFechar != nil && err == nil {
+			err = fmt.Errorf("fechando %s: %w", caminho, errFechar)
+		}
+	}()
+	if !writer.IsOpened() {
+		return fmt.Errorf("não foi possível abrir %s com o codec %s", caminho, codec)
 	}
-	defer writer.Close()
 
 	matRGB := gocv.NewMatWithSize(altura, largura, gocv.MatTypeCV8UC3)
 	defer matRGB.Close()
 
-	for _, frame := range frames {
+	for i, frame := range frames {
 		// Cria um slice com os dados RGB de um frame completo
 		data := make([]byte, numBytes)
 		idx := 0
@@ -84,105 +114,130 @@ func gravarVideo(frames [][][]uint8, caminho string, fps float64) {
 
 		mat, err := gocv.NewMatFromBytes(altura, largura, gocv.MatTypeCV8UC3, data)
 		if err != nil {
-			fmt.Println("Erro ao criar Mat do frame:", err)
-			continue
+			return fmt.Errorf("criando Mat do frame %d de %s: %w", i, caminho, err)
 		}
-		writer.Write(mat)
+		err = writer.Write(mat)
 		mat.Close()
+		if err != nil {
+			return fmt.Errorf("gravando o frame %d de %s: %w", i, caminho, err)
+		}
 	}
+	return nil
 }
 
-func main() {
-	fmt.Println("ola mundo")
-	caminhoVideo := "./videos/video.mp4"
-	caminhoSaida := "./videos/video2.mp4"
-	caminhoSaidaOriginal := "./videos/video3.mp4"
-	fps := 24.0
-
-	fmt.Println("→ Lendo", caminhoVideo)
-	pixels := carregarVideo(caminhoVideo)
-	pixels = pixels[400:640]
-
-	gravarVideo(pixels, caminhoSaidaOriginal, fps)
+// ehY4M indica se o caminho é de um arquivo YUV4MPEG2, lido e gravado sem codec.
+func ehY4M(caminho string) bool {
+	return strings.EqualFold(filepath.Ext(caminho), ".y4m")
+}
 
-	if len(pixels) > 0 {
-		fmt.Printf("Frames: %d   Resolução: %dx%d\n", len(pixels), len(pixels[0][0]), len(pixels[0]))
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		os.Exit(executarCompare(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "batch" {
+		os.Exit(executarBatch(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		os.Exit(executarServe(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "watch" {
+		os.Exit(executarWatch(os.Args[2:]))
 	}
 
-	filaProcessamento := make(chan internal.FrameIndentifier)
-	filaFramesProcessados := make(chan internal.FrameIndentifier, len(pixels))
-
-	go func() {
-		for i, frame := range pixels {
-			filaProcessamento <- internal.FrameIndentifier{
-				Id:     i,
-				Pixels: frame,
-			}
+	params := denoise.DefaultParams()
+	spatialParams := &params.Spatial
+	modoEspacial := flag.String("spatial", spatialParams.Mode.String(), "filtro espacial: adaptive, bilateral ou nlmeans")
+	flag.IntVar(&params.SpatialPasses, "spatial-passes", params.SpatialPasses, "quantas vezes o filtro espacial é aplicado a cada frame")
+	flag.IntVar(&spatialParams.Radius, "radius", spatialParams.Radius, "raio da vizinhança do filtro adaptativo")
+	flag.IntVar(&spatialParams.Bilateral.Radius, "bilateral-radius", spatialParams.Bilateral.Radius, "raio da janela do filtro bilateral")
+	flag.Float64Var(&spatialParams.Bilateral.SigmaSpatial, "sigma-spatial", spatialParams.Bilateral.SigmaSpatial, "sigma espacial do filtro bilateral")
+	flag.Float64Var(&spatialParams.Bilateral.SigmaRange, "sigma-range", spatialParams.Bilateral.SigmaRange, "sigma de intensidade do filtro bilateral")
+	flag.IntVar(&spatialParams.NLMeans.PatchRadius, "nlm-patch", spatialParams.NLMeans.PatchRadius, "raio do patch do non-local means")
+	flag.IntVar(&spatialParams.NLMeans.SearchRadius, "nlm-search", spatialParams.NLMeans.SearchRadius, "raio da janela de busca do non-local means")
+	flag.Float64Var(&spatialParams.NLMeans.H, "nlm-h", spatialParams.NLMeans.H, "intensidade (h) do non-local means")
+	flag.BoolVar(&spatialParams.NLMeans.Fast, "nlm-fast", spatialParams.NLMeans.Fast, "usa a variante com imagem integral do non-local means")
+	flag.IntVar(&params.TemporalWindow, "temporal-window", params.TemporalWindow, "frames anteriores usados pelo TimeTravaler (0 desliga o estágio)")
+	flag.BoolVar(&params.AutoStrength, "auto-strength", params.AutoStrength, "estima o ruído do vídeo e ajusta limiares e alfas dos filtros adaptativo e temporal")
+	stParams := &params.SpatioTemporal
+	flag.BoolVar(&params.Joint, "joint", params.Joint, "usa o denoiser espaço-temporal conjunto no lugar dos estágios espacial e temporal")
+	flag.IntVar(&stParams.PatchRadius, "joint-patch", stParams.PatchRadius, "raio do patch do denoiser conjunto")
+	flag.IntVar(&stParams.SearchRadius, "joint-search", stParams.SearchRadius, "raio de busca em cada frame do denoiser conjunto")
+	flag.IntVar(&stParams.TemporalRadius, "joint-temporal", stParams.TemporalRadius, "frames vizinhos pesquisados antes e depois do frame atual")
+	flag.Float64Var(&stParams.H, "joint-h", stParams.H, "intensidade (h) do denoiser conjunto")
+	modoVisual := flag.String("visualize", denoise.VisualNone.String(), "saída de revisão: none, side-by-side (original | processado) ou diff (diferença amplificada)")
+	ganhoDiferenca := flag.Float64("diff-gain", denoise.DefaultDiffGain, "ganho aplicado à diferença absoluta no modo diff")
+	caminhoVisual := flag.String("visualize-out", "./videos/video4.mp4", "arquivo da saída de revisão")
+	dirDecisoes := flag.String("decisions-dir", "", "se definido, grava o mapa de decisões do TimeTravaler de cada frame (PNG) e as contagens (decisions.csv) neste diretório")
+	caminhoStatsJSON := flag.String("stats-json", "", "se definido, grava as estatísticas por frame da execução neste arquivo JSON")
+	caminhoStatsCSV := flag.String("stats-csv", "", "se definido, grava as estatísticas por frame da execução neste arquivo CSV")
+	listarFiltros := flag.Bool("list-filters", false, "lista os filtros registrados e sai")
+	caminhoConfig := flag.String("config", "", "arquivo JSON com o trabalho completo (entrada, quadros, filtros, saída e relatórios); substitui as flags de filtros")
+	apenasVerificar := flag.Bool("check", false, "valida a configuração, imprime o pipeline resolvido e sai")
+	enderecoMetricas := flag.String("metrics-addr", "", "se definido, expõe /metrics (Prometheus) neste endereço durante o processamento")
+	intervaloCheckpoint := flag.Int("checkpoint-every", 0, "grava um checkpoint a cada n quadros, com os quadros prontos num spool ao lado da saída (0 desliga)")
+	retomar := flag.Bool("resume", false, "continua do checkpoint de uma execução interrompida com a mesma configuração; exige -checkpoint-every")
+	flagsLog := registrarFlagsLog(flag.CommandLine)
+	flag.Parse()
+	if err := flagsLog.configurar(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *retomar && *intervaloCheckpoint <= 0 {
+		fmt.Fprintln(os.Stderr, "-resume exige -checkpoint-every maior que zero")
+		os.Exit(2)
+	}
+	if *listarFiltros {
+		for _, nome := range denoise.FilterNames() {
+			fmt.Println(nome)
 		}
-		close(filaProcessamento)
-	}()
+		return
+	}
 
-	go func() {
-		for range 22 {
-			go func() {
-				for {
-					frame, ok := <-filaProcessamento
-					if !ok {
-						break
-					}
-					altura := len(frame.Pixels)
-					largura := len(frame.Pixels[0])
-					frameCopy := make(internal.Frame, altura)
-					fmt.Println("Frame ", frame.Id)
-
-					for y := range frameCopy {
-						frameCopy[y] = make([]uint8, largura)
-					}
-
-					for range 10 {
-						for y, row := range frame.Pixels {
-							for x := range row {
-								radius := internal.GetPixelRadius(frame.Pixels, y, x, 1)
-								radius.ApplyAdaptiveFilter()
-								frameCopy[y][x] = radius.Pixels[radius.CenterY][radius.CenterX]
-							}
-						}
-						frame.Pixels = frameCopy
-					}
-
-					filaFramesProcessados <- frame
-				}
-			}()
+	var config denoise.JobConfig
+	if *caminhoConfig != "" {
+		var err error
+		config, err = denoise.LoadJobConfig(*caminhoConfig)
+		if err != nil {
+			slog.Error("configuração inválida", "err", err)
+			os.Exit(2)
 		}
-	}()
+	} else {
+		mode, err := denoise.ParseSpatialMode(*modoEspacial)
+		if err != nil {
+			slog.Error("parâmetro inválido", "err", err)
+			os.Exit(2)
+		}
+		spatialParams.Mode = mode
+		config, err = configuracaoDasFlags(params, denoise.ReportsConfig{
+			StatsJSON:       *caminhoStatsJSON,
+			StatsCSV:        *caminhoStatsCSV,
+			DecisionsDir:    *dirDecisoes,
+			Visualize:       *modoVisual,
+			VisualizeOutput: *caminhoVisual,
+			DiffGain:        *ganhoDiferenca,
+		})
+		if err != nil {
+			slog.Error("parâmetro inválido", "err", err)
+			os.Exit(2)
+		}
+	}
 
-	for range len(pixels) {
-		frame := <-filaFramesProcessados
-		// fmt.Println("copiando frame", frame.Id)
-		pixels[frame.Id] = frame.Pixels
-	}
-	close(filaFramesProcessados)
-
-	for frameId := range len(pixels) {
-		internal.TimeTravaler(pixels, frameId, 7)
-		fmt.Println("Frame ", frameId)
-	}
-
-	//for i, frame := range pixels {
-	//	var frameCopy [][]uint8
-	//	fmt.Println("Frame ", i)
-	//	for y, row := range frame {
-	//		frameCopy = append(frameCopy, []uint8{})
-	//		for x, _ := range row {
-	//			radius := internal.GetPixelRadius(frame, y, x, 1)
-	//			radius.ApplyMedianMask()
-	//			frameCopy[y] = append(frameCopy[y], radius.Pixels[radius.CenterY][radius.CenterX])
-	//		}
-	//	}
-	//	pixels[i] = frameCopy
-	//}
-
-	fmt.Println("→ Gravando", caminhoSaida)
-	gravarVideo(pixels, caminhoSaida, fps)
-	fmt.Println("Concluído!")
+	if *apenasVerificar {
+		resolvida, err := config.Resolved()
+		if err == nil {
+			err = resolvida.WriteJSON(os.Stdout)
+		}
+		if err != nil {
+			slog.Error("configuração inválida", "err", err)
+			os.Exit(2)
+		}
+		return
+	}
+	opcoes := opcoesTrabalho{checkpoint: *intervaloCheckpoint, retomar: *retomar}
+	if *enderecoMetricas != "" {
+		registro := monitor.NewRegistry()
+		opcoes.observador = monitor.NewPipelineMetrics(registro)
+		servirMetricas(*enderecoMetricas, registro)
+	}
+	os.Exit(executarTrabalho(config, opcoes))
 }
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"video-processor/internal/monitor"
+)
+
+// servirMetricas inicia, em segundo plano, um servidor HTTP que expõe só /metrics.
+func servirMetricas(endereco string, registro *monitor.Registry) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", registro.Handler())
+	go func() {
+		slog.Info("métricas disponíveis", "addr", endereco, "path", "/metrics")
+		if err := http.ListenAndServe(endereco, mux); err != nil {
+			slog.Error("erro no servidor de métricas", "addr", endereco, "err", err)
+		}
+	}()
+}
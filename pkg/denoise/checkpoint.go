@@ -0,0 +1,64 @@
+package denoise
+
+import "video-processor/internal"
+
+// StreamState é o estado de Processor.ProcessStream ao fim de um bloco: o próximo quadro, a
+// estimativa de ruído, as estatísticas e o histórico dos filtros temporais.
+type StreamState = internal.StreamState
+
+// FrameSpool grava os quadros de saída num arquivo Y4M à medida que ficam prontos.
+type FrameSpool struct {
+	spool *internal.FrameSpool
+}
+
+// ErrCheckpointMismatch indica um checkpoint gravado com outros parâmetros.
+var ErrCheckpointMismatch = internal.ErrCheckpointMismatch
+
+// SaveCheckpoint grava o estado de ProcessStream em path, junto com o hash dos parâmetros
+// (JobConfig.Hash), substituindo o checkpoint anterior de forma atômica.
+func SaveCheckpoint(path, hash string, state StreamState) error {
+	return internal.SaveCheckpoint(path, hash, state)
+}
+
+// LoadCheckpoint lê um checkpoint; retorna ErrCheckpointMismatch se o hash for outro.
+func LoadCheckpoint(path, hash string) (StreamState, error) {
+	return internal.LoadCheckpoint(path, hash)
+}
+
+// CreateFrameSpool cria o spool com o cabeçalho Y4M.
+func CreateFrameSpool(path string, width, height int, fps float64) (*FrameSpool, error) {
+	spool, err := internal.CreateFrameSpool(path, width, height, fps)
+	if err != nil {
+		return nil, err
+	}
+	return &FrameSpool{spool: spool}, nil
+}
+
+// ResumeFrameSpool reabre um spool depois de frames quadros, descartando o que vier depois.
+func ResumeFrameSpool(path string, frames int) (*FrameSpool, error) {
+	spool, err := internal.ResumeFrameSpool(path, frames)
+	if err != nil {
+		return nil, err
+	}
+	return &FrameSpool{spool: spool}, nil
+}
+
+// WriteFrame acrescenta um quadro ao spool.
+func (s *FrameSpool) WriteFrame(frame Frame) error {
+	return s.spool.WriteFrame(frame)
+}
+
+// Frames retorna quantos quadros o spool tem.
+func (s *FrameSpool) Frames() int {
+	return s.spool.Frames()
+}
+
+// Sync grava no disco os quadros acrescentados, antes de um checkpoint que os conta.
+func (s *FrameSpool) Sync() error {
+	return s.spool.Sync()
+}
+
+// Close fecha o arquivo do spool.
+func (s *FrameSpool) Close() error {
+	return s.spool.Close()
+}
@@ -0,0 +1,85 @@
+package denoise
+
+import (
+	"io"
+
+	"video-processor/internal"
+)
+
+// JobConfig descreve um trabalho completo num arquivo JSON: entrada, intervalo de quadros,
+// filtros em ordem, saída e relatórios.
+type JobConfig internal.JobConfig
+
+// FrameRange seleciona os quadros [Start, End) da entrada; End 0 vai até o último quadro.
+type FrameRange = internal.FrameRange
+
+// FilterConfig é um estágio da configuração: o nome do filtro registrado e os seus parâmetros.
+type FilterConfig = internal.FilterConfig
+
+// OutputConfig descreve o vídeo de saída.
+type OutputConfig = internal.OutputConfig
+
+// ReportsConfig lista os relatórios opcionais de um trabalho.
+type ReportsConfig = internal.ReportsConfig
+
+// DefaultJobConfig retorna os valores usados para os campos ausentes do arquivo.
+func DefaultJobConfig() JobConfig {
+	return JobConfig(internal.DefaultJobConfig())
+}
+
+// ParseJobConfig lê e valida uma configuração em JSON, rejeitando campos e filtros desconhecidos.
+func ParseJobConfig(r io.Reader) (JobConfig, error) {
+	config, err := internal.ParseJobConfig(r)
+	return JobConfig(config), err
+}
+
+// DecodeJobConfig lê uma configuração em JSON sobre os valores padrão, sem validá-la.
+func DecodeJobConfig(r io.Reader) (JobConfig, error) {
+	config, err := internal.DecodeJobConfig(r)
+	return JobConfig(config), err
+}
+
+// LoadJobConfig lê e valida o arquivo de configuração; caminhos relativos são resolvidos
+// a partir do diretório do arquivo.
+func LoadJobConfig(path string) (JobConfig, error) {
+	config, err := internal.LoadJobConfig(path)
+	return JobConfig(config), err
+}
+
+// Validate verifica a configuração e constrói os filtros, rejeitando nomes desconhecidos e
+// parâmetros fora do intervalo.
+func (c JobConfig) Validate() error {
+	return internal.JobConfig(c).Validate()
+}
+
+// Resolved retorna uma cópia da configuração com todos os parâmetros de cada filtro preenchidos,
+// inclusive os padrão.
+func (c JobConfig) Resolved() (JobConfig, error) {
+	resolved, err := internal.JobConfig(c).Resolved()
+	return JobConfig(resolved), err
+}
+
+// Hash identifica o que determina os quadros de saída do trabalho: a entrada, o intervalo, o ajuste
+// ao ruído e os filtros com os parâmetros resolvidos. Um checkpoint só é retomado com o mesmo hash.
+func (c JobConfig) Hash() (string, error) {
+	return internal.JobConfig(c).Hash()
+}
+
+// WriteJSON escreve a configuração em JSON indentado.
+func (c JobConfig) WriteJSON(w io.Writer) error {
+	return internal.JobConfig(c).WriteJSON(w)
+}
+
+// FilterConfigs descreve estágios já construídos como FilterConfig.
+func FilterConfigs(stages []Stage) ([]FilterConfig, error) {
+	return internal.FilterConfigs(toInternalStages(stages))
+}
+
+// NewProcessorFromConfig cria um Processor com os filtros da configuração.
+func NewProcessorFromConfig(config JobConfig) (*Processor, error) {
+	pipeline, err := internal.JobConfig(config).Pipeline()
+	if err != nil {
+		return nil, err
+	}
+	return &Processor{pipeline: pipeline}, nil
+}
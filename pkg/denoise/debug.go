@@ -0,0 +1,52 @@
+package denoise
+
+import (
+	"log/slog"
+	"time"
+
+	"video-processor/internal"
+)
+
+// VisualMode identifica como a saída de revisão combina o vídeo original e o processado.
+type VisualMode = internal.VisualMode
+
+// Modos da saída de revisão.
+const (
+	VisualNone       = internal.VisualNone
+	VisualSideBySide = internal.VisualSideBySide
+	VisualDiff       = internal.VisualDiff
+)
+
+// DefaultDiffGain é o ganho padrão da imagem de diferença.
+const DefaultDiffGain = internal.DefaultDiffGain
+
+// ParseVisualMode converte "none", "side-by-side" ou "diff" no VisualMode correspondente.
+func ParseVisualMode(name string) (VisualMode, error) {
+	return internal.ParseVisualMode(name)
+}
+
+// Visualize gera o vídeo de revisão (lado a lado ou diferença amplificada por gain),
+// com o número de cada quadro desenhado no canto superior esquerdo.
+func Visualize(original, processed VideoFrames, mode VisualMode, gain float64) (VideoFrames, error) {
+	return internal.VisualizeVideo(original, processed, mode, gain)
+}
+
+// ProgressReporter registra quadros concluídos, fps e tempo estimado por estágio.
+// Seu método Frame pode ser usado como Processor.OnFrame.
+type ProgressReporter struct {
+	reporter *internal.ProgressReporter
+}
+
+// DefaultProgressInterval é o intervalo mínimo entre registros de progresso do mesmo estágio.
+const DefaultProgressInterval = internal.DefaultProgressInterval
+
+// NewProgressReporter cria um reporter para um vídeo de total quadros.
+func NewProgressReporter(logger *slog.Logger, total int, interval time.Duration) *ProgressReporter {
+	return &ProgressReporter{reporter: internal.NewProgressReporter(logger, total, interval)}
+}
+
+// Frame registra que o quadro frameID terminou o estágio stage. Pode ser chamado de várias
+// goroutines ao mesmo tempo.
+func (r *ProgressReporter) Frame(stage string, frameID int) {
+	r.reporter.Frame(stage, frameID)
+}
@@ -0,0 +1,36 @@
+// Package denoise é a API pública do processador de vídeo: restauração de vídeos em escala de
+// cinza com filtros espaciais (adaptativo, bilateral, non-local means), o filtro temporal
+// TimeTravaler e o denoiser espaço-temporal conjunto.
+//
+// Um quadro (Frame) é uma matriz de luminância [altura][largura]uint8 e um vídeo (VideoFrames)
+// é uma sequência de quadros de mesmas dimensões.
+//
+// O uso típico é montar um Processor com os parâmetros desejados e ligá-lo a uma fonte e a um
+// destino de quadros:
+//
+//	params := denoise.DefaultParams()
+//	params.Spatial.Mode = denoise.SpatialBilateral
+//	processor, err := denoise.NewProcessor(params)
+//	if err != nil {
+//		return err
+//	}
+//	source, err := denoise.NewY4MSource(input)
+//	if err != nil {
+//		return err
+//	}
+//	sink, err := denoise.NewY4MSink(output, source.Header().Width, source.Header().Height, source.Header().FPS())
+//	if err != nil {
+//		return err
+//	}
+//	result, err := processor.Process(ctx, source, sink)
+//
+// Os filtros também podem ser usados isoladamente, quadro a quadro (ApplyAdaptiveFilterFrame,
+// ApplyBilateralFilter, ApplyNLMeans) ou sobre o vídeo inteiro (TimeTravaler,
+// ApplySpatioTemporalNLMeansVideo).
+//
+// Só os tipos de dados simples (quadros, parâmetros dos filtros, estatísticas e seções da
+// configuração de job) são apelidos dos tipos internos. Filtros, estágios, Processor, resultados e
+// os tipos com comportamento (fontes, destinos, spool e relatórios) são tipos próprios deste pacote,
+// convertidos na fronteira com internal, de modo que seus métodos não mudam junto com o pipeline.
+// Renomear um campo de um tipo apelidado ainda muda esta API.
+package denoise
@@ -0,0 +1,41 @@
+package denoise_test
+
+import (
+	"context"
+	"fmt"
+
+	"video-processor/pkg/denoise"
+)
+
+func ExampleProcessor() {
+	// Um vídeo curto e estático, com um pixel de ruído no último quadro.
+	video := make(denoise.VideoFrames, 8)
+	for i := range video {
+		video[i] = denoise.Frame{
+			{100, 100, 100, 100},
+			{100, 100, 100, 100},
+			{100, 100, 100, 100},
+			{100, 100, 100, 100},
+		}
+	}
+	video[7][1][1] = 180
+
+	params := denoise.DefaultParams()
+	params.AutoStrength = false
+	processor, err := denoise.NewProcessor(params)
+	if err != nil {
+		panic(err)
+	}
+
+	sink := &denoise.SliceSink{}
+	result, err := processor.Process(context.Background(), denoise.NewSliceSource(video), sink)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(result.Frames, "quadros")
+	fmt.Println("pixel ruidoso:", video[7][1][1], "->", sink.Frames[7][1][1])
+	// Output:
+	// 8 quadros
+	// pixel ruidoso: 180 -> 100
+}
@@ -0,0 +1,190 @@
+package denoise
+
+import "video-processor/internal"
+
+// Filter é o que todo filtro implementa; além disso, cada filtro implementa SpatialFilter,
+// TemporalFilter ou PointFilter, conforme o Kind.
+type Filter interface {
+	// Name é o nome com que o filtro foi registrado.
+	Name() string
+	Kind() FilterKind
+	// Config retorna os parâmetros do filtro, no mesmo formato aceito pela sua FilterFactory.
+	Config() any
+}
+
+// SpatialFilter filtra um quadro inteiro e retorna um novo quadro, sem modificar a entrada.
+type SpatialFilter interface {
+	Filter
+	ApplyFrame(frame Frame) Frame
+}
+
+// TemporalFilter filtra um quadro usando os quadros vizinhos.
+type TemporalFilter interface {
+	Filter
+	// ApplyTemporal retorna o quadro frameID filtrado. input são os quadros que entraram no estágio
+	// e processed os mesmos quadros com 0..frameID-1 já filtrados por este estágio. Nenhum dos dois
+	// pode ser modificado.
+	ApplyTemporal(input, processed VideoFrames, frameID int) Frame
+}
+
+// PointFilter transforma cada pixel isoladamente.
+type PointFilter interface {
+	Filter
+	ApplyPixel(value uint8) uint8
+}
+
+// NoiseScalable é implementado por filtros registrados fora deste pacote cuja força pode ser
+// ajustada pelo ruído estimado. Os filtros embutidos são ajustados pelo próprio Processor.
+type NoiseScalable interface {
+	ScaledForNoise(sigma float64) Filter
+}
+
+// FilterKind classifica os filtros pela vizinhança que leem.
+type FilterKind = internal.FilterKind
+
+// Tipos de filtro.
+const (
+	FilterSpatial  = internal.FilterSpatial
+	FilterTemporal = internal.FilterTemporal
+	FilterPoint    = internal.FilterPoint
+)
+
+// Nomes dos filtros embutidos.
+const (
+	FilterNameAdaptive       = internal.FilterNameAdaptive
+	FilterNameBilateral      = internal.FilterNameBilateral
+	FilterNameNLMeans        = internal.FilterNameNLMeans
+	FilterNameTimeTravaler   = internal.FilterNameTimeTravaler
+	FilterNameSpatioTemporal = internal.FilterNameSpatioTemporal
+	FilterNameGamma          = internal.FilterNameGamma
+)
+
+// FilterFactory cria um filtro configurado; decode preenche o valor apontado com a configuração.
+type FilterFactory func(decode func(config any) error) (Filter, error)
+
+// Stage é um filtro do pipeline, com o nome do estágio e o número de passadas.
+type Stage struct {
+	// Name identifica o estágio em Processor.OnFrame e nas estatísticas. Vazio, usa o nome do filtro.
+	Name   string
+	Filter Filter
+	// Passes é quantas vezes um filtro espacial ou pontual é reaplicado a cada quadro.
+	// Filtros temporais sempre rodam uma vez.
+	Passes int
+}
+
+// RegisterFilter registra uma factory sob o nome informado, normalmente num init.
+// Entra em pânico se o nome já estiver registrado.
+func RegisterFilter(name string, factory FilterFactory) {
+	internal.RegisterFilter(name, func(decode func(config any) error) (internal.Filter, error) {
+		filter, err := factory(decode)
+		if err != nil {
+			return nil, err
+		}
+		return toInternalFilter(filter), nil
+	})
+}
+
+// NewFilter cria o filtro registrado sob name. Com decode nil, o filtro usa os parâmetros padrão.
+func NewFilter(name string, decode func(config any) error) (Filter, error) {
+	filter, err := internal.NewFilter(name, decode)
+	if err != nil {
+		return nil, err
+	}
+	return fromInternalFilter(filter), nil
+}
+
+// FilterNames retorna os nomes dos filtros registrados, em ordem alfabética.
+func FilterNames() []string {
+	return internal.FilterNames()
+}
+
+// ApplyPointFilter aplica um PointFilter a todos os pixels e retorna um novo quadro.
+func ApplyPointFilter(frame Frame, f PointFilter) Frame {
+	return internal.ApplyPointFilter(frame, f)
+}
+
+// toInternalFilter converte um filtro desta API para o pipeline. Os métodos de Filter e dos tipos
+// de filtro são os mesmos dos dois lados; só NoiseScalable difere, porque o pipeline espera o filtro
+// ajustado como internal.Filter, então filtros de fora que o implementam ganham um adaptador.
+func toInternalFilter(f Filter) internal.Filter {
+	if _, ok := f.(NoiseScalable); !ok {
+		return f
+	}
+	switch f.Kind() {
+	case FilterSpatial:
+		if spatial, ok := f.(SpatialFilter); ok {
+			return scalableSpatialFilter{spatial}
+		}
+	case FilterTemporal:
+		if temporal, ok := f.(TemporalFilter); ok {
+			return scalableTemporalFilter{temporal}
+		}
+	case FilterPoint:
+		if point, ok := f.(PointFilter); ok {
+			return scalablePointFilter{point}
+		}
+	}
+	// Um filtro que não implementa a interface do seu Kind é rejeitado pelo pipeline.
+	return f
+}
+
+// fromInternalFilter converte um filtro do pipeline para esta API, desfazendo os adaptadores de
+// toInternalFilter.
+func fromInternalFilter(f internal.Filter) Filter {
+	switch f := f.(type) {
+	case scalableSpatialFilter:
+		return f.SpatialFilter
+	case scalableTemporalFilter:
+		return f.TemporalFilter
+	case scalablePointFilter:
+		return f.PointFilter
+	}
+	return f
+}
+
+// scaleFilter ajusta ao ruído um filtro de fora que implementa NoiseScalable.
+func scaleFilter(f Filter, sigma float64) internal.Filter {
+	return toInternalFilter(f.(NoiseScalable).ScaledForNoise(sigma))
+}
+
+// scalableSpatialFilter adapta ao pipeline um SpatialFilter de fora que implementa NoiseScalable.
+type scalableSpatialFilter struct{ SpatialFilter }
+
+// ScaledForNoise implementa internal.NoiseScalable.
+func (f scalableSpatialFilter) ScaledForNoise(sigma float64) internal.Filter {
+	return scaleFilter(f.SpatialFilter, sigma)
+}
+
+// scalableTemporalFilter adapta ao pipeline um TemporalFilter de fora que implementa NoiseScalable.
+type scalableTemporalFilter struct{ TemporalFilter }
+
+// ScaledForNoise implementa internal.NoiseScalable.
+func (f scalableTemporalFilter) ScaledForNoise(sigma float64) internal.Filter {
+	return scaleFilter(f.TemporalFilter, sigma)
+}
+
+// scalablePointFilter adapta ao pipeline um PointFilter de fora que implementa NoiseScalable.
+type scalablePointFilter struct{ PointFilter }
+
+// ScaledForNoise implementa internal.NoiseScalable.
+func (f scalablePointFilter) ScaledForNoise(sigma float64) internal.Filter {
+	return scaleFilter(f.PointFilter, sigma)
+}
+
+// toInternalStages converte estágios desta API para o pipeline.
+func toInternalStages(stages []Stage) []internal.Stage {
+	converted := make([]internal.Stage, len(stages))
+	for i, stage := range stages {
+		converted[i] = internal.Stage{Name: stage.Name, Filter: toInternalFilter(stage.Filter), Passes: stage.Passes}
+	}
+	return converted
+}
+
+// fromInternalStages converte estágios do pipeline para esta API.
+func fromInternalStages(stages []internal.Stage) []Stage {
+	converted := make([]Stage, len(stages))
+	for i, stage := range stages {
+		converted[i] = Stage{Name: stage.Name, Filter: fromInternalFilter(stage.Filter), Passes: stage.Passes}
+	}
+	return converted
+}
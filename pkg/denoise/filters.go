@@ -0,0 +1,51 @@
+package denoise
+
+import "video-processor/internal"
+
+// ApplyAdaptiveFilterFrame aplica o filtro adaptativo a todos os pixels do quadro e retorna um
+// novo quadro. O quadro de entrada não é modificado.
+func ApplyAdaptiveFilterFrame(frame Frame, radius int, params AdaptiveParams) Frame {
+	return internal.ApplyAdaptiveFilterFrameWithParams(frame, radius, params)
+}
+
+// ApplyBilateralFilter aplica o filtro bilateral ao quadro e retorna um novo quadro.
+func ApplyBilateralFilter(frame Frame, params BilateralParams) (Frame, error) {
+	filter, err := internal.NewBilateralFilter(params)
+	if err != nil {
+		return nil, err
+	}
+	return filter.ApplyFrame(frame), nil
+}
+
+// ApplyNLMeans aplica o non-local means ao quadro e retorna um novo quadro.
+func ApplyNLMeans(frame Frame, params NLMeansParams) (Frame, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return internal.ApplyNLMeans(frame, params), nil
+}
+
+// NewSpatialFilter retorna o filtro espacial descrito pelos parâmetros, como função de quadro para quadro.
+func NewSpatialFilter(params SpatialParams) (func(Frame) Frame, error) {
+	return internal.NewSpatialFilter(params)
+}
+
+// TimeTravaler aplica o filtro temporal ao quadro currentFrame, usando os previousFrames quadros
+// anteriores como histórico. O quadro é modificado no lugar, dentro de videoFrames.
+func TimeTravaler(videoFrames VideoFrames, currentFrame, previousFrames int, params TemporalParams) {
+	internal.TimeTravalerWithParams(videoFrames, currentFrame, previousFrames, params)
+}
+
+// ApplySpatioTemporalNLMeansVideo aplica o denoiser espaço-temporal conjunto a todos os quadros
+// e retorna um novo vídeo.
+func ApplySpatioTemporalNLMeansVideo(videoFrames VideoFrames, params SpatioTemporalParams) (VideoFrames, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return internal.ApplySpatioTemporalNLMeansVideo(videoFrames, params), nil
+}
+
+// EstimateNoise estima o desvio padrão do ruído de cada quadro e do vídeo inteiro.
+func EstimateNoise(videoFrames VideoFrames) NoiseEstimate {
+	return internal.EstimateClipNoise(videoFrames)
+}
@@ -0,0 +1,143 @@
+package denoise
+
+import (
+	"fmt"
+	"io"
+
+	"video-processor/internal"
+)
+
+// Source fornece quadros em ordem. ReadFrame retorna io.EOF quando não há mais quadros.
+type Source interface {
+	ReadFrame() (Frame, error)
+}
+
+// Sink recebe quadros processados, em ordem.
+type Sink interface {
+	WriteFrame(frame Frame) error
+}
+
+// SliceSource é uma Source sobre quadros já em memória.
+type SliceSource struct {
+	frames VideoFrames
+	next   int
+}
+
+// NewSliceSource cria uma Source que entrega os quadros informados.
+func NewSliceSource(frames VideoFrames) *SliceSource {
+	return &SliceSource{frames: frames}
+}
+
+// ReadFrame retorna o próximo quadro, ou io.EOF.
+func (s *SliceSource) ReadFrame() (Frame, error) {
+	if s.next >= len(s.frames) {
+		return nil, io.EOF
+	}
+	frame := s.frames[s.next]
+	s.next++
+	return frame, nil
+}
+
+// SliceSink é um Sink que acumula os quadros em memória.
+type SliceSink struct {
+	Frames VideoFrames
+}
+
+// WriteFrame acrescenta o quadro a Frames.
+func (s *SliceSink) WriteFrame(frame Frame) error {
+	s.Frames = append(s.Frames, frame)
+	return nil
+}
+
+// Y4MHeader descreve o cabeçalho de um fluxo YUV4MPEG2.
+type Y4MHeader = internal.Y4MHeader
+
+// Y4MSource lê quadros de um fluxo Y4M; só o plano de luminância é mantido.
+type Y4MSource struct {
+	reader *internal.Y4MReader
+}
+
+// NewY4MSource lê o cabeçalho do fluxo e retorna a Source.
+func NewY4MSource(r io.Reader) (*Y4MSource, error) {
+	reader, err := internal.NewY4MReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Y4MSource{reader: reader}, nil
+}
+
+// Header retorna o cabeçalho do fluxo.
+func (s *Y4MSource) Header() Y4MHeader {
+	return s.reader.Header()
+}
+
+// ReadFrame lê o próximo quadro, ou retorna io.EOF no fim do fluxo.
+func (s *Y4MSource) ReadFrame() (Frame, error) {
+	return s.reader.ReadFrame()
+}
+
+// ReadAll lê todos os quadros restantes.
+func (s *Y4MSource) ReadAll() (VideoFrames, error) {
+	return s.reader.ReadAll()
+}
+
+// Y4MSink grava quadros num fluxo Y4M monocromático. Flush precisa ser chamado ao final.
+type Y4MSink struct {
+	writer *internal.Y4MWriter
+}
+
+// NewY4MSink grava o cabeçalho no fluxo e retorna o Sink.
+func NewY4MSink(w io.Writer, width, height int, fps float64) (*Y4MSink, error) {
+	writer, err := internal.NewY4MWriter(w, width, height, fps)
+	if err != nil {
+		return nil, err
+	}
+	return &Y4MSink{writer: writer}, nil
+}
+
+// WriteFrame grava um quadro com as dimensões do cabeçalho.
+func (s *Y4MSink) WriteFrame(frame Frame) error {
+	return s.writer.WriteFrame(frame)
+}
+
+// Flush grava no fluxo os dados ainda no buffer.
+func (s *Y4MSink) Flush() error {
+	return s.writer.Flush()
+}
+
+// ReadY4MFile lê todos os quadros de um arquivo Y4M.
+func ReadY4MFile(path string) (VideoFrames, Y4MHeader, error) {
+	return internal.ReadY4MFile(path)
+}
+
+// WriteY4MFile grava todos os quadros num arquivo Y4M monocromático.
+func WriteY4MFile(path string, frames VideoFrames, fps float64) error {
+	return internal.WriteY4MFile(path, frames, fps)
+}
+
+// readAll lê todos os quadros da fonte, verificando se têm as mesmas dimensões.
+func readAll(source Source) (VideoFrames, error) {
+	var frames VideoFrames
+	for {
+		frame, err := source.ReadFrame()
+		if err == io.EOF {
+			return frames, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("quadro %d: %w", len(frames), err)
+		}
+		if len(frames) > 0 && (len(frame) != len(frames[0]) || rowWidth(frame) != rowWidth(frames[0])) {
+			return nil, fmt.Errorf("quadro %d tem dimensões %dx%d, diferentes do primeiro (%dx%d)",
+				len(frames), rowWidth(frame), len(frame), rowWidth(frames[0]), len(frames[0]))
+		}
+		frames = append(frames, frame)
+	}
+}
+
+// rowWidth retorna a largura de um quadro, ou zero para um quadro vazio.
+func rowWidth(frame Frame) int {
+	if len(frame) == 0 {
+		return 0
+	}
+	return len(frame[0])
+}
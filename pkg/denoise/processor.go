@@ -0,0 +1,221 @@
+package denoise
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"video-processor/internal"
+)
+
+// DecisionSink recebe, para cada quadro, qual ramo do TimeTravaler decidiu cada pixel.
+type DecisionSink interface {
+	WriteDecisions(frameID int, decisions DecisionMap) error
+}
+
+// DecisionMap guarda a decisão do TimeTravaler para cada pixel de um quadro.
+type DecisionMap = internal.DecisionMap
+
+// DecisionCounts conta quantos pixels caíram em cada decisão do TimeTravaler.
+type DecisionCounts = internal.DecisionCounts
+
+// PipelineObserver recebe medições do processamento para monitoramento, como as métricas do
+// Prometheus. Os métodos podem ser chamados de várias goroutines ao mesmo tempo e não devem bloquear.
+type PipelineObserver interface {
+	// FrameDone é chamado quando um quadro termina um estágio, com o tempo gasto nele.
+	FrameDone(stage string, elapsed time.Duration)
+	// QueueDepth informa quantos quadros ainda esperam para entrar no estágio.
+	QueueDepth(stage string, depth int)
+	// Decisions recebe as contagens de decisões de um quadro num estágio temporal.
+	Decisions(stage string, counts DecisionCounts)
+	// FrameBuffers soma delta aos bytes dos quadros reaproveitados mantidos pelo processamento em
+	// andamento; ao fim de cada execução, a soma dos deltas é zero.
+	FrameBuffers(delta int64)
+}
+
+// PNGDecisionSink grava os mapas de decisão como PNG, com as contagens em decisions.csv.
+type PNGDecisionSink struct {
+	sink *internal.PNGDecisionSink
+}
+
+// NewPNGDecisionSink cria o diretório e o CSV de contagens. Close precisa ser chamado ao final.
+func NewPNGDecisionSink(dir string) (*PNGDecisionSink, error) {
+	sink, err := internal.NewPNGDecisionSink(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &PNGDecisionSink{sink: sink}, nil
+}
+
+// ResumePNGDecisionSink reabre o diretório de uma execução retomada no quadro next, mantendo no
+// CSV só as contagens dos quadros anteriores.
+func ResumePNGDecisionSink(dir string, next int) (*PNGDecisionSink, error) {
+	sink, err := internal.ResumePNGDecisionSink(dir, next)
+	if err != nil {
+		return nil, err
+	}
+	return &PNGDecisionSink{sink: sink}, nil
+}
+
+// WriteDecisions grava o mapa do quadro frameID e acrescenta as suas contagens ao CSV.
+func (s *PNGDecisionSink) WriteDecisions(frameID int, decisions DecisionMap) error {
+	return s.sink.WriteDecisions(frameID, decisions)
+}
+
+// Close grava e fecha o CSV de contagens.
+func (s *PNGDecisionSink) Close() error {
+	return s.sink.Close()
+}
+
+// FramesResult é o resultado de Processor.ProcessFrames: os quadros processados, a estimativa
+// de ruído, os parâmetros efetivos e as estatísticas por quadro.
+type FramesResult struct {
+	Frames VideoFrames   // Quadros processados; vazio em ProcessStream, que os entrega a emit.
+	Noise  NoiseEstimate // Estimativa de ruído; vazia quando nenhum filtro é ajustado pelo ruído.
+	Stages []Stage       // Estágios efetivamente usados, já escalados pelo ruído.
+	Stats  []FrameStats  // Estatísticas de cada quadro, na ordem dos quadros.
+
+	result internal.PipelineResult
+}
+
+// newFramesResult converte o resultado do pipeline.
+func newFramesResult(result internal.PipelineResult) FramesResult {
+	return FramesResult{
+		Frames: result.Frames,
+		Noise:  result.Noise,
+		Stages: fromInternalStages(result.Stages),
+		Stats:  result.Stats,
+		result: result,
+	}
+}
+
+// Report monta o relatório de estatísticas da execução.
+func (r FramesResult) Report() StatsReport {
+	return r.result.Report()
+}
+
+// Release devolve para reaproveitamento os quadros processados pelos filtros embutidos. Depois de
+// Release, Frames não pode mais ser usado.
+func (r *FramesResult) Release() {
+	r.result.Release()
+	r.Frames = nil
+}
+
+// Result é o resultado de Processor.Process.
+type Result struct {
+	Frames int           // Quadros processados e gravados no Sink.
+	Noise  NoiseEstimate // Estimativa de ruído; vazia quando AutoStrength está desligado.
+	Stages []Stage       // Estágios efetivamente usados, já escalados pelo ruído.
+	Stats  StatsReport   // Estatísticas por quadro.
+}
+
+// Processor aplica o processamento configurado a um vídeo lido de uma Source e grava o resultado num Sink.
+// Os filtros temporais precisam do vídeo inteiro, então todos os quadros são lidos antes do processamento.
+type Processor struct {
+	pipeline *internal.Pipeline
+
+	// OnFrame, se definido, é chamado sempre que um quadro termina um estágio (StageSpatial,
+	// StageTemporal ou StageJoint). Pode ser chamado de várias goroutines ao mesmo tempo.
+	OnFrame func(stage string, frameID int)
+	// Decisions, se definido, recebe o mapa de decisões do TimeTravaler de cada quadro.
+	Decisions DecisionSink
+	// Observer, se definido, recebe medições para monitoramento. Pode ser chamado de várias
+	// goroutines ao mesmo tempo.
+	Observer PipelineObserver
+	// CPU, se definido, limita quantos quadros são processados ao mesmo tempo, somando todos os
+	// Processors que compartilham o orçamento.
+	CPU *CPUBudget
+}
+
+// CPUBudget é um orçamento de CPUs compartilhado entre Processors: cada quadro em processamento
+// ocupa uma CPU do orçamento.
+type CPUBudget struct {
+	budget *internal.CPUBudget
+}
+
+// NewCPUBudget cria um orçamento de n CPUs; n < 1 equivale a 1.
+func NewCPUBudget(n int) *CPUBudget {
+	return &CPUBudget{budget: internal.NewCPUBudget(n)}
+}
+
+// NewProcessor valida os parâmetros e cria o Processor com os estágios equivalentes.
+func NewProcessor(params Params) (*Processor, error) {
+	pipeline, err := internal.NewPipeline(internal.PipelineParams(params))
+	if err != nil {
+		return nil, err
+	}
+	return &Processor{pipeline: pipeline}, nil
+}
+
+// NewProcessorFromStages cria um Processor que aplica os estágios em ordem. Com autoStrength,
+// o ruído do vídeo é estimado e os filtros que implementam NoiseScalable são ajustados a ele.
+func NewProcessorFromStages(stages []Stage, autoStrength bool) (*Processor, error) {
+	pipeline, err := internal.NewPipelineFromStages(toInternalStages(stages), autoStrength)
+	if err != nil {
+		return nil, err
+	}
+	return &Processor{pipeline: pipeline}, nil
+}
+
+// Stages retorna os estágios do Processor, antes do ajuste pelo ruído.
+func (p *Processor) Stages() []Stage {
+	return fromInternalStages(p.pipeline.Stages)
+}
+
+// Process lê todos os quadros da fonte, processa e grava os quadros resultantes no destino, em ordem.
+// O contexto é verificado entre quadros; se for cancelado, Process retorna o erro do contexto.
+func (p *Processor) Process(ctx context.Context, source Source, sink Sink) (Result, error) {
+	frames, err := readAll(source)
+	if err != nil {
+		return Result{}, fmt.Errorf("lendo a fonte: %w", err)
+	}
+
+	processed, err := p.ProcessFrames(ctx, frames)
+	if err != nil {
+		return Result{}, err
+	}
+	// Os quadros processados voltam ao pool também quando a gravação falha no meio.
+	defer processed.Release()
+
+	result := Result{Noise: processed.Noise, Stages: processed.Stages, Stats: processed.Report()}
+	for i, frame := range processed.Frames {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if err := sink.WriteFrame(frame); err != nil {
+			return result, fmt.Errorf("gravando o quadro %d: %w", i, err)
+		}
+		result.Frames++
+	}
+	return result, nil
+}
+
+// ProcessFrames processa quadros já em memória. Os quadros de entrada não são modificados.
+func (p *Processor) ProcessFrames(ctx context.Context, frames VideoFrames) (FramesResult, error) {
+	result, err := p.configured().RunContext(ctx, frames)
+	return newFramesResult(result), err
+}
+
+// ProcessStream processa os quadros em blocos a partir de state.Next, entregando cada bloco filtrado
+// a emit junto com o estado atualizado, que pode ser gravado com SaveCheckpoint para retomar depois.
+// O resultado é o mesmo de ProcessFrames, mas sem os quadros. Veja Streamable.
+func (p *Processor) ProcessStream(ctx context.Context, frames VideoFrames, state *StreamState, emit func(frames VideoFrames, state *StreamState) error) (FramesResult, error) {
+	result, err := p.configured().RunStream(ctx, frames, state, emit)
+	return newFramesResult(result), err
+}
+
+// Streamable verifica se os estágios podem rodar com ProcessStream: o filtro espaço-temporal
+// conjunto, que lê quadros futuros, só roda com ProcessFrames.
+func (p *Processor) Streamable() error {
+	return p.pipeline.Streamable()
+}
+
+// configured retorna uma cópia do pipeline com os ganchos do Processor.
+func (p *Processor) configured() *internal.Pipeline {
+	pipeline := *p.pipeline
+	pipeline.OnFrame, pipeline.Decisions, pipeline.Observer = p.OnFrame, p.Decisions, p.Observer
+	if p.CPU != nil {
+		pipeline.CPU = p.CPU.budget
+	}
+	return &pipeline
+}
@@ -0,0 +1,219 @@
+package denoise
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// Helper function to create a small video with a gradient and a bright square
+func createTestVideo(frames, height, width int) VideoFrames {
+	video := make(VideoFrames, frames)
+	for i := range video {
+		video[i] = make(Frame, height)
+		for y := range video[i] {
+			video[i][y] = make([]uint8, width)
+			for x := range video[i][y] {
+				video[i][y][x] = uint8(40 + 4*x + (i+y)%3)
+			}
+		}
+		video[i][height/2][width/2] = 220
+	}
+	return video
+}
+
+func TestProcessor_ProcessMatchesProcessFrames(t *testing.T) {
+	video := createTestVideo(9, 12, 16)
+	processor, err := NewProcessor(DefaultParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := processor.ProcessFrames(context.Background(), video)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sink := &SliceSink{}
+	result, err := processor.Process(context.Background(), NewSliceSource(video), sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Frames != len(video) || len(sink.Frames) != len(video) {
+		t.Fatalf("wrote %d frames (result says %d), expected %d", len(sink.Frames), result.Frames, len(video))
+	}
+	if len(result.Stats.PerFrame) != len(video) {
+		t.Errorf("%d stats entries, expected %d", len(result.Stats.PerFrame), len(video))
+	}
+	for i := range video {
+		for y := range video[i] {
+			if !bytes.Equal(sink.Frames[i][y], expected.Frames[i][y]) {
+				t.Fatalf("frame %d row %d differs from ProcessFrames", i, y)
+			}
+		}
+	}
+}
+
+func TestProcessor_Y4MRoundTrip(t *testing.T) {
+	video := createTestVideo(6, 8, 10)
+	var input bytes.Buffer
+	sink, err := NewY4MSink(&input, 10, 8, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, frame := range video {
+		if err := sink.WriteFrame(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	params := DefaultParams()
+	params.Spatial.Mode = SpatialBilateral
+	params.SpatialPasses = 1
+	processor, err := NewProcessor(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := NewY4MSource(&input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	outSink, err := NewY4MSink(&output, source.Header().Width, source.Header().Height, source.Header().FPS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := processor.Process(context.Background(), source, outSink); err != nil {
+		t.Fatal(err)
+	}
+	if err := outSink.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewY4MSource(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != len(video) {
+		t.Errorf("output has %d frames, expected %d", len(frames), len(video))
+	}
+}
+
+// failingSource returns an error after the first frame
+type failingSource struct{ reads int }
+
+func (s *failingSource) ReadFrame() (Frame, error) {
+	s.reads++
+	if s.reads > 1 {
+		return nil, errors.New("disco removido")
+	}
+	return createTestVideo(1, 4, 4)[0], nil
+}
+
+// failingSink returns an error when writing the second frame
+type failingSink struct{ writes int }
+
+func (s *failingSink) WriteFrame(Frame) error {
+	s.writes++
+	if s.writes > 1 {
+		return errors.New("disco cheio")
+	}
+	return nil
+}
+
+func TestProcessor_Errors(t *testing.T) {
+	params := DefaultParams()
+	params.SpatialPasses = -1
+	if _, err := NewProcessor(params); err == nil {
+		t.Error("expected error for invalid params")
+	}
+
+	processor, err := NewProcessor(DefaultParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := processor.Process(context.Background(), &failingSource{}, &SliceSink{}); err == nil {
+		t.Error("expected source error")
+	}
+
+	result, err := processor.Process(context.Background(), NewSliceSource(createTestVideo(4, 4, 4)), &failingSink{})
+	if err == nil {
+		t.Error("expected sink error")
+	}
+	if result.Frames != 1 {
+		t.Errorf("%d frames written before the sink error, expected 1", result.Frames)
+	}
+
+	mixed := append(createTestVideo(1, 4, 4), createTestVideo(1, 5, 4)...)
+	if _, err := processor.Process(context.Background(), NewSliceSource(mixed), &SliceSink{}); err == nil {
+		t.Error("expected error for frames with different sizes")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := processor.Process(ctx, NewSliceSource(createTestVideo(4, 4, 4)), &SliceSink{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, expected context.Canceled", err)
+	}
+}
+
+// scaledGain is a point filter defined outside the package whose gain follows the noise
+type scaledGain struct{ gain float64 }
+
+func (f scaledGain) Name() string     { return "scaled-gain" }
+func (f scaledGain) Kind() FilterKind { return FilterPoint }
+func (f scaledGain) Config() any      { return f.gain }
+
+func (f scaledGain) ApplyPixel(value uint8) uint8 {
+	return uint8(min(float64(value)*f.gain, 255))
+}
+
+func (f scaledGain) ScaledForNoise(sigma float64) Filter {
+	return scaledGain{gain: f.gain + 1}
+}
+
+func TestProcessor_ExternalFilterIsScaledForNoise(t *testing.T) {
+	processor, err := NewProcessorFromStages([]Stage{{Filter: scaledGain{gain: 1}, Passes: 1}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := processor.Stages()[0].Filter.(scaledGain); !ok {
+		t.Errorf("Stages() returned %T, expected the scaledGain passed in", processor.Stages()[0].Filter)
+	}
+
+	video := createTestVideo(3, 8, 8)
+	result, err := processor.ProcessFrames(context.Background(), video)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer result.Release()
+	scaled, ok := result.Stages[0].Filter.(scaledGain)
+	if !ok || scaled.gain != 2 {
+		t.Fatalf("effective stage filter = %#v, expected scaledGain with gain 2", result.Stages[0].Filter)
+	}
+	if got, expected := result.Frames[0][0][0], min(2*video[0][0][0], 255); got != expected {
+		t.Errorf("pixel = %d, expected %d from the scaled filter", got, expected)
+	}
+}
+
+func TestSliceSource(t *testing.T) {
+	source := NewSliceSource(createTestVideo(2, 2, 2))
+	for i := 0; i < 2; i++ {
+		if _, err := source.ReadFrame(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := source.ReadFrame(); err != io.EOF {
+		t.Errorf("err = %v, expected io.EOF", err)
+	}
+}
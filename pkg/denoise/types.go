@@ -0,0 +1,160 @@
+package denoise
+
+import "video-processor/internal"
+
+// Os apelidos deste pacote são só de tipos de dados; veja a nota sobre compatibilidade na
+// documentação do pacote.
+
+// Frame é um quadro em escala de cinza, indexado por [linha][coluna].
+type Frame = internal.Frame
+
+// VideoFrames é uma sequência de quadros de mesmas dimensões.
+type VideoFrames = internal.VideoFrames
+
+// NewFrame cria um quadro zerado de height x width pixels, com as linhas num único bloco de memória.
+func NewFrame(height, width int) Frame {
+	return internal.NewFrame(height, width)
+}
+
+// PixelsRadius é a vizinhança de um pixel, usada pelos filtros espaciais pixel a pixel. Pixels é
+// uma cópia da região do quadro; os métodos Apply* modificam só o pixel central dessa cópia.
+type PixelsRadius struct {
+	CenterX, CenterY       int   // Posição do pixel central dentro de Pixels.
+	OriginalY, OriginalX   int   // Posição do pixel central no quadro.
+	Pixels                 Frame // Os pixels da região.
+	XMin, XMax, YMin, YMax int   // Caixa delimitadora da região no quadro.
+}
+
+// GetPixelRadius retorna a vizinhança de raio radius ao redor do pixel (y, x), cortada nas bordas do quadro.
+func GetPixelRadius(frame Frame, y, x, radius int) PixelsRadius {
+	return PixelsRadius(internal.GetPixelRadius(frame, y, x, radius))
+}
+
+// IsEdgePixel indica se o gradiente de Sobel no pixel central passa de threshold.
+func (p PixelsRadius) IsEdgePixel(threshold float64) bool {
+	return internal.PixelsRadius(p).IsEdgePixel(threshold)
+}
+
+// CalculateVariance retorna a variância dos pixels da região.
+func (p PixelsRadius) CalculateVariance() float64 {
+	return internal.PixelsRadius(p).CalculateVariance()
+}
+
+// IsNoisePixel indica se o pixel central destoa da maioria dos vizinhos, com os limiares padrão.
+func (p PixelsRadius) IsNoisePixel() bool {
+	return internal.PixelsRadius(p).IsNoisePixel()
+}
+
+// IsNoisePixelWith é o IsNoisePixel com o limiar de similaridade e a razão mínima de vizinhos similares informados.
+func (p PixelsRadius) IsNoisePixelWith(threshold, minRatio float64) bool {
+	return internal.PixelsRadius(p).IsNoisePixelWith(threshold, minRatio)
+}
+
+// ApplyAdaptiveFilter aplica o filtro adaptativo ao pixel central, com os parâmetros padrão.
+func (p PixelsRadius) ApplyAdaptiveFilter() {
+	internal.PixelsRadius(p).ApplyAdaptiveFilter()
+}
+
+// ApplyAdaptiveFilterWithParams aplica o filtro adaptativo ao pixel central com os parâmetros
+// informados e uma classificação de borda já conhecida.
+func (p PixelsRadius) ApplyAdaptiveFilterWithParams(params AdaptiveParams, isEdge bool) {
+	internal.PixelsRadius(p).ApplyAdaptiveFilterWithParams(params, isEdge)
+}
+
+// Params define o processamento completo de um vídeo.
+type Params internal.PipelineParams
+
+// DefaultParams retorna o processamento usado pela CLI: 10 passadas do filtro adaptativo seguidas
+// do TimeTravaler com janela de 7 quadros, com força ajustada pelo ruído estimado.
+func DefaultParams() Params {
+	return Params(internal.DefaultPipelineParams())
+}
+
+// Validate verifica os parâmetros dos estágios que serão executados.
+func (p Params) Validate() error {
+	return internal.PipelineParams(p).Validate()
+}
+
+// Stages valida os parâmetros e monta os estágios equivalentes: o denoiser conjunto, ou o filtro
+// espacial seguido do TimeTravaler, omitindo os que estiverem desligados.
+func (p Params) Stages() ([]Stage, error) {
+	stages, err := internal.PipelineParams(p).Stages()
+	if err != nil {
+		return nil, err
+	}
+	return fromInternalStages(stages), nil
+}
+
+// SpatialParams define qual filtro espacial usar e seus parâmetros.
+type SpatialParams = internal.SpatialParams
+
+// SpatialMode identifica o filtro espacial.
+type SpatialMode = internal.SpatialMode
+
+// Filtros espaciais disponíveis.
+const (
+	SpatialAdaptive  = internal.SpatialAdaptive
+	SpatialBilateral = internal.SpatialBilateral
+	SpatialNLMeans   = internal.SpatialNLMeans
+)
+
+// ParseSpatialMode converte "adaptive", "bilateral" ou "nlmeans" no SpatialMode correspondente.
+func ParseSpatialMode(name string) (SpatialMode, error) {
+	return internal.ParseSpatialMode(name)
+}
+
+// AdaptiveParams são os limiares e alfas do filtro adaptativo.
+type AdaptiveParams = internal.AdaptiveParams
+
+// DefaultAdaptiveParams retorna os parâmetros padrão do filtro adaptativo.
+func DefaultAdaptiveParams() AdaptiveParams {
+	return internal.DefaultAdaptiveParams()
+}
+
+// BilateralParams são os parâmetros do filtro bilateral.
+type BilateralParams = internal.BilateralParams
+
+// DefaultBilateralParams retorna os parâmetros padrão do filtro bilateral.
+func DefaultBilateralParams() BilateralParams {
+	return internal.DefaultBilateralParams()
+}
+
+// NLMeansParams são os parâmetros do non-local means espacial.
+type NLMeansParams = internal.NLMeansParams
+
+// DefaultNLMeansParams retorna os parâmetros padrão do non-local means.
+func DefaultNLMeansParams() NLMeansParams {
+	return internal.DefaultNLMeansParams()
+}
+
+// TemporalParams são os limiares e alfas do filtro temporal TimeTravaler.
+type TemporalParams = internal.TemporalParams
+
+// DefaultTemporalParams retorna os parâmetros padrão do TimeTravaler.
+func DefaultTemporalParams() TemporalParams {
+	return internal.DefaultTemporalParams()
+}
+
+// SpatioTemporalParams são os parâmetros do denoiser espaço-temporal conjunto.
+type SpatioTemporalParams = internal.SpatioTemporalParams
+
+// DefaultSpatioTemporalParams retorna os parâmetros padrão do denoiser conjunto.
+func DefaultSpatioTemporalParams() SpatioTemporalParams {
+	return internal.DefaultSpatioTemporalParams()
+}
+
+// NoiseEstimate é a estimativa do desvio padrão do ruído de um vídeo.
+type NoiseEstimate = internal.NoiseEstimate
+
+// FrameStats são as estatísticas de um quadro numa execução.
+type FrameStats = internal.FrameStats
+
+// StatsReport é o relatório de estatísticas de uma execução, gravável em JSON ou CSV.
+type StatsReport = internal.StatsReport
+
+// Estágios informados a Processor.OnFrame.
+const (
+	StageSpatial  = internal.StageSpatial
+	StageTemporal = internal.StageTemporal
+	StageJoint    = internal.StageJoint
+)
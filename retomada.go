@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"video-processor/pkg/denoise"
+)
+
+// caminhosCheckpoint retorna o checkpoint e o spool de quadros de uma saída, gravados ao lado dela.
+func caminhosCheckpoint(saida string) (checkpoint, spool string) {
+	return saida + ".checkpoint", saida + ".spool.y4m"
+}
+
+// carregarCheckpoint lê o checkpoint de uma execução interrompida da configuração. Sem checkpoint,
+// retorna o estado zerado, que começa do início.
+func carregarCheckpoint(config denoise.JobConfig, quadros int) (denoise.StreamState, error) {
+	hash, err := config.Hash()
+	if err != nil {
+		return denoise.StreamState{}, err
+	}
+	caminhoCheckpoint, _ := caminhosCheckpoint(config.Output.Path)
+	estado, err := denoise.LoadCheckpoint(caminhoCheckpoint, hash)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		slog.Warn("nenhum checkpoint encontrado; começando do início", "path", caminhoCheckpoint)
+		return denoise.StreamState{}, nil
+	case err != nil:
+		return denoise.StreamState{}, err
+	}
+	slog.Info("retomando do checkpoint", "frame", estado.Next, "frames", quadros)
+	return estado, nil
+}
+
+// processarComCheckpoints processa os quadros em blocos a partir de estado, gravando cada bloco
+// pronto no spool e, a cada opcoes.checkpoint quadros, um checkpoint com o estado do pipeline. Com
+// um estado lido por carregarCheckpoint, o spool da execução interrompida é reaberto no quadro em
+// que ela parou. Ao final, os quadros processados são lidos de volta do spool.
+func processarComCheckpoints(ctx context.Context, config denoise.JobConfig, processador *denoise.Processor, pixels denoise.VideoFrames, estado denoise.StreamState, opcoes opcoesTrabalho) (denoise.FramesResult, error) {
+	hash, err := config.Hash()
+	if err != nil {
+		return denoise.FramesResult{}, err
+	}
+	caminhoCheckpoint, caminhoSpool := caminhosCheckpoint(config.Output.Path)
+
+	var spool *denoise.FrameSpool
+	if estado.Next > 0 {
+		spool, err = denoise.ResumeFrameSpool(caminhoSpool, estado.Next)
+	} else {
+		spool, err = denoise.CreateFrameSpool(caminhoSpool, len(pixels[0][0]), len(pixels[0]), config.Output.FPS)
+	}
+	if err != nil {
+		return denoise.FramesResult{}, fmt.Errorf("abrindo o spool: %w", err)
+	}
+
+	ultimo := estado.Next
+	resultado, err := processador.ProcessStream(ctx, pixels, &estado, func(quadros denoise.VideoFrames, estado *denoise.StreamState) error {
+		for _, quadro := range quadros {
+			if err := spool.WriteFrame(quadro); err != nil {
+				return fmt.Errorf("gravando o spool: %w", err)
+			}
+		}
+		if estado.Next-ultimo < opcoes.checkpoint && estado.Next < len(pixels) {
+			return nil
+		}
+		// Os quadros precisam estar no disco antes do checkpoint que os conta.
+		if err := spool.Sync(); err != nil {
+			return fmt.Errorf("gravando o spool: %w", err)
+		}
+		if err := denoise.SaveCheckpoint(caminhoCheckpoint, hash, *estado); err != nil {
+			return err
+		}
+		ultimo = estado.Next
+		slog.Debug("checkpoint gravado", "frame", estado.Next, "path", caminhoCheckpoint)
+		return nil
+	})
+	if errFechar := spool.Close(); err == nil {
+		err = errFechar
+	}
+	if err != nil {
+		return resultado, err
+	}
+
+	resultado.Frames, _, err = denoise.ReadY4MFile(caminhoSpool)
+	return resultado, err
+}
+
+// removerCheckpoint apaga o checkpoint e o spool de uma saída já gravada.
+func removerCheckpoint(saida string) {
+	if _, err := os.Stat(saida); err != nil {
+		slog.Warn("saída não encontrada; checkpoint mantido", "path", saida)
+		return
+	}
+	caminhoCheckpoint, caminhoSpool := caminhosCheckpoint(saida)
+	for _, caminho := range []string{caminhoCheckpoint, caminhoSpool} {
+		if err := os.Remove(caminho); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("erro ao remover o checkpoint", "path", caminho, "err", err)
+		}
+	}
+}
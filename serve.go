@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	"video-processor/internal"
+	"video-processor/internal/monitor"
+	"video-processor/internal/service"
+	"video-processor/pkg/denoise"
+)
+
+// tempoDesligamento é quanto o servidor espera as requisições em andamento ao ser desligado.
+const tempoDesligamento = 10 * time.Second
+
+// executarServe implementa o comando "serve": um servidor HTTP que recebe trabalhos (entrada no
+// armazenamento compartilhado e a configuração do pipeline), os executa numa fila persistente com
+// o mesmo processamento da CLI e disponibiliza o resultado e os relatórios para download.
+// As métricas do Prometheus ficam em /metrics.
+func executarServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	endereco := fs.String("addr", ":8080", "endereço HTTP do servidor")
+	dirDados := fs.String("data-dir", "./videos/jobs", "diretório da fila: estado, resultados e relatórios de cada trabalho")
+	raizEntrada := fs.String("input-root", "./videos", "diretório compartilhado das entradas: caminhos relativos partem dele e caminhos fora dele são rejeitados")
+	trabalhadores := fs.Int("workers", 1, "quantos trabalhos rodam ao mesmo tempo")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "uso: video-processor serve [-addr :8080] [-data-dir dir] [-input-root dir] [-workers n]")
+		fs.PrintDefaults()
+	}
+	flagsLog := registrarFlagsLog(fs)
+	fs.Parse(args)
+	if err := flagsLog.configurar(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	registro := monitor.NewRegistry()
+	metricas := monitor.NewPipelineMetrics(registro)
+	executar := func(ctx context.Context, config internal.JobConfig, progresso func(feitos, total int)) error {
+		return processarTrabalho(ctx, denoise.JobConfig(config), opcoesTrabalho{progresso: progresso, observador: metricas})
+	}
+	fila, err := service.Open(*dirDados, executar, service.Options{
+		Workers:   *trabalhadores,
+		InputRoot: *raizEntrada,
+	})
+	if err != nil {
+		slog.Error("erro ao abrir a fila", "dir", *dirDados, "err", err)
+		return 1
+	}
+
+	trabalhos := registro.NewGaugeVec("video_processor_jobs", "Trabalhos da fila em cada estado.", "state")
+	registro.OnCollect(func() {
+		for estado, total := range fila.Counts() {
+			trabalhos.With(string(estado)).Set(float64(total))
+		}
+	})
+
+	ctx, parar := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer parar()
+	fila.Start(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", registro.Handler())
+	mux.Handle("/", service.NewHandler(fila))
+	servidor := &http.Server{Addr: *endereco, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		ctxDesligamento, cancelar := context.WithTimeout(context.Background(), tempoDesligamento)
+		defer cancelar()
+		servidor.Shutdown(ctxDesligamento)
+	}()
+
+	slog.Info("servidor iniciado", "addr", *endereco, "data_dir", *dirDados, "workers", *trabalhadores)
+	if err := servidor.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("erro no servidor", "err", err)
+		parar()
+		fila.Wait()
+		return 1
+	}
+	fila.Wait()
+	slog.Info("servidor encerrado")
+	return 0
+}
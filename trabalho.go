@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"video-processor/pkg/denoise"
+)
+
+// configuracaoDasFlags monta a configuração equivalente às flags de filtros, com a entrada,
+// o intervalo e as saídas fixos usados pela CLI antes dos arquivos de configuração.
+func configuracaoDasFlags(params denoise.Params, relatorios denoise.ReportsConfig) (denoise.JobConfig, error) {
+	stages, err := params.Stages()
+	if err != nil {
+		return denoise.JobConfig{}, err
+	}
+	filtros, err := denoise.FilterConfigs(stages)
+	if err != nil {
+		return denoise.JobConfig{}, err
+	}
+
+	config := denoise.DefaultJobConfig()
+	config.Input = "./videos/video.mp4"
+	config.Frames = denoise.FrameRange{Start: 400, End: 640}
+	config.AutoStrength = params.AutoStrength
+	config.Filters = filtros
+	config.Output.Path = "./videos/video2.mp4"
+	config.Output.Original = "./videos/video3.mp4"
+	config.Reports = relatorios
+	return config, config.Validate()
+}
+
+// opcoesTrabalho são os ganchos opcionais de processarTrabalho.
+type opcoesTrabalho struct {
+	// progresso recebe os quadros concluídos e o total, somados em todos os estágios.
+	progresso func(feitos, total int)
+	// observador recebe as medições do pipeline, para as métricas.
+	observador denoise.PipelineObserver
+	// cpu é o orçamento de CPUs compartilhado com outros trabalhos simultâneos.
+	cpu *denoise.CPUBudget
+	// checkpoint, se maior que zero, grava um checkpoint a cada tantos quadros; veja
+	// processarComCheckpoints.
+	checkpoint int
+	// retomar continua do checkpoint de uma execução interrompida.
+	retomar bool
+}
+
+// executarTrabalho executa o trabalho da CLI e retorna o código de saída do processo.
+func executarTrabalho(config denoise.JobConfig, opcoes opcoesTrabalho) int {
+	if err := config.Validate(); err != nil {
+		slog.Error("configuração inválida", "err", err)
+		return 2
+	}
+	if err := processarTrabalho(context.Background(), config, opcoes); err != nil {
+		slog.Error("erro no trabalho", "err", err)
+		return 1
+	}
+	return 0
+}
+
+// processarTrabalho lê a entrada, processa o intervalo de quadros com os filtros da configuração
+// e grava a saída e os relatórios.
+func processarTrabalho(ctx context.Context, config denoise.JobConfig, opcoes opcoesTrabalho) error {
+	processador, err := denoise.NewProcessorFromConfig(config)
+	if err != nil {
+		return err
+	}
+	processador.Observer, processador.CPU = opcoes.observador, opcoes.cpu
+	visualMode, err := denoise.ParseVisualMode(config.Reports.Visualize)
+	if err != nil {
+		return err
+	}
+	if opcoes.checkpoint > 0 {
+		if err := processador.Streamable(); err != nil {
+			return fmt.Errorf("checkpoints: %w", err)
+		}
+	}
+	estagios := processador.Stages()
+	for _, stage := range estagios {
+		slog.Info("estágio", "name", stage.Name, "filter", stage.Filter.Name(), "passes", stage.Passes, "config", stage.Filter.Config())
+	}
+
+	video := carregarVideo(config.Input)
+	if len(video) == 0 {
+		return fmt.Errorf("não foi possível ler o vídeo %s", config.Input)
+	}
+	pixels, err := config.Frames.Apply(video)
+	if err != nil {
+		return err
+	}
+
+	// Numa retomada, os quadros antes do checkpoint já foram processados e gravados.
+	var estado denoise.StreamState
+	if opcoes.checkpoint > 0 && opcoes.retomar {
+		if estado, err = carregarCheckpoint(config, len(pixels)); err != nil {
+			return err
+		}
+	}
+
+	if config.Reports.DecisionsDir != "" {
+		var sink *denoise.PNGDecisionSink
+		if estado.Next > 0 {
+			sink, err = denoise.ResumePNGDecisionSink(config.Reports.DecisionsDir, estado.Next)
+		} else {
+			sink, err = denoise.NewPNGDecisionSink(config.Reports.DecisionsDir)
+		}
+		if err != nil {
+			return fmt.Errorf("criando o diretório de decisões: %w", err)
+		}
+		processador.Decisions = sink
+	}
+
+	if config.Output.Original != "" {
+		if err := gravarVideo(pixels, config.Output.Original, config.Output.Codec, config.Output.FPS); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("processando", "frames", len(pixels), "width", len(pixels[0][0]), "height", len(pixels[0]))
+	reporter := denoise.NewProgressReporter(slog.Default(), len(pixels)-estado.Next, denoise.DefaultProgressInterval)
+	var feitos atomic.Int64
+	feitos.Store(int64(estado.Next * len(estagios)))
+	total := len(pixels) * len(estagios)
+	processador.OnFrame = func(stage string, frameID int) {
+		reporter.Frame(stage, frameID)
+		if opcoes.progresso != nil {
+			opcoes.progresso(int(feitos.Add(1)), total)
+		}
+	}
+	var resultado denoise.FramesResult
+	if opcoes.checkpoint > 0 {
+		resultado, err = processarComCheckpoints(ctx, config, processador, pixels, estado, opcoes)
+	} else {
+		resultado, err = processador.ProcessFrames(ctx, pixels)
+	}
+	if sink, ok := processador.Decisions.(*denoise.PNGDecisionSink); ok {
+		if errFechar := sink.Close(); errFechar != nil {
+			slog.Error("erro ao gravar as contagens de decisões", "err", errFechar)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	// Os quadros processados voltam ao pool para o próximo trabalho do batch, watch ou serve.
+	defer resultado.Release()
+	if len(resultado.Noise.PerFrame) > 0 {
+		slog.Info("ruído estimado", "sigma", resultado.Noise.Clip, "min", resultado.Noise.Min(),
+			"max", resultado.Noise.Max(), "scale", resultado.Noise.Scale())
+	}
+	relatorio := resultado.Report()
+	if caminho := config.Reports.StatsJSON; caminho != "" {
+		if err := gravarArquivo(caminho, relatorio.WriteJSON); err != nil {
+			slog.Error("erro ao gravar estatísticas", "path", caminho, "err", err)
+		}
+	}
+	if caminho := config.Reports.StatsCSV; caminho != "" {
+		if err := gravarArquivo(caminho, relatorio.WriteCSV); err != nil {
+			slog.Error("erro ao gravar estatísticas", "path", caminho, "err", err)
+		}
+	}
+
+	slog.Info("gravando", "path", config.Output.Path)
+	if err := gravarVideo(resultado.Frames, config.Output.Path, config.Output.Codec, config.Output.FPS); err != nil {
+		return err
+	}
+	if opcoes.checkpoint > 0 {
+		removerCheckpoint(config.Output.Path)
+	}
+
+	if visualMode != denoise.VisualNone {
+		revisao, err := denoise.Visualize(pixels, resultado.Frames, visualMode, config.Reports.DiffGain)
+		if err != nil {
+			return fmt.Errorf("visualização: %w", err)
+		}
+		slog.Info("gravando visualização", "mode", visualMode, "path", config.Reports.VisualizeOutput)
+		if err := gravarVideo(revisao, config.Reports.VisualizeOutput, config.Output.Codec, config.Output.FPS); err != nil {
+			return err
+		}
+	}
+	slog.Info("concluído")
+	return nil
+}
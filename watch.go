@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+	"video-processor/internal/batch"
+	"video-processor/internal/monitor"
+	"video-processor/internal/watch"
+	"video-processor/pkg/denoise"
+)
+
+// executarWatch implementa o comando "watch": um daemon que monitora a caixa de entrada e processa
+// cada vídeo novo com o pipeline configurado quando o arquivo para de crescer. O resultado vai para
+// a caixa de saída e o original para o arquivo; originais que falham vão para a pasta de falhas com
+// o erro num arquivo .error ao lado.
+func executarWatch(args []string) int {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	entrada := fs.String("inbox", "", "caixa de entrada monitorada (obrigatório)")
+	saida := fs.String("outbox", "", "pasta dos resultados; vazio usa outbox ao lado da entrada")
+	arquivo := fs.String("archive", "", "pasta dos originais processados; vazio usa archive ao lado da entrada")
+	falhas := fs.String("failed", "", "pasta dos originais que falharam; vazio usa failed ao lado da entrada")
+	padrao := fs.String("glob", "*.mp4", "padrão do nome dos arquivos processados (sintaxe de filepath.Match)")
+	extensao := fs.String("ext", "", "extensão das saídas, como .y4m; vazio mantém a da entrada")
+	caminhoConfig := fs.String("config", "", "configuração JSON usada como modelo (filtros, quadros, codec); input e output.path são ignorados")
+	intervalo := fs.Duration("interval", watch.DefaultInterval, "intervalo entre as varreduras da caixa de entrada")
+	estavel := fs.Duration("stable", 10*time.Second, "tempo sem mudança de tamanho para um arquivo ser considerado completo")
+	cpus := fs.Int("cpus", runtime.NumCPU(), "orçamento de CPUs: cada quadro em filtragem ocupa uma; os blocos e linhas dos quadros dividem um único pool com um worker por núcleo")
+	enderecoMetricas := fs.String("metrics-addr", "", "se definido, expõe /metrics (Prometheus) neste endereço")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "uso: video-processor watch -inbox dir [-outbox dir] [-archive dir] [-failed dir] [-config job.json]")
+		fs.PrintDefaults()
+	}
+	flagsLog := registrarFlagsLog(fs)
+	fs.Parse(args)
+	if err := flagsLog.configurar(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if *entrada == "" {
+		fs.Usage()
+		return 2
+	}
+
+	modelo, err := modeloBatch(*caminhoConfig)
+	if err != nil {
+		slog.Error("configuração inválida", "err", err)
+		return 2
+	}
+	// Valida o modelo com um arquivo fictício, para que um erro apareça antes do primeiro vídeo.
+	exemplo := batch.Task{Input: filepath.Join(*entrada, "exemplo.mp4"), Output: filepath.Join(*entrada, "exemplo.mp4")}
+	if *extensao != "" {
+		exemplo.Output = strings.TrimSuffix(exemplo.Output, ".mp4") + *extensao
+	}
+	if err := configuracaoBatch(modelo, exemplo, exemplo.Output).Validate(); err != nil {
+		slog.Error("configuração inválida", "err", err)
+		return 2
+	}
+
+	pastas := watch.Dirs{
+		Inbox:   *entrada,
+		Outbox:  pastaIrma(*saida, *entrada, "outbox"),
+		Archive: pastaIrma(*arquivo, *entrada, "archive"),
+		Failed:  pastaIrma(*falhas, *entrada, "failed"),
+	}
+	opcoes := opcoesTrabalho{cpu: denoise.NewCPUBudget(*cpus)}
+	if *enderecoMetricas != "" {
+		registro := monitor.NewRegistry()
+		opcoes.observador = monitor.NewPipelineMetrics(registro)
+		servirMetricas(*enderecoMetricas, registro)
+	}
+	monitorado, err := watch.New(pastas, func(ctx context.Context, tarefa batch.Task, saida string) error {
+		return processarTrabalho(ctx, configuracaoBatch(modelo, tarefa, saida), opcoes)
+	}, watch.Options{Pattern: *padrao, Ext: *extensao, Interval: *intervalo, StableFor: *estavel})
+	if err != nil {
+		slog.Error("erro ao preparar as pastas", "err", err)
+		return 1
+	}
+
+	ctx, parar := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer parar()
+	slog.Info("monitorando", "inbox", pastas.Inbox, "outbox", pastas.Outbox, "archive", pastas.Archive,
+		"failed", pastas.Failed, "interval", *intervalo, "stable", *estavel)
+	monitorado.Run(ctx)
+	slog.Info("monitoramento encerrado")
+	return 0
+}
+
+// pastaIrma retorna pasta ou, se vazia, a pasta nome ao lado da caixa de entrada.
+func pastaIrma(pasta, entrada, nome string) string {
+	if pasta != "" {
+		return pasta
+	}
+	return filepath.Join(filepath.Dir(filepath.Clean(entrada)), nome)
+}